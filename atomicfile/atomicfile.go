@@ -0,0 +1,47 @@
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Write replaces the file at path with data through a temporary file in the same folder, so a crash leaves
+// either the old or the new content
+func Write(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Update reads the file at path, passes its content to update and writes the result, all while holding an
+// exclusive lock on path+".lock". Processes sharing the file through Update see each others changes instead
+// of overwriting them. A missing file is passed as nil, returning nil data leaves the file as it is.
+func Update(path string, update func(data []byte) ([]byte, error)) error {
+	unlock, err := lock(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	data, err = update(data)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return nil
+	}
+	return Write(path, data)
+}
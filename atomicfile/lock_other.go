@@ -0,0 +1,13 @@
+//go:build !unix
+
+package atomicfile
+
+import "sync"
+
+var processLock sync.Mutex
+
+// lock only serializes the updates of this process, clerks sharing a file have to run on unix
+func lock(path string) (func(), error) {
+	processLock.Lock()
+	return processLock.Unlock, nil
+}
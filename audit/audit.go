@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+)
+
+const (
+	SourceGraphQL = "GRAPHQL"
+	SourceREST    = "REST"
+	// SourceWatcher marks changes the clerk made on its own, like provisioned partitions
+	SourceWatcher = "WATCHER"
+
+	// KindStatus is the archiving status of the ingest, the other kinds are the ones of the policy engine
+	KindStatus = "Status"
+)
+
+// Event records one mutating operation. Before and After are json snapshots of the entity,
+// Before is empty for creations and After for deletions.
+type Event struct {
+	ID       string          `json:"id"`
+	Time     time.Time       `json:"time"`
+	Actor    string          `json:"actor"`
+	Subject  string          `json:"subject"`
+	Source   string          `json:"source"`
+	IP       string          `json:"ip"`
+	Action   string          `json:"action"`
+	Kind     string          `json:"kind"`
+	TargetID string          `json:"targetId"`
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+}
+
+// Actor is the caller of a mutation
+type Actor struct {
+	Name    string
+	Subject string
+	Source  string
+	IP      string
+}
+
+// ActorFromClaims names the caller by its preferred username, tokens of service accounts without one
+// by the client they were issued for
+func ActorFromClaims(claims *models.KeyCloakToken, source string, ip string) Actor {
+	actor := Actor{Source: source, IP: ip}
+	if claims == nil {
+		return actor
+	}
+	actor.Subject = claims.Sub
+	if actor.Subject == "" {
+		actor.Subject = claims.Subject
+	}
+	actor.Name = claims.PreferredUsername
+	if actor.Name == "" {
+		actor.Name = claims.Azp
+	}
+	return actor
+}
+
+// Sink stores audit events, a sink never changes or removes written events
+type Sink interface {
+	Write(ctx context.Context, event Event) error
+}
+
+// Reader is a sink the trail can be read back from. Read returns take of the matching events newest first,
+// after skipping skip of them, and the number of all matching events.
+type Reader interface {
+	Read(ctx context.Context, filter Filter, skip int, take int) ([]Event, int, error)
+}
+
+// Filter selects events, empty fields match every event
+type Filter struct {
+	Actor    string
+	Action   string
+	Kind     string
+	TargetID string
+	Source   string
+	From     time.Time
+	To       time.Time
+}
+
+func (f Filter) Matches(event Event) bool {
+	return (f.Actor == "" || f.Actor == event.Actor) &&
+		(f.Action == "" || f.Action == event.Action) &&
+		(f.Kind == "" || f.Kind == event.Kind) &&
+		(f.TargetID == "" || f.TargetID == event.TargetID) &&
+		(f.Source == "" || f.Source == event.Source) &&
+		(f.From.IsZero() || !event.Time.Before(f.From)) &&
+		(f.To.IsZero() || event.Time.Before(f.To))
+}
+
+// Log writes the audit events to all its sinks. A failing sink is logged but does not fail the operation,
+// which already happened when it is recorded. A nil Log records nothing.
+type Log struct {
+	sinks  []Sink
+	logger zLogger.ZLogger
+}
+
+func NewLog(logger zLogger.ZLogger, sinks ...Sink) *Log {
+	return &Log{sinks: sinks, logger: logger}
+}
+
+// Record writes the operation of actor on the entity with the snapshots before and after it
+func (l *Log) Record(ctx context.Context, actor Actor, action string, kind string, targetId string, before any, after any) {
+	if l == nil {
+		return
+	}
+	event := Event{
+		ID:       newId(),
+		Time:     time.Now().UTC(),
+		Actor:    actor.Name,
+		Subject:  actor.Subject,
+		Source:   actor.Source,
+		IP:       actor.IP,
+		Action:   action,
+		Kind:     kind,
+		TargetID: targetId,
+	}
+	var err error
+	if event.Before, err = snapshot(before); err != nil {
+		l.logger.Error().Msgf("cannot snapshot %s %s for audit: %v", kind, targetId, err)
+	}
+	if event.After, err = snapshot(after); err != nil {
+		l.logger.Error().Msgf("cannot snapshot %s %s for audit: %v", kind, targetId, err)
+	}
+	for _, sink := range l.sinks {
+		if err := sink.Write(ctx, event); err != nil {
+			l.logger.Error().Msgf("cannot write audit event %s: %v", event.ID, err)
+		}
+	}
+}
+
+// Query returns a page of the matching events newest first from the first sink which can be read back,
+// together with the number of all matching events
+func (l *Log) Query(ctx context.Context, filter Filter, skip int, take int) ([]Event, int, error) {
+	if l != nil {
+		for _, sink := range l.sinks {
+			if reader, ok := sink.(Reader); ok {
+				return reader.Read(ctx, filter, skip, take)
+			}
+		}
+	}
+	return nil, 0, errors.New("no readable audit sink configured")
+}
+
+func snapshot(value any) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return nil, nil
+	}
+	return data, nil
+}
+
+func newId() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,187 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"sync"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/zLogger"
+)
+
+// FileSink appends the events as json lines to a file. The file is only ever appended to,
+// rotating or archiving it is left to the operator. The sink keeps an index of the events without their
+// snapshots, so a query only reads the lines of the events it returns.
+type FileSink struct {
+	lock  sync.Mutex
+	path  string
+	file  *os.File
+	size  int64
+	index []indexEntry
+}
+
+// indexEntry locates the line of an event, the snapshots are left out
+type indexEntry struct {
+	event  Event
+	offset int64
+	length int
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot open audit file %s", path)
+	}
+	s := &FileSink{path: path, file: file}
+	if err := s.buildIndex(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// buildIndex reads the existing file once, lines which cannot be parsed are skipped
+func (s *FileSink) buildIndex() error {
+	reader := bufio.NewReaderSize(io.NewSectionReader(s.file, 0, math.MaxInt64), 64*1024)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var event Event
+			if json.Unmarshal(line, &event) == nil {
+				s.index = append(s.index, indexEntry{event: withoutSnapshots(event), offset: s.size, length: len(line)})
+			}
+			s.size += int64(len(line))
+		}
+		if errors.Is(err, io.EOF) {
+			// a partly written last line is skipped, the next event is appended behind it
+			s.size += int64(len(line))
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "cannot read audit file %s", s.path)
+		}
+	}
+}
+
+func (s *FileSink) Write(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal audit event")
+	}
+	data = append(data, '\n')
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, err := s.file.Write(data); err != nil {
+		return errors.Wrapf(err, "cannot write audit file %s", s.path)
+	}
+	s.index = append(s.index, indexEntry{event: withoutSnapshots(event), offset: s.size, length: len(data)})
+	s.size += int64(len(data))
+	return nil
+}
+
+// Read matches the filter against the index and reads the lines of the returned events only
+func (s *FileSink) Read(ctx context.Context, filter Filter, skip int, take int) ([]Event, int, error) {
+	s.lock.Lock()
+	page := make([]indexEntry, 0, take)
+	total := 0
+	for i := len(s.index) - 1; i >= 0; i-- {
+		if !filter.Matches(s.index[i].event) {
+			continue
+		}
+		if total >= skip && total < skip+take {
+			page = append(page, s.index[i])
+		}
+		total++
+	}
+	s.lock.Unlock()
+	result := make([]Event, 0, len(page))
+	for _, entry := range page {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		line := make([]byte, entry.length)
+		if _, err := s.file.ReadAt(line, entry.offset); err != nil {
+			return nil, 0, errors.Wrapf(err, "cannot read audit file %s", s.path)
+		}
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, 0, errors.Wrapf(err, "cannot parse audit event %s", entry.event.ID)
+		}
+		result = append(result, event)
+	}
+	return result, total, nil
+}
+
+func (s *FileSink) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.file.Close()
+}
+
+func withoutSnapshots(event Event) Event {
+	event.Before = nil
+	event.After = nil
+	return event
+}
+
+// MemorySink keeps the latest events in memory, so the trail can be queried without an audit file.
+// Older events are dropped once it holds size events and all of them are lost on restart.
+type MemorySink struct {
+	lock   sync.Mutex
+	size   int
+	events []Event
+}
+
+func NewMemorySink(size int) *MemorySink {
+	return &MemorySink{size: size}
+}
+
+func (s *MemorySink) Write(ctx context.Context, event Event) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.events) >= s.size {
+		s.events = slices.Delete(s.events, 0, len(s.events)-s.size+1)
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *MemorySink) Read(ctx context.Context, filter Filter, skip int, take int) ([]Event, int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result := make([]Event, 0, take)
+	total := 0
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if !filter.Matches(s.events[i]) {
+			continue
+		}
+		if total >= skip && total < skip+take {
+			result = append(result, s.events[i])
+		}
+		total++
+	}
+	return result, total, nil
+}
+
+// LoggerSink sends the events to the logger, which forwards them to the log stash
+type LoggerSink struct {
+	logger zLogger.ZLogger
+}
+
+func NewLoggerSink(logger zLogger.ZLogger) *LoggerSink {
+	return &LoggerSink{logger: logger}
+}
+
+func (s *LoggerSink) Write(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal audit event")
+	}
+	s.logger.Info().Str("type", "audit").RawJSON("audit", data).Msgf("%s %s %s by %s", event.Action, event.Kind, event.TargetID, event.Actor)
+	return nil
+}
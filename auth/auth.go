@@ -1,23 +1,135 @@
 package auth
 
 import (
+	"context"
 	"fmt"
-	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/coreos/go-oidc"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
 )
 
-func tokenValid(c *gin.Context, key string) error {
-	tokenString := extractToken(c)
-	_, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+const (
+	ModeHMAC = "hmac"
+	ModeJWKS = "jwks"
+
+	// ClaimsKey is the gin context key of the verified token claims
+	ClaimsKey = "claims"
+)
+
+var defaultAlgorithms = []string{"RS256", "ES256"}
+
+// Verifier checks a raw bearer token and returns its claims
+type Verifier interface {
+	Verify(ctx context.Context, rawToken string) (*models.KeyCloakToken, error)
+}
+
+// NewVerifier creates the verifier for the configured mode. The hmac mode uses the shared jwt key,
+// the jwks mode the public keys of the keycloak realm, which are cached and refetched on rotation.
+func NewVerifier(conf models.JwtConfig, key string, keycloak models.Keycloak) (Verifier, error) {
+	switch conf.Mode {
+	case "", ModeHMAC:
+		return &hmacVerifier{key: []byte(key), conf: conf}, nil
+	case ModeJWKS:
+		realm := strings.TrimRight(keycloak.Addr, "/") + "/" + keycloak.Realm
+		if conf.JWKSURL == "" {
+			conf.JWKSURL = realm + "/protocol/openid-connect/certs"
+		}
+		if conf.Issuer == "" {
+			conf.Issuer = realm
+		}
+		if len(conf.Algorithms) == 0 {
+			conf.Algorithms = defaultAlgorithms
+		}
+		return &jwksVerifier{keySet: oidc.NewRemoteKeySet(context.Background(), conf.JWKSURL), conf: conf}, nil
+	}
+	return nil, errors.Errorf("unknown jwt mode %s", conf.Mode)
+}
+
+type hmacVerifier struct {
+	key  []byte
+	conf models.JwtConfig
+}
+
+func (v *hmacVerifier) Verify(ctx context.Context, rawToken string) (*models.KeyCloakToken, error) {
+	if len(v.key) == 0 {
+		// an empty key would accept tokens anybody can sign
+		return nil, errors.New("no jwt key configured")
+	}
+	var claims models.KeyCloakToken
+	parser := jwt.Parser{SkipClaimsValidation: true}
+	_, err := parser.ParseWithClaims(rawToken, &claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(key), nil
+		return v.key, nil
 	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := validateClaims(&claims, v.conf, false); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+type jwksVerifier struct {
+	keySet oidc.KeySet
+	conf   models.JwtConfig
+}
+
+func (v *jwksVerifier) Verify(ctx context.Context, rawToken string) (*models.KeyCloakToken, error) {
+	var claims models.KeyCloakToken
+	token, _, err := new(jwt.Parser).ParseUnverified(rawToken, &claims)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(v.conf.Algorithms, token.Method.Alg()) {
+		return nil, errors.Errorf("Unexpected signing method: %v", token.Method.Alg())
+	}
+	if _, err := v.keySet.VerifySignature(ctx, rawToken); err != nil {
+		return nil, errors.Wrap(err, "invalid token signature")
+	}
+	if err := validateClaims(&claims, v.conf, true); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// validateClaims checks exp, nbf, iss and aud, allowing the configured clock skew
+func validateClaims(claims *models.KeyCloakToken, conf models.JwtConfig, expRequired bool) error {
+	now := time.Now()
+	if claims.Exp == 0 {
+		if expRequired {
+			return errors.New("token has no expiration")
+		}
+	} else if now.Add(-conf.ClockSkew).After(time.Unix(claims.Exp, 0)) {
+		return errors.New("token is expired")
+	}
+	if claims.Nbf != 0 && now.Add(conf.ClockSkew).Before(time.Unix(claims.Nbf, 0)) {
+		return errors.New("token is not valid yet")
+	}
+	if conf.Issuer != "" && claims.Iss != conf.Issuer {
+		return errors.Errorf("unexpected token issuer %s", claims.Iss)
+	}
+	if len(conf.Audience) > 0 {
+		audience := append([]string{claims.Azp}, claims.Audience...)
+		found := false
+		for _, aud := range conf.Audience {
+			if slices.Contains(audience, aud) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("token is not issued for this audience")
+		}
 	}
 	return nil
 }
@@ -31,14 +143,25 @@ func extractToken(c *gin.Context) string {
 	return ""
 }
 
-func JwtAuthMiddleware(key string) gin.HandlerFunc {
+func JwtAuthMiddleware(verifier Verifier) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		err := tokenValid(c, key)
+		claims, err := verifier.Verify(c.Request.Context(), extractToken(c))
 		if err != nil {
 			c.String(http.StatusUnauthorized, "Unauthorized")
 			c.Abort()
 			return
 		}
+		c.Set(ClaimsKey, claims)
 		c.Next()
 	}
 }
+
+// Claims returns the claims of the token the request was authorized with
+func Claims(c *gin.Context) (*models.KeyCloakToken, bool) {
+	value, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*models.KeyCloakToken)
+	return claims, ok
+}
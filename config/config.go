@@ -3,6 +3,7 @@ package config
 import (
 	"io/fs"
 	"os"
+	"strings"
 
 	"emperror.dev/errors"
 	"github.com/BurntSushi/toml"
@@ -13,22 +14,33 @@ import (
 )
 
 type Config struct {
-	GraphQLConfig           models.GraphQLConfig `toml:"graphqlconfig"`
-	LocalAddr               string               `toml:"localaddr"`
-	Domain                  string               `toml:"domain"`
-	ExternalAddr            string               `toml:"externaladdr"`
-	Bearer                  string               `toml:"bearer"`
-	ResolverAddr            string               `toml:"resolveraddr"`
-	ResolverTimeout         config.Duration      `toml:"resolvertimeout"`
-	ResolverNotFoundTimeout config.Duration      `toml:"resolvernotfoundtimeout"`
-	ActionTimeout           config.Duration      `toml:"actiontimeout"`
-	ServerTLS               *loader.Config       `toml:"server"`
-	ClientTLS               *loader.Config       `toml:"client"`
-	GRPCClient              map[string]string    `toml:"grpcclient"`
-	Addresses               map[string]string    `toml:"addresses"`
-	NetName                 string               `toml:"netname"`
-	Log                     stashconfig.Config   `toml:"log"`
-	Jwt                     string               `toml:"jwt"`
+	GraphQLConfig           models.GraphQLConfig      `toml:"graphqlconfig"`
+	LocalAddr               string                    `toml:"localaddr"`
+	Domain                  string                    `toml:"domain"`
+	ExternalAddr            string                    `toml:"externaladdr"`
+	Bearer                  string                    `toml:"bearer"`
+	ResolverAddr            string                    `toml:"resolveraddr"`
+	ResolverTimeout         config.Duration           `toml:"resolvertimeout"`
+	ResolverNotFoundTimeout config.Duration           `toml:"resolvernotfoundtimeout"`
+	ActionTimeout           config.Duration           `toml:"actiontimeout"`
+	ServerTLS               *loader.Config            `toml:"server"`
+	ClientTLS               *loader.Config            `toml:"client"`
+	GRPCClient              map[string]string         `toml:"grpcclient"`
+	Addresses               map[string]string         `toml:"addresses"`
+	NetName                 string                    `toml:"netname"`
+	Log                     stashconfig.Config        `toml:"log"`
+	Jwt                     string                    `toml:"jwt"`
+	JwtAuth                 models.JwtConfig          `toml:"jwtauth"`
+	Session                 models.SessionConfig      `toml:"session"`
+	Audit                   models.AuditConfig        `toml:"audit"`
+	Validation              models.ValidationConfig   `toml:"validation"`
+	Partitions              models.PartitionConfig    `toml:"partitions"`
+	Provisioning            models.ProvisioningConfig `toml:"provisioning"`
+	Forecast                models.ForecastConfig     `toml:"forecast"`
+	Billing                 models.BillingConfig      `toml:"billing"`
+	Fixity                  models.FixityConfig       `toml:"fixity"`
+	Status                  models.StatusConfig       `toml:"status"`
+	Replication             models.ReplicationConfig  `toml:"replication"`
 }
 
 func LoadConfig(fSys fs.FS, fp string, conf *Config) error {
@@ -51,5 +63,11 @@ func LoadConfig(fSys fs.FS, fp string, conf *Config) error {
 	if conf.Jwt == "" {
 		conf.Jwt = os.Getenv("JWT_KEY")
 	}
+	if len(conf.Session.Keys) == 0 && os.Getenv("SESSION_KEYS") != "" {
+		conf.Session.Keys = strings.Split(os.Getenv("SESSION_KEYS"), ",")
+	}
+	if conf.Session.Redis.Password == "" {
+		conf.Session.Redis.Password = os.Getenv("SESSION_REDIS_PASSWORD")
+	}
 	return nil
 }
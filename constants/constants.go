@@ -4,7 +4,9 @@ type key string
 
 const (
 	Needed                  key    = "needed"
+	Loaders                 key    = "loaders"
 	KEYCLOAK_GROUPS_CTX     string = "keycloak_groups"
 	KEYCLOAK_ACCESS_KEY_CTX string = "access_key"
 	ADMIN_ROLE              string = ""
+	OIDC_CLIENT_CTX         string = "oidc_client"
 )
@@ -0,0 +1,14 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
+	"github.com/ocfl-archive/dlza-manager-clerk/auth"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+)
+
+// record writes the operation of the caller of the request to the audit trail
+func record(ctx *gin.Context, auditLog *audit.Log, action policy.Action, kind string, id string, before any, after any) {
+	claims, _ := auth.Claims(ctx)
+	auditLog.Record(ctx, audit.ActorFromClaims(claims, audit.SourceREST, ctx.ClientIP()), string(action), kind, id, before, after)
+}
@@ -0,0 +1,250 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
+	"github.com/ocfl-archive/dlza-manager-clerk/auth"
+	"github.com/ocfl-archive/dlza-manager-clerk/dataloader"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	"github.com/ocfl-archive/dlza-manager-clerk/service"
+	"github.com/ocfl-archive/dlza-manager-clerk/statusowner"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+// Authorizer gates the controller actions with the tenant permissions of the request token.
+// Scoped ingest tokens have no tenant permissions, they may only read and deliver objects and statuses
+// of the collections listed in the token.
+type Authorizer struct {
+	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+	engine             *policy.Engine
+	// legacyAccess lets tokens without groups, tenants and scope act as admin, as the shared key tokens
+	// issued before the tenant permissions did
+	legacyAccess bool
+	// statusOwners tells which token created a status
+	statusOwners *statusowner.Store
+}
+
+func NewAuthorizer(clientClerkHandler pbHandler.ClerkHandlerServiceClient, legacyAccess bool, statusOwners *statusowner.Store) *Authorizer {
+	return &Authorizer{
+		ClientClerkHandler: clientClerkHandler,
+		engine:             policy.NewEngine(service.NewTenantOwners(clientClerkHandler)),
+		legacyAccess:       legacyAccess,
+		statusOwners:       statusOwners,
+	}
+}
+
+// Subject returns the caller of the request as seen by the policy engine
+func (a *Authorizer) Subject(ctx *gin.Context) policy.Subject {
+	claims, ok := auth.Claims(ctx)
+	if !ok {
+		return policy.Subject{}
+	}
+	if a.legacyAccess && len(claims.Groups) == 0 && len(claims.TenantList) == 0 && claims.Scope == "" {
+		return policy.Subject{Groups: []string{policy.AdminGroup}}
+	}
+	return policy.SubjectFromClaims(claims)
+}
+
+// Allow checks action on the targets and answers the request itself if the caller is denied
+func (a *Authorizer) Allow(ctx *gin.Context, action policy.Action, targets ...policy.Target) bool {
+	subject := a.Subject(ctx)
+	if subject.Ingest {
+		a.deny(ctx, errors.Errorf("You are not allowed to proceed with %s", strings.ToLower(string(action))))
+		return false
+	}
+	if err := a.engine.Authorize(a.context(ctx), subject, action, targets); err != nil {
+		a.deny(ctx, err)
+		return false
+	}
+	return true
+}
+
+// AllowAdmin admits members of the admin group only
+func (a *Authorizer) AllowAdmin(ctx *gin.Context, action policy.Action) bool {
+	if !a.Subject(ctx).IsAdmin() {
+		a.deny(ctx, errors.Errorf("You are not allowed to proceed with %s", strings.ToLower(string(action))))
+		return false
+	}
+	return true
+}
+
+// AllowStatus admits admins and the ingest token which created the status. Statuses are not linked to a
+// collection, the creator is recorded by RecordStatusOwner.
+func (a *Authorizer) AllowStatus(ctx *gin.Context, action policy.Action, statusId string) bool {
+	subject := a.Subject(ctx)
+	if subject.IsAdmin() {
+		return true
+	}
+	denied := errors.Errorf("You are not allowed to proceed with %s", strings.ToLower(string(action)))
+	claims, ok := auth.Claims(ctx)
+	if !subject.Ingest || !ok {
+		a.deny(ctx, denied)
+		return false
+	}
+	actor := audit.ActorFromClaims(claims, audit.SourceREST, ctx.ClientIP())
+	owner, err := a.statusOwners.Owner(ctx.Request.Context(), statusId)
+	if err != nil {
+		a.deny(ctx, errors.Wrapf(err, "Could not find the creator of status %s: %v", statusId, err))
+		return false
+	}
+	if owner == "" || actor.Subject == "" || owner != actor.Subject {
+		a.deny(ctx, denied)
+		return false
+	}
+	return true
+}
+
+// RecordStatusOwner remembers the token which created the status, so AllowStatus admits it later on
+func (a *Authorizer) RecordStatusOwner(ctx *gin.Context, statusId string) error {
+	claims, _ := auth.Claims(ctx)
+	actor := audit.ActorFromClaims(claims, audit.SourceREST, ctx.ClientIP())
+	if actor.Subject == "" {
+		return nil
+	}
+	if err := a.statusOwners.Put(ctx.Request.Context(), statusId, actor.Subject); err != nil {
+		return errors.Wrapf(err, "Could not record the creator of status %s: %v", statusId, err)
+	}
+	return nil
+}
+
+// AllowIngest admits ingest tokens and admins, which are the only callers creating statuses
+func (a *Authorizer) AllowIngest(ctx *gin.Context, action policy.Action) bool {
+	subject := a.Subject(ctx)
+	if !subject.Ingest && !subject.IsAdmin() {
+		a.deny(ctx, errors.Errorf("You are not allowed to proceed with %s", strings.ToLower(string(action))))
+		return false
+	}
+	return true
+}
+
+// AllowCollection checks action on the collection, ingest tokens must list its alias
+func (a *Authorizer) AllowCollection(ctx *gin.Context, action policy.Action, collectionId string) bool {
+	ok, err := a.PermitsCollection(ctx, action, collectionId)
+	if err == nil && !ok {
+		err = errors.Errorf("You are not allowed to proceed with %s", strings.ToLower(string(action)))
+		if action == policy.Read {
+			err = errors.New("You are not allowed to retrieve datas")
+		}
+	}
+	if err != nil {
+		a.deny(ctx, err)
+		return false
+	}
+	return true
+}
+
+// AllowCollectionAlias checks action on the collection with alias
+func (a *Authorizer) AllowCollectionAlias(ctx *gin.Context, action policy.Action, alias string) bool {
+	subject := a.Subject(ctx)
+	if subject.IsAdmin() || subject.MayIngest(alias) {
+		return true
+	}
+	c := a.context(ctx)
+	for _, tenant := range subject.Tenants {
+		if !subject.Allows(tenant.Id, action) {
+			continue
+		}
+		collections, err := a.ClientClerkHandler.GetCollectionsByTenantId(c, &pb.Id{Id: tenant.Id})
+		if err != nil {
+			a.deny(ctx, errors.Wrapf(err, "Could not GetCollectionsByTenantId: %v", err))
+			return false
+		}
+		for _, collection := range collections.Collections {
+			if collection.Alias == alias {
+				return true
+			}
+		}
+	}
+	a.deny(ctx, errors.New("You are not allowed to retrieve datas"))
+	return false
+}
+
+// AllowObjectCreation checks the delivery of a new object to its collection
+func (a *Authorizer) AllowObjectCreation(ctx *gin.Context, objectAndFile *pb.ObjectAndFile) bool {
+	objectPb := objectAndFile.GetObject()
+	if objectPb == nil || objectPb.CollectionId == "" {
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": "object has no collection"})
+		return false
+	}
+	return a.AllowCollection(ctx, policy.Create, objectPb.CollectionId)
+}
+
+// PermitsCollection checks action on the collection without answering the request, for filtering results
+func (a *Authorizer) PermitsCollection(ctx *gin.Context, action policy.Action, collectionId string) (bool, error) {
+	subject := a.Subject(ctx)
+	if subject.IsAdmin() {
+		return true, nil
+	}
+	c := a.context(ctx)
+	if subject.Ingest {
+		collectionPb, err := dataloader.For(c, a.ClientClerkHandler).Collection.Load(c, collectionId)
+		if err != nil {
+			return false, errors.Wrapf(err, "Could not GetCollectionByIdFromMv: %v", err)
+		}
+		return subject.MayIngest(collectionPb.Alias), nil
+	}
+	err := a.engine.Authorize(c, subject, action, []policy.Target{{Kind: policy.KindCollection, ID: collectionId}})
+	if err != nil {
+		if strings.Contains(err.Error(), "You are not allowed") {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// PermitsObject checks action on the collection of the object
+func (a *Authorizer) PermitsObject(ctx *gin.Context, action policy.Action, objectId string) (bool, error) {
+	c := a.context(ctx)
+	objectPb, err := dataloader.For(c, a.ClientClerkHandler).Object.Load(c, objectId)
+	if err != nil {
+		return false, errors.Wrapf(err, "Could not GetObjectById: %v", err)
+	}
+	if objectPb.Id == "" {
+		return false, errors.Errorf("%s %s could not be found", policy.KindObject, objectId)
+	}
+	return a.PermitsCollection(ctx, action, objectPb.CollectionId)
+}
+
+// AllowObject checks action on the collection of the object and answers the request itself if the caller is denied
+func (a *Authorizer) AllowObject(ctx *gin.Context, action policy.Action, objectId string) bool {
+	ok, err := a.PermitsObject(ctx, action, objectId)
+	if err == nil && !ok {
+		err = errors.New("You are not allowed to retrieve datas")
+	}
+	if err != nil {
+		a.deny(ctx, err)
+		return false
+	}
+	return true
+}
+
+// context attaches a loader set to the request, so that the walk up to the owning tenant is shared
+// between the checks of one request
+func (a *Authorizer) context(ctx *gin.Context) context.Context {
+	c := ctx.Request.Context()
+	if loaders, ok := ctx.Get(loadersKey); ok {
+		return dataloader.WithLoaders(c, loaders.(*dataloader.Loaders))
+	}
+	loaders := dataloader.NewLoaders(a.ClientClerkHandler)
+	ctx.Set(loadersKey, loaders)
+	return dataloader.WithLoaders(c, loaders)
+}
+
+func (a *Authorizer) deny(ctx *gin.Context, err error) {
+	httpStatus := http.StatusInternalServerError
+	if strings.Contains(err.Error(), "You are not allowed") {
+		httpStatus = http.StatusForbidden
+	} else if strings.Contains(err.Error(), "could not be found") {
+		httpStatus = http.StatusNotFound
+	}
+	ctx.IndentedJSON(httpStatus, gin.H{"message": err.Error()})
+}
+
+const loadersKey = "loaders"
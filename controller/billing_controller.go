@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"net/http"
+
+	"emperror.dev/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	"github.com/ocfl-archive/dlza-manager-clerk/service"
+	"github.com/ocfl-archive/dlza-manager-clerk/validation"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+)
+
+type BillingController struct {
+	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+	Authorizer         *Authorizer
+	Billing            models.BillingConfig
+}
+
+func (b *BillingController) InitRoutes(billingRouter *gin.RouterGroup) {
+	billingRouter.GET("/:tenantId/:period", b.GetCostReport)
+}
+
+func (b *BillingController) Path() string {
+	return "/billing"
+}
+
+func NewBillingController(clientClerkHandler pbHandler.ClerkHandlerServiceClient, authorizer *Authorizer, billing models.BillingConfig) Controller {
+	return &BillingController{ClientClerkHandler: clientClerkHandler, Authorizer: authorizer, Billing: billing}
+}
+
+// GetCostReport godoc
+// @Summary		Cost report of a tenant
+// @Description	Cost of the object instances of the tenant in the month per collection and storage location, as csv or with format=json as json
+// @Security 	ApiKeyAuth
+// @ID 			get-cost-report
+// @Param		tenantId path string true "tenant ID"
+// @Param		period path string true "month as YYYY-MM, not in the future"
+// @Param		format query string false "csv or json"
+// @Produce		text/csv
+// @Produce		json
+// @Success		200
+// @Failure 	400
+// @Router		/billing/{tenantId}/{period} [get]
+func (b *BillingController) GetCostReport(ctx *gin.Context) {
+	tenantId := ctx.Param("tenantId")
+	period := ctx.Param("period")
+	if !b.Authorizer.Allow(ctx, policy.Read, policy.Target{Kind: policy.KindTenant, ID: tenantId}) {
+		return
+	}
+	report, err := service.CostReport(ctx, b.ClientClerkHandler, b.Billing, tenantId, period)
+	if err != nil {
+		var invalid *validation.Errors
+		if errors.As(err, &invalid) {
+			ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error(), "fields": invalid.Fields})
+			return
+		}
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if ctx.Query("format") == "json" {
+		ctx.JSON(http.StatusOK, report)
+		return
+	}
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Header("Content-Disposition", "attachment; filename=\"cost-"+tenantId+"-"+period+".csv\"")
+	ctx.Status(http.StatusOK)
+	if err := service.WriteCostReportCSV(ctx.Writer, report); err != nil {
+		_ = ctx.Error(err)
+	}
+}
@@ -3,8 +3,10 @@ package controller
 import (
 	"context"
 
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
 	_ "github.com/ocfl-archive/dlza-manager-clerk/controller/docs"
 	_ "github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
 	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
 	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
 	"net/http"
@@ -13,12 +15,14 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func NewCollectionController(clientClerkHandler pbHandler.ClerkHandlerServiceClient) Controller {
-	return &CollectionController{ClientClerkHandler: clientClerkHandler}
+func NewCollectionController(clientClerkHandler pbHandler.ClerkHandlerServiceClient, authorizer *Authorizer, auditLog *audit.Log) Controller {
+	return &CollectionController{ClientClerkHandler: clientClerkHandler, Authorizer: authorizer, AuditLog: auditLog}
 }
 
 type CollectionController struct {
 	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+	Authorizer         *Authorizer
+	AuditLog           *audit.Log
 }
 
 func (col *CollectionController) Path() string {
@@ -49,14 +53,19 @@ func (col *CollectionController) CreateCollection(ctx *gin.Context) {
 		ctx.IndentedJSON(http.StatusUnprocessableEntity, gin.H{"message": "request failed"})
 		return
 	}
+	if !col.Authorizer.Allow(ctx, policy.Create, policy.Target{Kind: policy.KindTenant, ID: collection.TenantId}) {
+		return
+	}
 	c := context.Background()
 	cont, cancel := context.WithTimeout(c, 10000*time.Second)
 	defer cancel()
-	_, err = col.ClientClerkHandler.CreateCollection(cont, collection)
+	idPb, err := col.ClientClerkHandler.CreateCollection(cont, collection)
 	if err != nil {
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	collection.Id = idPb.Id
+	record(ctx, col.AuditLog, policy.Create, policy.KindCollection, collection.Id, nil, collection)
 	ctx.Header("Content-Type", "application/json")
 	ctx.JSON(http.StatusOK, gin.H{"message": "Ok"})
 }
@@ -81,11 +90,23 @@ func (col *CollectionController) UpdateCollection(ctx *gin.Context) {
 		ctx.IndentedJSON(http.StatusUnprocessableEntity, gin.H{"message": "request failed"})
 		return
 	}
+	// moving a collection needs the permission on both tenants
+	if !col.Authorizer.Allow(ctx, policy.Update,
+		policy.Target{Kind: policy.KindCollection, ID: collection.Id},
+		policy.Target{Kind: policy.KindTenant, ID: collection.TenantId}) {
+		return
+	}
+	before, err := col.ClientClerkHandler.GetCollectionById(cont, &pb.Id{Id: collection.Id})
+	if err != nil {
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
 	_, err = col.ClientClerkHandler.UpdateCollection(cont, &collection)
 	if err != nil {
 		ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
+	record(ctx, col.AuditLog, policy.Update, policy.KindCollection, collection.Id, before, &collection)
 	ctx.Header("Content-Type", "application/json")
 	ctx.JSON(http.StatusOK, gin.H{"message": "Ok"})
 }
@@ -105,12 +126,20 @@ func (col *CollectionController) DeleteCollectionById(ctx *gin.Context) {
 	cont, cancel := context.WithTimeout(c, 10000*time.Second)
 	defer cancel()
 	id := ctx.Param("id")
-
-	_, err := col.ClientClerkHandler.DeleteCollectionById(cont, &pb.Id{Id: id})
+	if !col.Authorizer.Allow(ctx, policy.Delete, policy.Target{Kind: policy.KindCollection, ID: id}) {
+		return
+	}
+	before, err := col.ClientClerkHandler.GetCollectionById(cont, &pb.Id{Id: id})
+	if err != nil {
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	_, err = col.ClientClerkHandler.DeleteCollectionById(cont, &pb.Id{Id: id})
 	if err != nil {
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	record(ctx, col.AuditLog, policy.Delete, policy.KindCollection, id, before, nil)
 	ctx.JSON(http.StatusOK, gin.H{"message": "Ok"})
 }
 
@@ -129,6 +158,9 @@ func (col *CollectionController) GetCollectionsByTenantId(ctx *gin.Context) {
 	cont, cancel := context.WithTimeout(c, 10000*time.Second)
 	defer cancel()
 	id := ctx.Param("id")
+	if !col.Authorizer.Allow(ctx, policy.Read, policy.Target{Kind: policy.KindTenant, ID: id}) {
+		return
+	}
 	collections, err := col.ClientClerkHandler.GetCollectionsByTenantId(cont, &pb.Id{Id: id})
 	if err != nil {
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
@@ -214,7 +214,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Finding all storageLocations for tenant ID",
+                "description": "Finding all storageLocations for tenant ID. Only admins get the connection as saved, for other callers its secrets and unknown fields are redacted",
                 "produces": [
                     "application/json"
                 ],
@@ -250,7 +250,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a storageLocation",
+                "description": "Delete a storageLocation, refused like the GraphQL deleteStorageLocation if objects would lose their last copy or drop below their needed quality",
                 "produces": [
                     "application/json"
                 ],
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"net/http"
+
+	"emperror.dev/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	"github.com/ocfl-archive/dlza-manager-clerk/service"
+	"github.com/ocfl-archive/dlza-manager-clerk/validation"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+)
+
+type IntegrityController struct {
+	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+	Authorizer         *Authorizer
+}
+
+func (i *IntegrityController) InitRoutes(integrityRouter *gin.RouterGroup) {
+	integrityRouter.GET("/report", i.GetIntegrityReport)
+}
+
+func (i *IntegrityController) Path() string {
+	return "/integrity"
+}
+
+func NewIntegrityController(clientClerkHandler pbHandler.ClerkHandlerServiceClient, authorizer *Authorizer) Controller {
+	return &IntegrityController{ClientClerkHandler: clientClerkHandler, Authorizer: authorizer}
+}
+
+// GetIntegrityReport godoc
+// @Summary		Integrity report
+// @Description	Checks of the object instances of a collection, a storage location, both or all of a tenant run within the range, per bucket, as csv or with format=json as json
+// @Security 	ApiKeyAuth
+// @ID 			get-integrity-report
+// @Param		tenantId query string false "tenant ID"
+// @Param		collectionId query string false "collection ID"
+// @Param		storageLocationId query string false "storage location ID"
+// @Param		from query string true "YYYY-MM-DD or RFC 3339"
+// @Param		to query string true "YYYY-MM-DD or RFC 3339"
+// @Param		bucket query string false "DAY, WEEK or MONTH"
+// @Param		format query string false "csv or json"
+// @Produce		text/csv
+// @Produce		json
+// @Success		200
+// @Failure 	400
+// @Router		/integrity/report [get]
+func (i *IntegrityController) GetIntegrityReport(ctx *gin.Context) {
+	args := service.IntegrityReportArguments{From: ctx.Query("from"), To: ctx.Query("to"), Bucket: model.IntegrityReportBucket(ctx.Query("bucket"))}
+	targets := make([]policy.Target, 0)
+	for _, param := range []struct {
+		name string
+		kind string
+		arg  **string
+	}{
+		{"tenantId", policy.KindTenant, &args.TenantID},
+		{"collectionId", policy.KindCollection, &args.CollectionID},
+		{"storageLocationId", policy.KindStorageLocation, &args.StorageLocationID},
+	} {
+		if id := ctx.Query(param.name); id != "" {
+			*param.arg = &id
+			targets = append(targets, policy.Target{Kind: param.kind, ID: id})
+		}
+	}
+	if args.Bucket != "" && !args.Bucket.IsValid() {
+		ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": "bucket should be DAY, WEEK or MONTH"})
+		return
+	}
+	if len(targets) > 0 && !i.Authorizer.Allow(ctx, policy.Read, targets...) {
+		return
+	}
+	report, err := service.IntegrityReport(ctx, i.ClientClerkHandler, args)
+	if err != nil {
+		var invalid *validation.Errors
+		if errors.As(err, &invalid) {
+			ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error(), "fields": invalid.Fields})
+			return
+		}
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	if ctx.Query("format") == "json" {
+		ctx.JSON(http.StatusOK, report)
+		return
+	}
+	ctx.Header("Content-Type", "text/csv")
+	ctx.Header("Content-Disposition", "attachment; filename=\"integrity-"+report.From[:10]+"-"+report.To[:10]+".csv\"")
+	ctx.Status(http.StatusOK)
+	if err := service.WriteIntegrityReportCSV(ctx.Writer, report); err != nil {
+		_ = ctx.Error(err)
+	}
+}
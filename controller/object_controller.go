@@ -4,12 +4,16 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
 	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
 	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
 )
 
 type ObjectController struct {
 	ClientClerkHandlerService pbHandler.ClerkHandlerServiceClient
+	Authorizer                *Authorizer
+	AuditLog                  *audit.Log
 }
 
 func (o *ObjectController) InitRoutes(StorageInfoRouter *gin.RouterGroup) {
@@ -24,8 +28,8 @@ func (o *ObjectController) Path() string {
 	return "/object"
 }
 
-func NewObjectController(clientClerkHandlerService pbHandler.ClerkHandlerServiceClient) Controller {
-	return &ObjectController{ClientClerkHandlerService: clientClerkHandlerService}
+func NewObjectController(clientClerkHandlerService pbHandler.ClerkHandlerServiceClient, authorizer *Authorizer, auditLog *audit.Log) Controller {
+	return &ObjectController{ClientClerkHandlerService: clientClerkHandlerService, Authorizer: authorizer, AuditLog: auditLog}
 }
 
 // GetObjectsByChecksum godoc
@@ -45,7 +49,18 @@ func (o *ObjectController) GetObjectsByChecksum(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
 		return
 	}
-	ctx.JSON(http.StatusOK, objects)
+	allowed := make([]*pb.Object, 0, len(objects.Objects))
+	for _, objectPb := range objects.Objects {
+		ok, err := o.Authorizer.PermitsCollection(ctx, policy.Read, objectPb.CollectionId)
+		if err != nil {
+			o.Authorizer.deny(ctx, err)
+			return
+		}
+		if ok {
+			allowed = append(allowed, objectPb)
+		}
+	}
+	ctx.JSON(http.StatusOK, &pb.Objects{Objects: allowed})
 }
 
 // GetObjectBySignature godoc
@@ -65,6 +80,9 @@ func (o *ObjectController) GetObjectBySignature(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
 		return
 	}
+	if objects.Id != "" && !o.Authorizer.AllowCollection(ctx, policy.Read, objects.CollectionId) {
+		return
+	}
 	ctx.JSON(http.StatusOK, objects)
 }
 
@@ -80,6 +98,9 @@ func (o *ObjectController) GetObjectBySignature(ctx *gin.Context) {
 func (o *ObjectController) GetResultingQualityForObject(ctx *gin.Context) {
 
 	checksum := ctx.Param("id")
+	if !o.Authorizer.AllowObject(ctx, policy.Read, checksum) {
+		return
+	}
 	quality, err := o.ClientClerkHandlerService.GetResultingQualityForObject(ctx, &pb.Id{Id: checksum})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
@@ -100,6 +121,9 @@ func (o *ObjectController) GetResultingQualityForObject(ctx *gin.Context) {
 func (o *ObjectController) GetNeededQualityForObject(ctx *gin.Context) {
 
 	checksum := ctx.Param("id")
+	if !o.Authorizer.AllowObject(ctx, policy.Read, checksum) {
+		return
+	}
 	quality, err := o.ClientClerkHandlerService.GetNeededQualityForObject(ctx, &pb.Id{Id: checksum})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
@@ -125,11 +149,15 @@ func (o *ObjectController) CreateObjectAndInstance(ctx *gin.Context) {
 		ctx.IndentedJSON(http.StatusUnprocessableEntity, gin.H{"message": "request failed"})
 		return
 	}
+	if !o.Authorizer.AllowObjectCreation(ctx, &object) {
+		return
+	}
 	_, err = o.ClientClerkHandlerService.CreateObjectAndInstance(ctx, &object)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
 		return
 	}
+	record(ctx, o.AuditLog, policy.Create, policy.KindObject, object.GetObject().GetId(), nil, &object)
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "success"})
 }
@@ -4,12 +4,14 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
 	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
 	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
 )
 
 type ObjectInstanceController struct {
 	ClientClerkHandlerService pbHandler.ClerkHandlerServiceClient
+	Authorizer                *Authorizer
 }
 
 func (o *ObjectInstanceController) InitRoutes(StorageInfoRouter *gin.RouterGroup) {
@@ -22,8 +24,8 @@ func (o *ObjectInstanceController) Path() string {
 	return "/object-instance"
 }
 
-func NewObjectInstanceController(clientClerkHandlerService pbHandler.ClerkHandlerServiceClient) Controller {
-	return &ObjectInstanceController{ClientClerkHandlerService: clientClerkHandlerService}
+func NewObjectInstanceController(clientClerkHandlerService pbHandler.ClerkHandlerServiceClient, authorizer *Authorizer) Controller {
+	return &ObjectInstanceController{ClientClerkHandlerService: clientClerkHandlerService, Authorizer: authorizer}
 }
 
 // ObjectInstanceWithNameExists godoc
@@ -43,7 +45,18 @@ func (o *ObjectInstanceController) ObjectInstanceWithNameExists(ctx *gin.Context
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
 		return
 	}
-	ctx.JSON(http.StatusOK, objectInstances)
+	allowed := make([]*pb.ObjectInstance, 0, len(objectInstances.ObjectInstances))
+	for _, objectInstancePb := range objectInstances.ObjectInstances {
+		ok, err := o.Authorizer.PermitsObject(ctx, policy.Read, objectInstancePb.ObjectId)
+		if err != nil {
+			o.Authorizer.deny(ctx, err)
+			return
+		}
+		if ok {
+			allowed = append(allowed, objectInstancePb)
+		}
+	}
+	ctx.JSON(http.StatusOK, &pb.ObjectInstances{ObjectInstances: allowed})
 }
 
 // GetObjectInstancesBySignatureAndLocationsPathName godoc
@@ -63,6 +76,9 @@ func (o *ObjectInstanceController) GetObjectInstancesBySignatureAndLocationsPath
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
 		return
 	}
+	if objectInstance.Id != "" && !o.Authorizer.AllowObject(ctx, policy.Read, objectInstance.ObjectId) {
+		return
+	}
 	ctx.JSON(http.StatusOK, objectInstance)
 }
 
@@ -77,6 +93,9 @@ func (o *ObjectInstanceController) GetObjectInstancesBySignatureAndLocationsPath
 // @Router	/object-instance/raw-check/{object-id} [get]
 func (o *ObjectInstanceController) GetRawObjectInstanceByObjectId(ctx *gin.Context) {
 	objectId := ctx.Param("object-id")
+	if !o.Authorizer.AllowObject(ctx, policy.Read, objectId) {
+		return
+	}
 	objectInstance, err := o.ClientClerkHandlerService.CheckRawObjectInstanceByObjectId(ctx, &pb.Id{Id: objectId})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
@@ -2,14 +2,23 @@ package controller
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
+	"github.com/ocfl-archive/dlza-manager-clerk/events"
 	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	"github.com/ocfl-archive/dlza-manager-clerk/service"
 	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
 	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
 	"net/http"
 )
 
+// StatusController serves the upload statuses of the ingest, which are not linked to a collection
+// and therefore only open to admins and the ingest token which created them
 type StatusController struct {
 	ClientClerkHandlerService pbHandler.ClerkHandlerServiceClient
+	Authorizer                *Authorizer
+	EventSource               events.Source
+	AuditLog                  *audit.Log
 }
 
 func (s *StatusController) InitRoutes(statusRouter *gin.RouterGroup) {
@@ -22,8 +31,8 @@ func (s *StatusController) Path() string {
 	return "/status"
 }
 
-func NewStatusController(clientClerkHandlerService pbHandler.ClerkHandlerServiceClient) Controller {
-	return &StatusController{ClientClerkHandlerService: clientClerkHandlerService}
+func NewStatusController(clientClerkHandlerService pbHandler.ClerkHandlerServiceClient, authorizer *Authorizer, eventSource events.Source, auditLog *audit.Log) Controller {
+	return &StatusController{ClientClerkHandlerService: clientClerkHandlerService, Authorizer: authorizer, EventSource: eventSource, AuditLog: auditLog}
 }
 
 // CheckStatus godoc
@@ -36,8 +45,10 @@ func NewStatusController(clientClerkHandlerService pbHandler.ClerkHandlerService
 // @Failure 	400
 // @Router		/status/{id} [get]
 func (s *StatusController) CheckStatus(ctx *gin.Context) {
-
 	id := ctx.Param("id")
+	if !s.Authorizer.AllowStatus(ctx, policy.Read, id) {
+		return
+	}
 	status, err := s.ClientClerkHandlerService.CheckStatus(ctx, &pb.Id{Id: id})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
@@ -62,11 +73,21 @@ func (s *StatusController) AlterStatus(ctx *gin.Context) {
 		ctx.IndentedJSON(http.StatusUnprocessableEntity, gin.H{"message": "request failed"})
 		return
 	}
+	if !s.Authorizer.AllowStatus(ctx, policy.Update, statusObject.Id) {
+		return
+	}
+	before, err := s.ClientClerkHandlerService.CheckStatus(ctx, &pb.Id{Id: statusObject.Id})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
+		return
+	}
 	_, err = s.ClientClerkHandlerService.AlterStatus(ctx, &statusObject)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
 		return
 	}
+	record(ctx, s.AuditLog, policy.Update, audit.KindStatus, statusObject.Id, before, &statusObject)
+	s.publish(ctx, statusObject.Id)
 	ctx.JSON(http.StatusOK, gin.H{"message": "Ok"})
 }
 
@@ -80,6 +101,9 @@ func (s *StatusController) AlterStatus(ctx *gin.Context) {
 // @Failure 	400
 // @Router		/status [post]
 func (s *StatusController) CreateStatus(ctx *gin.Context) {
+	if !s.Authorizer.AllowIngest(ctx, policy.Create) {
+		return
+	}
 	statusObject := pb.StatusObject{}
 	err := ctx.ShouldBindJSON(&statusObject)
 	if err != nil {
@@ -91,5 +115,23 @@ func (s *StatusController) CreateStatus(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
 		return
 	}
+	statusObject.Id = id.Id
+	if err := s.Authorizer.RecordStatusOwner(ctx, id.Id); err != nil {
+		ctx.Error(err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"message": "request failed"})
+		return
+	}
+	record(ctx, s.AuditLog, policy.Create, audit.KindStatus, id.Id, nil, &statusObject)
+	s.publish(ctx, id.Id)
 	ctx.JSON(http.StatusOK, models.ArchivingStatus{Id: id.Id})
 }
+
+// publish informs the subscribers of the archiving status, the request does not fail if it cannot
+func (s *StatusController) publish(ctx *gin.Context, id string) {
+	if s.EventSource == nil {
+		return
+	}
+	if err := service.PublishArchivingStatus(ctx, s.ClientClerkHandlerService, s.EventSource, id); err != nil {
+		ctx.Error(err)
+	}
+}
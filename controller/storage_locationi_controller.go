@@ -2,8 +2,11 @@ package controller
 
 import (
 	"context"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
 	_ "github.com/ocfl-archive/dlza-manager-clerk/controller/docs"
 	_ "github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	"github.com/ocfl-archive/dlza-manager-clerk/service"
 	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
 	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
 	"strconv"
@@ -16,6 +19,8 @@ import (
 
 type StorageLocationController struct {
 	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+	Authorizer         *Authorizer
+	AuditLog           *audit.Log
 }
 
 func (s *StorageLocationController) InitRoutes(storageLocationRouter *gin.RouterGroup) {
@@ -29,8 +34,8 @@ func (s *StorageLocationController) Path() string {
 	return "/storage-location"
 }
 
-func NewStorageLocationController(clientClerkHandler pbHandler.ClerkHandlerServiceClient) Controller {
-	return &StorageLocationController{ClientClerkHandler: clientClerkHandler}
+func NewStorageLocationController(clientClerkHandler pbHandler.ClerkHandlerServiceClient, authorizer *Authorizer, auditLog *audit.Log) Controller {
+	return &StorageLocationController{ClientClerkHandler: clientClerkHandler, Authorizer: authorizer, AuditLog: auditLog}
 }
 
 // SaveStorageLocation godoc
@@ -50,21 +55,27 @@ func (s *StorageLocationController) SaveStorageLocation(ctx *gin.Context) {
 		ctx.IndentedJSON(http.StatusUnprocessableEntity, gin.H{"message": "request failed"})
 		return
 	}
+	if !s.Authorizer.Allow(ctx, policy.Create, policy.Target{Kind: policy.KindTenant, ID: storageLocation.TenantId}) {
+		return
+	}
 	c := context.Background()
 	cont, cancel := context.WithTimeout(c, 10000*time.Second)
 	defer cancel()
-	_, err = s.ClientClerkHandler.SaveStorageLocation(cont, &storageLocation)
+	idPb, err := s.ClientClerkHandler.SaveStorageLocation(cont, &storageLocation)
 	if err != nil {
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	storageLocation.Id = idPb.Id
+	storageLocation.Connection = service.RedactConnection(storageLocation.Connection)
+	record(ctx, s.AuditLog, policy.Create, policy.KindStorageLocation, storageLocation.Id, nil, &storageLocation)
 	ctx.Header("Content-Type", "application/json")
 	ctx.JSON(http.StatusOK, storageLocation.Alias)
 }
 
 // DeleteStorageLocationById godoc
 // @Summary		Delete storageLocation
-// @Description	Delete a storageLocation
+// @Description	Delete a storageLocation, refused like the GraphQL deleteStorageLocation if objects would lose their last copy or drop below their needed quality
 // @Security 	 ApiKeyAuth
 // @ID 			delete-storageLocation
 // @Param		id path string true "storage-location ID"
@@ -77,18 +88,31 @@ func (s *StorageLocationController) DeleteStorageLocationById(ctx *gin.Context)
 	cont, cancel := context.WithTimeout(c, 10000*time.Second)
 	defer cancel()
 	id := ctx.Param("id")
-
-	_, err := s.ClientClerkHandler.DeleteStorageLocationById(cont, &pb.Id{Id: id})
+	if !s.Authorizer.Allow(ctx, policy.Delete, policy.Target{Kind: policy.KindStorageLocation, ID: id}) {
+		return
+	}
+	before, err := s.ClientClerkHandler.GetStorageLocationById(cont, &pb.Id{Id: id})
 	if err != nil {
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	if err := service.RefuseStorageLocationQualityLoss(cont, s.ClientClerkHandler, before); err != nil {
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	before.Connection = service.RedactConnection(before.Connection)
+	_, err = s.ClientClerkHandler.DeleteStorageLocationById(cont, &pb.Id{Id: id})
+	if err != nil {
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	record(ctx, s.AuditLog, policy.Delete, policy.KindStorageLocation, id, before, nil)
 	ctx.JSON(http.StatusOK, gin.H{"message": "Ok"})
 }
 
 // GetStorageLocationsByTenantId godoc
 // @Summary		Find all storageLocations for tenant ID
-// @Description	Finding all storageLocations for tenant ID
+// @Description	Finding all storageLocations for tenant ID. Only admins get the connection as saved, for other callers its secrets and unknown fields are redacted
 // @Security 	 ApiKeyAuth
 // @ID 			find-all-storageLocations-for-tenant-id
 // @Param		id path string true "tenant ID"
@@ -101,11 +125,20 @@ func (s *StorageLocationController) GetStorageLocationsByTenantId(ctx *gin.Conte
 	cont, cancel := context.WithTimeout(c, 10000*time.Second)
 	defer cancel()
 	id := ctx.Param("id")
+	if !s.Authorizer.Allow(ctx, policy.Read, policy.Target{Kind: policy.KindTenant, ID: id}) {
+		return
+	}
 	storageLocations, err := s.ClientClerkHandler.GetStorageLocationsByTenantId(cont, &pb.Id{Id: id})
 	if err != nil {
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	// admins get the connection as saved, for everybody else its values may hold secrets
+	if !s.Authorizer.Subject(ctx).IsAdmin() {
+		for _, storageLocation := range storageLocations.StorageLocations {
+			storageLocation.Connection = service.RedactConnection(storageLocation.Connection)
+		}
+	}
 	ctx.Header("Content-Type", "application/json")
 	ctx.JSON(http.StatusOK, storageLocations.StorageLocations)
 }
@@ -125,6 +158,9 @@ func (s *StorageLocationController) GetStorageLocationsStatusForCollectionAlias(
 	cont, cancel := context.WithTimeout(c, 10000*time.Second)
 	defer cancel()
 	alias := ctx.Param("alias")
+	if !s.Authorizer.AllowCollectionAlias(ctx, policy.Read, alias) {
+		return
+	}
 	size := ctx.Param("size")
 	signature := ctx.Param("signature")
 	head := ctx.Param("head")
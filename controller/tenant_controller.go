@@ -2,8 +2,10 @@ package controller
 
 import (
 	"context"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
 	_ "github.com/ocfl-archive/dlza-manager-clerk/controller/docs"
 	_ "github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
 	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
 	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
 	"net/http"
@@ -12,12 +14,14 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func NewTenantController(clientClerkHandler pbHandler.ClerkHandlerServiceClient) Controller {
-	return &TenantController{ClientClerkHandler: clientClerkHandler}
+func NewTenantController(clientClerkHandler pbHandler.ClerkHandlerServiceClient, authorizer *Authorizer, auditLog *audit.Log) Controller {
+	return &TenantController{ClientClerkHandler: clientClerkHandler, Authorizer: authorizer, AuditLog: auditLog}
 }
 
 type TenantController struct {
 	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+	Authorizer         *Authorizer
+	AuditLog           *audit.Log
 }
 
 func (t *TenantController) Path() string {
@@ -44,6 +48,9 @@ func (t *TenantController) InitRoutes(tenantRouter *gin.RouterGroup) {
 // @Failure 	400
 // @Router		/tenant [post]
 func (t *TenantController) SaveTenant(ctx *gin.Context) {
+	if !t.Authorizer.AllowAdmin(ctx, policy.Create) {
+		return
+	}
 	tenant := pb.Tenant{}
 	err := ctx.ShouldBindJSON(&tenant)
 	if err != nil {
@@ -58,6 +65,7 @@ func (t *TenantController) SaveTenant(ctx *gin.Context) {
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	record(ctx, t.AuditLog, policy.Create, policy.KindTenant, tenant.Id, nil, &tenant)
 	ctx.Header("Content-Type", "application/json")
 	ctx.JSON(http.StatusOK, tenant.Alias)
 }
@@ -73,6 +81,9 @@ func (t *TenantController) SaveTenant(ctx *gin.Context) {
 // @Failure 	400
 // @Router		/tenant [patch]
 func (t *TenantController) UpdateTenant(ctx *gin.Context) {
+	if !t.Authorizer.AllowAdmin(ctx, policy.Update) {
+		return
+	}
 	c := context.Background()
 	cont, cancel := context.WithTimeout(c, 10000*time.Second)
 	defer cancel()
@@ -82,11 +93,17 @@ func (t *TenantController) UpdateTenant(ctx *gin.Context) {
 		ctx.IndentedJSON(http.StatusUnprocessableEntity, gin.H{"message": "request failed"})
 		return
 	}
+	before, err := t.ClientClerkHandler.FindTenantById(cont, &pb.Id{Id: tenant.Id})
+	if err != nil {
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
 	_, err = t.ClientClerkHandler.UpdateTenant(cont, &tenant)
 	if err != nil {
 		ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
+	record(ctx, t.AuditLog, policy.Update, policy.KindTenant, tenant.Id, before, &tenant)
 	ctx.Header("Content-Type", "application/json")
 	ctx.JSON(http.StatusOK, gin.H{"message": "Ok"})
 }
@@ -102,16 +119,24 @@ func (t *TenantController) UpdateTenant(ctx *gin.Context) {
 // @Failure 	400
 // @Router		/tenant/{id} [delete]
 func (t *TenantController) DeleteTenant(ctx *gin.Context) {
+	if !t.Authorizer.AllowAdmin(ctx, policy.Delete) {
+		return
+	}
 	c := context.Background()
 	cont, cancel := context.WithTimeout(c, 10000*time.Second)
 	defer cancel()
 	id := ctx.Param("id")
-
-	_, err := t.ClientClerkHandler.DeleteTenant(cont, &pb.Id{Id: id})
+	before, err := t.ClientClerkHandler.FindTenantById(cont, &pb.Id{Id: id})
 	if err != nil {
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	_, err = t.ClientClerkHandler.DeleteTenant(cont, &pb.Id{Id: id})
+	if err != nil {
+		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		return
+	}
+	record(ctx, t.AuditLog, policy.Delete, policy.KindTenant, id, before, nil)
 	ctx.JSON(http.StatusOK, gin.H{"message": "Ok"})
 }
 
@@ -130,6 +155,9 @@ func (t *TenantController) FindTenantById(ctx *gin.Context) {
 	cont, cancel := context.WithTimeout(c, 10000*time.Second)
 	defer cancel()
 	id := ctx.Param("id")
+	if !t.Authorizer.Allow(ctx, policy.Read, policy.Target{Kind: policy.KindTenant, ID: id}) {
+		return
+	}
 	tenant, err := t.ClientClerkHandler.FindTenantById(cont, &pb.Id{Id: id})
 	if err != nil {
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
@@ -157,6 +185,13 @@ func (t *TenantController) FindAllTenants(ctx *gin.Context) {
 		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
+	subject := t.Authorizer.Subject(ctx)
+	allowed := make([]*pb.Tenant, 0, len(tenants.Tenants))
+	for _, tenant := range tenants.Tenants {
+		if subject.Allows(tenant.Id, policy.Read) {
+			allowed = append(allowed, tenant)
+		}
+	}
 	ctx.Header("Content-Type", "application/json")
-	ctx.JSON(http.StatusOK, tenants.Tenants)
+	ctx.JSON(http.StatusOK, allowed)
 }
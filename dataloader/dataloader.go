@@ -0,0 +1,198 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWait        = 2 * time.Millisecond
+	defaultMaxBatch    = 1000
+	defaultMaxParallel = 16
+)
+
+// FetchFunc resolves a batch of keys. It must return exactly one value and one error per key, in key order.
+type FetchFunc[K comparable, V any] func(ctx context.Context, keys []K) ([]V, []error)
+
+// Loader collects the keys requested within a short wait window, deduplicates them and hands them to its fetch
+// function as one batch. Results are cached for the lifetime of the loader, which is one GraphQL operation.
+// The handler has no batch lookups, so the fetch functions of this package still call it once per key, see
+// fetchEach.
+type Loader[K comparable, V any] struct {
+	fetch    FetchFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu    sync.Mutex
+	cache map[K]*result[K, V]
+	batch *batch[K, V]
+}
+
+type result[K comparable, V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+	// batch is the batch resolving the result until it is done
+	batch *batch[K, V]
+}
+
+type batch[K comparable, V any] struct {
+	// ctx keeps the values of the first caller, it is only cancelled once no caller waits for the batch anymore
+	ctx        context.Context
+	cancel     context.CancelFunc
+	keys       []K
+	results    []*result[K, V]
+	waiting    int
+	dispatched bool
+}
+
+func NewLoader[K comparable, V any](fetch FetchFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     defaultWait,
+		maxBatch: defaultMaxBatch,
+		cache:    make(map[K]*result[K, V]),
+	}
+}
+
+// Load returns the value for key, waiting for the batch it was scheduled in.
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	res := l.enqueue(ctx, key)
+	return l.await(ctx, res)
+}
+
+// LoadAll returns the values for all keys in the same order. Duplicate keys are resolved only once.
+func (l *Loader[K, V]) LoadAll(ctx context.Context, keys []K) ([]V, error) {
+	results := make([]*result[K, V], len(keys))
+	for i, key := range keys {
+		results[i] = l.enqueue(ctx, key)
+	}
+	values := make([]V, len(keys))
+	var err error
+	for i, res := range results {
+		if err != nil {
+			l.leave(res)
+			continue
+		}
+		values[i], err = l.await(ctx, res)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K) *result[K, V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if res, ok := l.cache[key]; ok {
+		if res.batch != nil {
+			res.batch.waiting++
+		}
+		return res
+	}
+	if l.batch == nil {
+		batchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		b := &batch[K, V]{ctx: batchCtx, cancel: cancel}
+		l.batch = b
+		time.AfterFunc(l.wait, func() { l.dispatch(b) })
+	}
+	res := &result[K, V]{done: make(chan struct{}), batch: l.batch}
+	l.cache[key] = res
+	l.batch.keys = append(l.batch.keys, key)
+	l.batch.results = append(l.batch.results, res)
+	l.batch.waiting++
+	if len(l.batch.keys) >= l.maxBatch {
+		b := l.batch
+		l.batch = nil
+		go l.dispatch(b)
+	}
+	return res
+}
+
+func (l *Loader[K, V]) dispatch(b *batch[K, V]) {
+	l.mu.Lock()
+	if b.dispatched {
+		// already dispatched because the batch was full
+		l.mu.Unlock()
+		return
+	}
+	b.dispatched = true
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	values, errs := l.fetch(b.ctx, b.keys)
+	b.cancel()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i, res := range b.results {
+		if i < len(values) {
+			res.value = values[i]
+		}
+		if i < len(errs) {
+			res.err = errs[i]
+		}
+		res.batch = nil
+		// a batch given up by all its callers is not cached, a later caller fetches the key again
+		if res.err != nil && b.ctx.Err() != nil && l.cache[b.keys[i]] == res {
+			delete(l.cache, b.keys[i])
+		}
+		close(res.done)
+	}
+}
+
+// await waits for the result, a caller giving up leaves the batch
+func (l *Loader[K, V]) await(ctx context.Context, res *result[K, V]) (V, error) {
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		l.leave(res)
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// leave stops waiting for the result and cancels its batch once nobody waits for it anymore
+func (l *Loader[K, V]) leave(res *result[K, V]) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if res.batch == nil {
+		return
+	}
+	res.batch.waiting--
+	if res.batch.waiting == 0 {
+		res.batch.cancel()
+	}
+}
+
+// fetchEach resolves every key with fn, running at most defaultMaxParallel calls at the same time. The handler
+// only offers lookups of one id at a time, so this does not reduce the number of calls, only deduplicates and
+// bounds them.
+func fetchEach[K comparable, V any](fn func(ctx context.Context, key K) (V, error)) FetchFunc[K, V] {
+	return func(ctx context.Context, keys []K) ([]V, []error) {
+		values := make([]V, len(keys))
+		errs := make([]error, len(keys))
+		sem := make(chan struct{}, defaultMaxParallel)
+		var wg sync.WaitGroup
+		for i, key := range keys {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				values[i], errs[i] = fn(ctx, key)
+			}()
+		}
+		wg.Wait()
+		return values, errs
+	}
+}
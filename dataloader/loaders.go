@@ -0,0 +1,188 @@
+package dataloader
+
+import (
+	"context"
+
+	"github.com/ocfl-archive/dlza-manager-clerk/constants"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+// pageSize is the page the loaders of whole lists read with
+const pageSize = 1000
+
+// Loaders bundles all relationship lookups which are resolved once per item in list queries.
+// A new set is attached to every GraphQL response, so the cache never outlives a single query or subscription event.
+type Loaders struct {
+	Tenant                         *Loader[string, *pb.Tenant]
+	TenantAmountAndSize            *Loader[string, *pb.AmountAndSize]
+	TenantCollections              *Loader[string, []*pb.Collection]
+	Collection                     *Loader[string, *pb.Collection]
+	CollectionAmountOfErrors       *Loader[string, int64]
+	CollectionObjects              *Loader[string, []*pb.Object]
+	Object                         *Loader[string, *pb.Object]
+	ObjectStatus                   *Loader[string, int64]
+	ObjectNeededQuality            *Loader[string, int64]
+	ObjectResultingQuality         *Loader[string, int64]
+	ObjectObjectInstances          *Loader[string, []*pb.ObjectInstance]
+	ObjectInstance                 *Loader[string, *pb.ObjectInstance]
+	ObjectInstanceCheck            *Loader[string, *pb.ObjectInstanceCheck]
+	ObjectInstanceLastSuccess      *Loader[string, *pb.ObjectInstanceCheck]
+	File                           *Loader[string, *pb.File]
+	StorageLocation                *Loader[string, *pb.StorageLocation]
+	StorageLocationAmountOfObjects *Loader[string, int64]
+	StorageLocationAmountOfErrors  *Loader[string, int64]
+	StoragePartition               *Loader[string, *pb.StoragePartition]
+	StoragePartitionInstances      *Loader[string, []*pb.ObjectInstance]
+}
+
+func NewLoaders(clientClerkHandler pbHandler.ClerkHandlerServiceClient) *Loaders {
+	return &Loaders{
+		Tenant: NewLoader(fetchEach(func(ctx context.Context, id string) (*pb.Tenant, error) {
+			return clientClerkHandler.FindTenantById(ctx, &pb.Id{Id: id})
+		})),
+		TenantAmountAndSize: NewLoader(fetchEach(func(ctx context.Context, id string) (*pb.AmountAndSize, error) {
+			return clientClerkHandler.GetAmountOfObjectsAndTotalSizeByTenantId(ctx, &pb.Id{Id: id})
+		})),
+		TenantCollections: NewLoader(fetchEach(func(ctx context.Context, id string) ([]*pb.Collection, error) {
+			collectionsPb, err := clientClerkHandler.GetCollectionsByTenantId(ctx, &pb.Id{Id: id})
+			if err != nil {
+				return nil, err
+			}
+			return collectionsPb.Collections, nil
+		})),
+		Collection: NewLoader(fetchEach(func(ctx context.Context, id string) (*pb.Collection, error) {
+			return clientClerkHandler.GetCollectionByIdFromMv(ctx, &pb.Id{Id: id})
+		})),
+		CollectionAmountOfErrors: NewLoader(fetchEach(func(ctx context.Context, id string) (int64, error) {
+			amount, err := clientClerkHandler.GetAmountOfErrorsByCollectionId(ctx, &pb.Id{Id: id})
+			if err != nil {
+				return 0, err
+			}
+			return amount.Size, nil
+		})),
+		CollectionObjects: NewLoader(fetchEach(func(ctx context.Context, id string) ([]*pb.Object, error) {
+			objects := make([]*pb.Object, 0)
+			for skip := int32(0); ; skip += pageSize {
+				objectsPb, err := clientClerkHandler.GetObjectsByCollectionIdPaginated(ctx, &pb.Pagination{Id: id, Skip: skip, Take: pageSize, SortKey: "ID", SortDirection: "ASC"})
+				if err != nil {
+					return nil, err
+				}
+				objects = append(objects, objectsPb.Objects...)
+				if len(objectsPb.Objects) == 0 || int64(skip)+pageSize >= int64(objectsPb.TotalItems) {
+					return objects, nil
+				}
+			}
+		})),
+		Object: NewLoader(fetchEach(func(ctx context.Context, id string) (*pb.Object, error) {
+			return clientClerkHandler.GetObjectById(ctx, &pb.Id{Id: id})
+		})),
+		ObjectStatus: NewLoader(fetchEach(func(ctx context.Context, id string) (int64, error) {
+			status, err := clientClerkHandler.GetStatusForObjectId(ctx, &pb.Id{Id: id})
+			if err != nil {
+				return 0, err
+			}
+			return status.Size, nil
+		})),
+		ObjectNeededQuality: NewLoader(fetchEach(func(ctx context.Context, id string) (int64, error) {
+			quality, err := clientClerkHandler.GetNeededQualityForObject(ctx, &pb.Id{Id: id})
+			if err != nil {
+				return 0, err
+			}
+			return quality.Size, nil
+		})),
+		ObjectResultingQuality: NewLoader(fetchEach(func(ctx context.Context, id string) (int64, error) {
+			quality, err := clientClerkHandler.GetResultingQualityForObject(ctx, &pb.Id{Id: id})
+			if err != nil {
+				return 0, err
+			}
+			return quality.Size, nil
+		})),
+		ObjectObjectInstances: NewLoader(fetchEach(func(ctx context.Context, id string) ([]*pb.ObjectInstance, error) {
+			objectInstances := make([]*pb.ObjectInstance, 0)
+			for skip := int32(0); ; skip += pageSize {
+				objectInstancesPb, err := clientClerkHandler.GetObjectInstancesByObjectIdPaginated(ctx, &pb.Pagination{Id: id, Skip: skip, Take: pageSize, SortKey: "ID", SortDirection: "ASC"})
+				if err != nil {
+					return nil, err
+				}
+				objectInstances = append(objectInstances, objectInstancesPb.ObjectInstances...)
+				if len(objectInstancesPb.ObjectInstances) == 0 || int64(skip)+pageSize >= int64(objectInstancesPb.TotalItems) {
+					return objectInstances, nil
+				}
+			}
+		})),
+		ObjectInstance: NewLoader(fetchEach(func(ctx context.Context, id string) (*pb.ObjectInstance, error) {
+			return clientClerkHandler.GetObjectInstanceById(ctx, &pb.Id{Id: id})
+		})),
+		ObjectInstanceCheck: NewLoader(fetchEach(func(ctx context.Context, id string) (*pb.ObjectInstanceCheck, error) {
+			return clientClerkHandler.GetObjectInstanceCheckById(ctx, &pb.Id{Id: id})
+		})),
+		// the newest check of the object instance without error, nil if there is none
+		ObjectInstanceLastSuccess: NewLoader(fetchEach(func(ctx context.Context, id string) (*pb.ObjectInstanceCheck, error) {
+			for skip := int32(0); ; skip += pageSize {
+				checksPb, err := clientClerkHandler.GetObjectInstanceChecksByObjectInstanceIdPaginated(ctx, &pb.Pagination{Id: id, Skip: skip, Take: pageSize, SortKey: "checktime", SortDirection: "DESC NULLS LAST"})
+				if err != nil {
+					return nil, err
+				}
+				for _, checkPb := range checksPb.ObjectInstanceChecks {
+					if !checkPb.Error {
+						return checkPb, nil
+					}
+				}
+				if len(checksPb.ObjectInstanceChecks) == 0 || int64(skip)+pageSize >= checksPb.TotalItems {
+					return nil, nil
+				}
+			}
+		})),
+		File: NewLoader(fetchEach(func(ctx context.Context, id string) (*pb.File, error) {
+			return clientClerkHandler.GetFileById(ctx, &pb.Id{Id: id})
+		})),
+		StorageLocation: NewLoader(fetchEach(func(ctx context.Context, id string) (*pb.StorageLocation, error) {
+			return clientClerkHandler.GetStorageLocationById(ctx, &pb.Id{Id: id})
+		})),
+		StorageLocationAmountOfObjects: NewLoader(fetchEach(func(ctx context.Context, id string) (int64, error) {
+			amount, err := clientClerkHandler.GetAmountOfObjectsForStorageLocationId(ctx, &pb.Id{Id: id})
+			if err != nil {
+				return 0, err
+			}
+			return amount.Size, nil
+		})),
+		StorageLocationAmountOfErrors: NewLoader(fetchEach(func(ctx context.Context, id string) (int64, error) {
+			amount, err := clientClerkHandler.GetAmountOfErrorsForStorageLocationId(ctx, &pb.Id{Id: id})
+			if err != nil {
+				return 0, err
+			}
+			return amount.Size, nil
+		})),
+		StoragePartition: NewLoader(fetchEach(func(ctx context.Context, id string) (*pb.StoragePartition, error) {
+			return clientClerkHandler.GetStoragePartitionById(ctx, &pb.Id{Id: id})
+		})),
+		StoragePartitionInstances: NewLoader(fetchEach(func(ctx context.Context, id string) ([]*pb.ObjectInstance, error) {
+			objectInstances := make([]*pb.ObjectInstance, 0)
+			for skip := int32(0); ; skip += pageSize {
+				objectInstancesPb, err := clientClerkHandler.GetObjectInstancesByStoragePartitionIdPaginated(ctx, &pb.Pagination{Id: id, Skip: skip, Take: pageSize, SortKey: "ID", SortDirection: "ASC"})
+				if err != nil {
+					return nil, err
+				}
+				objectInstances = append(objectInstances, objectInstancesPb.ObjectInstances...)
+				if len(objectInstancesPb.ObjectInstances) == 0 || int64(skip)+pageSize >= objectInstancesPb.TotalItems {
+					return objectInstances, nil
+				}
+			}
+		})),
+	}
+}
+
+// WithLoaders attaches a loader set to the context of a response or REST request
+func WithLoaders(ctx context.Context, loaders *Loaders) context.Context {
+	return context.WithValue(ctx, constants.Loaders, loaders)
+}
+
+// For retrieves the loader set of the current response. If the context carries none, a fresh set is created,
+// which still deduplicates lookups within the calling function.
+func For(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient) *Loaders {
+	if loaders, ok := ctx.Value(constants.Loaders).(*Loaders); ok {
+		return loaders
+	}
+	return NewLoaders(clientClerkHandler)
+}
@@ -0,0 +1,87 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Event is a change published on a topic, Payload is the graphql model of the changed entity
+type Event struct {
+	Topic   string
+	Payload any
+}
+
+// Source delivers published events to the subscribers of their topic.
+// The in-process implementation serves a single clerk instance, a message bus can be plugged in instead.
+type Source interface {
+	Publish(ctx context.Context, event Event) error
+	// Subscribe returns the events of the topics until ctx is done, then the channel is closed
+	Subscribe(ctx context.Context, topics ...string) (<-chan Event, error)
+}
+
+func ArchivingStatusTopic(id string) string {
+	return "archiving-status/" + id
+}
+
+func CheckErrorsTopic(collectionId string) string {
+	return "check-errors/" + collectionId
+}
+
+func FillLevelTopic(storageLocationId string) string {
+	return "fill-level/" + storageLocationId
+}
+
+// DefaultBuffer is the number of events kept per subscriber before further events are dropped
+const DefaultBuffer = 64
+
+// InProcess delivers events within the process. A subscriber which does not keep up loses events
+// instead of blocking the publisher.
+type InProcess struct {
+	lock        sync.RWMutex
+	subscribers map[string]map[chan Event]struct{}
+	buffer      int
+}
+
+func NewInProcess(buffer int) *InProcess {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	return &InProcess{subscribers: map[string]map[chan Event]struct{}{}, buffer: buffer}
+}
+
+func (s *InProcess) Publish(ctx context.Context, event Event) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for ch := range s.subscribers[event.Topic] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *InProcess) Subscribe(ctx context.Context, topics ...string) (<-chan Event, error) {
+	ch := make(chan Event, s.buffer)
+	s.lock.Lock()
+	for _, topic := range topics {
+		if s.subscribers[topic] == nil {
+			s.subscribers[topic] = map[chan Event]struct{}{}
+		}
+		s.subscribers[topic][ch] = struct{}{}
+	}
+	s.lock.Unlock()
+	go func() {
+		<-ctx.Done()
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		for _, topic := range topics {
+			delete(s.subscribers[topic], ch)
+			if len(s.subscribers[topic]) == 0 {
+				delete(s.subscribers, topic)
+			}
+		}
+		close(ch)
+	}()
+	return ch, nil
+}
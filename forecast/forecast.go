@@ -0,0 +1,86 @@
+package forecast
+
+import (
+	"time"
+)
+
+// Sample is the used size of a storage location or partition at a point in time
+type Sample struct {
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// Forecast projects when the used size reaches the capacity. DaysUntilFull is nil if the size does not grow
+// or there are too few samples to tell.
+type Forecast struct {
+	GrowthPerDay  float64
+	DaysUntilFull *float64
+}
+
+// Linear fits a line through all samples with least squares
+func Linear(samples []Sample, used int64, capacity int64) Forecast {
+	if len(samples) < 2 {
+		return Forecast{}
+	}
+	start := samples[0].Time
+	var sumX, sumY float64
+	for _, sample := range samples {
+		sumX += days(sample.Time.Sub(start))
+		sumY += float64(sample.Size)
+	}
+	n := float64(len(samples))
+	meanX, meanY := sumX/n, sumY/n
+	var covariance, variance float64
+	for _, sample := range samples {
+		dx := days(sample.Time.Sub(start)) - meanX
+		covariance += dx * (float64(sample.Size) - meanY)
+		variance += dx * dx
+	}
+	if variance == 0 {
+		return Forecast{}
+	}
+	return project(covariance/variance, used, capacity)
+}
+
+// MovingAverage averages the growth per day between the last window+1 samples, so it follows recent changes
+// of the ingest rate faster than the linear model
+func MovingAverage(samples []Sample, window int, used int64, capacity int64) Forecast {
+	if window > 0 && len(samples) > window+1 {
+		samples = samples[len(samples)-window-1:]
+	}
+	var sum float64
+	intervals := 0
+	for i := 1; i < len(samples); i++ {
+		elapsed := days(samples[i].Time.Sub(samples[i-1].Time))
+		if elapsed <= 0 {
+			continue
+		}
+		sum += float64(samples[i].Size-samples[i-1].Size) / elapsed
+		intervals++
+	}
+	if intervals == 0 {
+		return Forecast{}
+	}
+	return project(sum/float64(intervals), used, capacity)
+}
+
+func project(growthPerDay float64, used int64, capacity int64) Forecast {
+	result := Forecast{GrowthPerDay: growthPerDay}
+	if capacity <= 0 {
+		return result
+	}
+	if used >= capacity {
+		full := 0.0
+		result.DaysUntilFull = &full
+		return result
+	}
+	if growthPerDay > 0 {
+		daysUntilFull := float64(capacity-used) / growthPerDay
+		result.DaysUntilFull = &daysUntilFull
+	}
+	return result
+}
+
+func days(d time.Duration) float64 {
+	return d.Hours() / 24
+}
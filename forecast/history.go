@@ -0,0 +1,146 @@
+package forecast
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/atomicfile"
+)
+
+// History keeps the size samples per storage location and partition for the retention. With a file the
+// samples are written as one json document after every sampling round, without one they only live as long
+// as the process.
+type History struct {
+	lock      sync.RWMutex
+	path      string
+	retention time.Duration
+	samples   map[string][]Sample
+}
+
+func NewHistory(path string, retention time.Duration) (*History, error) {
+	h := &History{path: path, retention: retention, samples: map[string][]Sample{}}
+	if path == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read capacity history %s", path)
+	}
+	if len(data) == 0 {
+		return h, nil
+	}
+	if err := json.Unmarshal(data, &h.samples); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse capacity history %s", path)
+	}
+	return h, nil
+}
+
+// Key names the samples of an entity, kind is the one of the policy engine
+func Key(kind string, id string) string {
+	return kind + "/" + id
+}
+
+// Add appends the sample and drops the ones older than the retention
+func (h *History) Add(ctx context.Context, key string, sample Sample) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	samples := append(h.samples[key], sample)
+	if h.retention > 0 {
+		cutoff := sample.Time.Add(-h.retention)
+		first := 0
+		for first < len(samples) && samples[first].Time.Before(cutoff) {
+			first++
+		}
+		samples = samples[first:]
+	}
+	h.samples[key] = samples
+}
+
+// Samples returns the samples of the entity, oldest first
+func (h *History) Samples(ctx context.Context, key string) []Sample {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return append([]Sample(nil), h.samples[key]...)
+}
+
+// Forget drops the entities which were not sampled within the retention before now
+func (h *History) Forget(ctx context.Context, now time.Time) {
+	if h.retention <= 0 {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	before := now.Add(-h.retention)
+	for key, samples := range h.samples {
+		if len(samples) == 0 || samples[len(samples)-1].Time.Before(before) {
+			delete(h.samples, key)
+		}
+	}
+}
+
+// Save merges the samples with the ones in the file and writes them back under the lock of the file, so clerks
+// sharing the history add to it instead of overwriting each other. The merged samples are kept in memory too.
+func (h *History) Save(ctx context.Context) error {
+	if h.path == "" {
+		return nil
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	err := atomicfile.Update(h.path, func(data []byte) ([]byte, error) {
+		stored := map[string][]Sample{}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &stored); err != nil {
+				return nil, errors.Wrapf(err, "cannot parse capacity history %s", h.path)
+			}
+		}
+		h.samples = merge(h.samples, stored, time.Now(), h.retention)
+		data, err := json.Marshal(h.samples)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot marshal capacity history")
+		}
+		return data, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot write capacity history %s", h.path)
+	}
+	return nil
+}
+
+// merge joins the samples per entity ordered by time, samples of the same time are kept once. Samples older
+// than the retention before now are dropped, with them the entities which were not sampled anymore.
+func merge(samples map[string][]Sample, stored map[string][]Sample, now time.Time, retention time.Duration) map[string][]Sample {
+	result := make(map[string][]Sample, len(samples))
+	for _, from := range []map[string][]Sample{samples, stored} {
+		for key, keySamples := range from {
+			result[key] = append(result[key], keySamples...)
+		}
+	}
+	for key, keySamples := range result {
+		slices.SortStableFunc(keySamples, func(a, b Sample) int {
+			return a.Time.Compare(b.Time)
+		})
+		keySamples = slices.CompactFunc(keySamples, func(a, b Sample) bool {
+			return a.Time.Equal(b.Time)
+		})
+		if retention > 0 {
+			cutoff := now.Add(-retention)
+			keySamples = slices.DeleteFunc(keySamples, func(sample Sample) bool {
+				return sample.Time.Before(cutoff)
+			})
+		}
+		if len(keySamples) == 0 {
+			delete(result, key)
+			continue
+		}
+		result[key] = keySamples
+	}
+	return result
+}
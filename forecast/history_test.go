@@ -0,0 +1,48 @@
+package forecast
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistorySaveMerges(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "history.json")
+	now := time.Now().Truncate(time.Second)
+	first, err := NewHistory(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewHistory(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Add(ctx, "StorageLocation/1", Sample{Time: now.Add(-2 * time.Minute), Size: 10})
+	second.Add(ctx, "StorageLocation/1", Sample{Time: now.Add(-time.Minute), Size: 20})
+	second.Add(ctx, "StorageLocation/2", Sample{Time: now, Size: 5})
+	// expired before the first save, dropped on merge
+	second.Add(ctx, "StorageLocation/3", Sample{Time: now.Add(-2 * time.Hour), Size: 1})
+	for _, history := range []*History{first, second, first} {
+		if err := history.Save(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loaded, err := NewHistory(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples := loaded.Samples(ctx, "StorageLocation/1"); len(samples) != 2 || samples[0].Size != 10 || samples[1].Size != 20 {
+		t.Errorf("samples of location 1 = %v, want the ones of both histories in order", samples)
+	}
+	if samples := loaded.Samples(ctx, "StorageLocation/2"); len(samples) != 1 {
+		t.Errorf("samples of location 2 = %v, want one", samples)
+	}
+	if samples := loaded.Samples(ctx, "StorageLocation/3"); len(samples) != 0 {
+		t.Errorf("samples of location 3 = %v, want none", samples)
+	}
+	if samples := first.Samples(ctx, "StorageLocation/2"); len(samples) != 1 {
+		t.Errorf("first history did not take over the samples of the second: %v", samples)
+	}
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
+	"github.com/ocfl-archive/dlza-manager-clerk/middleware"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	"github.com/ocfl-archive/dlza-manager-clerk/service"
+)
+
+// This file will not be regenerated automatically.
+//
+// It records the mutations in the audit trail.
+
+// record writes the mutation of the caller to the audit trail
+func (r *Resolver) record(ctx context.Context, action policy.Action, kind string, id string, before any, after any) {
+	if r.Audit == nil {
+		return
+	}
+	r.Audit.Record(ctx, actorOf(ctx), string(action), kind, id, before, after)
+}
+
+// actorOf identifies the caller of the request
+func actorOf(ctx context.Context) audit.Actor {
+	if c, err := middleware.GinContextFromContext(ctx); err == nil {
+		claims, _ := middleware.GetUser(c)
+		return audit.ActorFromClaims(claims, audit.SourceGraphQL, c.ClientIP())
+	}
+	return audit.ActorFromClaims(nil, audit.SourceGraphQL, "")
+}
+
+// snapshot loads the entity before it is changed, the mutation is not held up if that fails
+func (r *Resolver) snapshot(ctx context.Context, kind string, id string) any {
+	if r.Audit == nil || id == "" {
+		return nil
+	}
+	before, err := service.Snapshot(ctx, r.ClientClerkHandler, kind, id)
+	if err != nil {
+		r.Logger.Warn().Msgf("cannot snapshot %s %s for audit: %v", kind, id, err)
+		return nil
+	}
+	return before
+}
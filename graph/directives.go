@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"emperror.dev/errors"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	"github.com/ocfl-archive/dlza-manager-clerk/middleware"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+)
+
+// This file will not be regenerated automatically.
+//
+// It binds the schema directives to their implementations.
+
+func NewDirectiveRoot(engine *policy.Engine) DirectiveRoot {
+	return DirectiveRoot{
+		HasTenantPermission: func(ctx context.Context, obj any, next graphql.Resolver, action model.TenantAction) (any, error) {
+			if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
+				return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
+			}
+			subject, err := policy.SubjectFromContext(ctx)
+			if err != nil {
+				return nil, middleware.GraphqlErrorWrapper(err, ctx, http.StatusUnauthorized)
+			}
+			if err := engine.Authorize(ctx, subject, policy.Action(action), permissionTargets(ctx, obj, action)); err != nil {
+				return nil, middleware.GraphqlErrorWrapper(err, ctx, http.StatusInternalServerError)
+			}
+			return next(ctx)
+		},
+		IsAdmin: func(ctx context.Context, obj any, next graphql.Resolver) (any, error) {
+			if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
+				return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
+			}
+			subject, err := policy.SubjectFromContext(ctx)
+			if err != nil {
+				return nil, middleware.GraphqlErrorWrapper(err, ctx, http.StatusUnauthorized)
+			}
+			if !subject.IsAdmin() {
+				err := errors.Errorf("You are not allowed to proceed with %s, it is reserved to %s", graphql.GetFieldContext(ctx).Field.Name, policy.AdminGroup)
+				return nil, middleware.GraphqlErrorWrapper(err, ctx, http.StatusForbidden)
+			}
+			return next(ctx)
+		},
+	}
+}
+
+// permissionTargets collects the entities the field acts on from its arguments and its parent object
+func permissionTargets(ctx context.Context, obj any, action model.TenantAction) []policy.Target {
+	fc := graphql.GetFieldContext(ctx)
+	targets := make([]policy.Target, 0)
+	kind := fc.Field.Definition.Type.Name()
+	if id, ok := fc.Args["id"].(string); ok && id != "" {
+		targets = append(targets, policy.Target{Kind: kind, ID: id})
+	}
+	if input := fc.Args["input"]; input != nil {
+		if tenantId := stringField(input, "TenantID"); tenantId != "" {
+			targets = append(targets, policy.Target{Kind: policy.KindTenant, ID: tenantId})
+		}
+		if storageLocationId := stringField(input, "StorageLocationID"); storageLocationId != "" {
+			targets = append(targets, policy.Target{Kind: policy.KindStorageLocation, ID: storageLocationId})
+		}
+		// updates must be allowed on the tenant currently owning the entity as well
+		if id := stringField(input, "ID"); id != "" && action != model.TenantActionCreate {
+			targets = append(targets, policy.Target{Kind: kind, ID: id})
+		}
+	}
+	if options := fc.Args["options"]; options != nil {
+		if tenantId := stringField(options, "TenantID"); tenantId != "" {
+			targets = append(targets, policy.Target{Kind: policy.KindTenant, ID: tenantId})
+		}
+		if collectionId := stringField(options, "CollectionID"); collectionId != "" {
+			targets = append(targets, policy.Target{Kind: policy.KindCollection, ID: collectionId})
+		}
+		if storageLocationId := stringField(options, "StorageLocationID"); storageLocationId != "" {
+			targets = append(targets, policy.Target{Kind: policy.KindStorageLocation, ID: storageLocationId})
+		}
+		if objectId := stringField(options, "ObjectID"); objectId != "" {
+			targets = append(targets, policy.Target{Kind: policy.KindObject, ID: objectId})
+		}
+		if objectInstanceId := stringField(options, "ObjectInstanceID"); objectInstanceId != "" {
+			targets = append(targets, policy.Target{Kind: policy.KindObjectInstance, ID: objectInstanceId})
+		}
+	}
+	for _, arg := range []struct{ name, kind string }{
+		{"tenantId", policy.KindTenant},
+		{"collectionId", policy.KindCollection},
+		{"storageLocationId", policy.KindStorageLocation},
+	} {
+		if id := stringArg(fc.Args[arg.name]); id != "" {
+			targets = append(targets, policy.Target{Kind: arg.kind, ID: id})
+		}
+	}
+	if obj != nil {
+		if tenantId := stringField(obj, "TenantID"); tenantId != "" {
+			targets = append(targets, policy.Target{Kind: policy.KindTenant, ID: tenantId})
+		} else if id := stringField(obj, "ID"); id != "" {
+			targets = append(targets, policy.Target{Kind: fc.Object, ID: id})
+		}
+	}
+	return targets
+}
+
+// stringArg reads a String or ID argument, which is a *string if nullable
+func stringArg(v any) string {
+	switch arg := v.(type) {
+	case string:
+		return arg
+	case *string:
+		if arg != nil {
+			return *arg
+		}
+	}
+	return ""
+}
+
+// stringField reads a string or *string field of a generated model struct
+func stringField(v any, name string) string {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return ""
+	}
+	field := value.FieldByName(name)
+	if !field.IsValid() {
+		return ""
+	}
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+	if field.Kind() != reflect.String {
+		return ""
+	}
+	return field.String()
+}
@@ -20,32 +20,123 @@ type PaginatedList interface {
 	GetTotalItems() int
 }
 
+type ArchivingStatus struct {
+	ID          string `json:"id"`
+	Status      string `json:"status"`
+	LastChanged string `json:"lastChanged"`
+}
+
+type AuditEvent struct {
+	ID       string       `json:"id"`
+	Time     string       `json:"time"`
+	Actor    string       `json:"actor"`
+	Subject  string       `json:"subject"`
+	Source   string       `json:"source"`
+	IP       string       `json:"ip"`
+	Action   TenantAction `json:"action"`
+	Kind     string       `json:"kind"`
+	TargetID string       `json:"targetId"`
+	Before   *string      `json:"before,omitempty"`
+	After    *string      `json:"after,omitempty"`
+}
+
+func (AuditEvent) IsNode()            {}
+func (this AuditEvent) GetID() string { return this.ID }
+
+type AuditEventList struct {
+	Items      []*AuditEvent `json:"items"`
+	TotalItems int           `json:"totalItems"`
+}
+
+func (AuditEventList) IsPaginatedList() {}
+func (this AuditEventList) GetItems() []Node {
+	if this.Items == nil {
+		return nil
+	}
+	interfaceSlice := make([]Node, 0, len(this.Items))
+	for _, concrete := range this.Items {
+		interfaceSlice = append(interfaceSlice, concrete)
+	}
+	return interfaceSlice
+}
+func (this AuditEventList) GetTotalItems() int { return this.TotalItems }
+
+type AuditEventListOptions struct {
+	Actor    *string       `json:"actor,omitempty"`
+	Action   *TenantAction `json:"action,omitempty"`
+	Kind     *string       `json:"kind,omitempty"`
+	TargetID *string       `json:"targetId,omitempty"`
+	Source   *string       `json:"source,omitempty"`
+	From     *string       `json:"from,omitempty"`
+	To       *string       `json:"to,omitempty"`
+	Skip     *int          `json:"skip,omitempty"`
+	Take     *int          `json:"take,omitempty"`
+}
+
 type Auth struct {
 	AuthCodeURL string `json:"authCodeUrl"`
 }
 
+type CapacityForecast struct {
+	Samples       int             `json:"samples"`
+	Since         string          `json:"since"`
+	Used          float64         `json:"used"`
+	Capacity      float64         `json:"capacity"`
+	Linear        *ForecastResult `json:"linear"`
+	MovingAverage *ForecastResult `json:"movingAverage"`
+	DaysUntilFull *float64        `json:"daysUntilFull,omitempty"`
+}
+
+type CheckErrorEvent struct {
+	CollectionID   string      `json:"collectionId"`
+	Collection     *Collection `json:"collection"`
+	AmountOfErrors int         `json:"amountOfErrors"`
+	NewErrors      int         `json:"newErrors"`
+}
+
 type Collection struct {
-	ID                                   string      `json:"id"`
-	Alias                                string      `json:"alias"`
-	Description                          string      `json:"description"`
-	Owner                                string      `json:"owner"`
-	OwnerMail                            string      `json:"ownerMail"`
-	Name                                 string      `json:"name"`
-	Quality                              int         `json:"quality"`
-	TenantID                             string      `json:"tenantId"`
-	Tenant                               *Tenant     `json:"tenant"`
-	Objects                              *ObjectList `json:"objects"`
-	Files                                *FileList   `json:"files"`
-	TotalFileSize                        float64     `json:"totalFileSize"`
-	TotalObjectSizeForAllObjectInstances float64     `json:"totalObjectSizeForAllObjectInstances"`
-	TotalFileCount                       int         `json:"totalFileCount"`
-	TotalObjectCount                     int         `json:"totalObjectCount"`
-	AmountOfErrors                       int         `json:"amountOfErrors"`
+	ID                                   string          `json:"id"`
+	Alias                                string          `json:"alias"`
+	Description                          string          `json:"description"`
+	Owner                                string          `json:"owner"`
+	OwnerMail                            string          `json:"ownerMail"`
+	Name                                 string          `json:"name"`
+	Quality                              int             `json:"quality"`
+	TenantID                             string          `json:"tenantId"`
+	Tenant                               *Tenant         `json:"tenant"`
+	Objects                              *ObjectList     `json:"objects"`
+	Files                                *FileList       `json:"files"`
+	TotalFileSize                        float64         `json:"totalFileSize"`
+	TotalObjectSizeForAllObjectInstances float64         `json:"totalObjectSizeForAllObjectInstances"`
+	TotalFileCount                       int             `json:"totalFileCount"`
+	TotalObjectCount                     int             `json:"totalObjectCount"`
+	AmountOfErrors                       int             `json:"amountOfErrors"`
+	DeleteImpact                         *DeleteImpact   `json:"deleteImpact,omitempty"`
+	ReplicationGap                       *ReplicationGap `json:"replicationGap"`
+	OverdueObjectInstances               int             `json:"overdueObjectInstances"`
 }
 
 func (Collection) IsNode()            {}
 func (this Collection) GetID() string { return this.ID }
 
+type CollectionConnection struct {
+	Edges      []*CollectionEdge `json:"edges"`
+	PageInfo   *PageInfo         `json:"pageInfo"`
+	TotalCount int               `json:"totalCount"`
+}
+
+type CollectionCost struct {
+	CollectionID     string                 `json:"collectionId"`
+	Alias            string                 `json:"alias"`
+	Total            float64                `json:"total"`
+	StorageLocations []*StorageLocationCost `json:"storageLocations"`
+}
+
+type CollectionEdge struct {
+	Cursor string      `json:"cursor"`
+	Node   *Collection `json:"node"`
+}
+
 type CollectionInput struct {
 	ID          string `json:"id"`
 	Alias       string `json:"alias"`
@@ -84,6 +175,34 @@ type CollectionListOptions struct {
 	Search        *string            `json:"search,omitempty"`
 }
 
+type CostReport struct {
+	TenantID    string            `json:"tenantId"`
+	Period      string            `json:"period"`
+	Unit        string            `json:"unit"`
+	Currency    string            `json:"currency"`
+	Total       float64           `json:"total"`
+	Collections []*CollectionCost `json:"collections"`
+}
+
+type DeleteImpact struct {
+	DryRun                    bool    `json:"dryRun"`
+	Objects                   int     `json:"objects"`
+	ObjectInstances           int     `json:"objectInstances"`
+	Files                     int     `json:"files"`
+	TotalSize                 float64 `json:"totalSize"`
+	LastCopyLost              bool    `json:"lastCopyLost"`
+	ObjectsLosingLastCopy     int     `json:"objectsLosingLastCopy"`
+	ObjectsBelowNeededQuality int     `json:"objectsBelowNeededQuality"`
+}
+
+type DrainProgress struct {
+	StartObjects     int     `json:"startObjects"`
+	StartSize        int     `json:"startSize"`
+	RemainingObjects int     `json:"remainingObjects"`
+	RemainingSize    int     `json:"remainingSize"`
+	Progress         float64 `json:"progress"`
+}
+
 type File struct {
 	ID       string   `json:"id"`
 	Checksum string   `json:"checksum"`
@@ -101,6 +220,17 @@ type File struct {
 func (File) IsNode()            {}
 func (this File) GetID() string { return this.ID }
 
+type FileConnection struct {
+	Edges      []*FileEdge `json:"edges"`
+	PageInfo   *PageInfo   `json:"pageInfo"`
+	TotalCount int         `json:"totalCount"`
+}
+
+type FileEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *File  `json:"node"`
+}
+
 type FileList struct {
 	Items      []*File `json:"items"`
 	TotalItems int     `json:"totalItems"`
@@ -130,6 +260,42 @@ type FileListOptions struct {
 	Search        *string        `json:"search,omitempty"`
 }
 
+type ForecastResult struct {
+	GrowthPerDay  float64  `json:"growthPerDay"`
+	DaysUntilFull *float64 `json:"daysUntilFull,omitempty"`
+	FullAt        *string  `json:"fullAt,omitempty"`
+}
+
+type IntegrityErrorCount struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+type IntegrityReport struct {
+	TenantID                  *string                       `json:"tenantId,omitempty"`
+	CollectionID              *string                       `json:"collectionId,omitempty"`
+	StorageLocationID         *string                       `json:"storageLocationId,omitempty"`
+	From                      string                        `json:"from"`
+	To                        string                        `json:"to"`
+	Bucket                    IntegrityReportBucket         `json:"bucket"`
+	ObjectInstances           int                           `json:"objectInstances"`
+	Checks                    int                           `json:"checks"`
+	Errors                    int                           `json:"errors"`
+	FailingObjectInstances    int                           `json:"failingObjectInstances"`
+	MeanHoursSinceLastSuccess *float64                      `json:"meanHoursSinceLastSuccess,omitempty"`
+	NeverSucceeded            int                           `json:"neverSucceeded"`
+	TopErrors                 []*IntegrityErrorCount        `json:"topErrors"`
+	Buckets                   []*IntegrityReportBucketStats `json:"buckets"`
+}
+
+type IntegrityReportBucketStats struct {
+	Start                  string `json:"start"`
+	End                    string `json:"end"`
+	Checks                 int    `json:"checks"`
+	Errors                 int    `json:"errors"`
+	FailingObjectInstances int    `json:"failingObjectInstances"`
+}
+
 type MimeType struct {
 	ID        string  `json:"id"`
 	FileCount int     `json:"fileCount"`
@@ -203,6 +369,17 @@ type Object struct {
 func (Object) IsNode()            {}
 func (this Object) GetID() string { return this.ID }
 
+type ObjectConnection struct {
+	Edges      []*ObjectEdge `json:"edges"`
+	PageInfo   *PageInfo     `json:"pageInfo"`
+	TotalCount int           `json:"totalCount"`
+}
+
+type ObjectEdge struct {
+	Cursor string  `json:"cursor"`
+	Node   *Object `json:"node"`
+}
+
 type ObjectInstance struct {
 	ID                   string                   `json:"id"`
 	Path                 string                   `json:"path"`
@@ -231,6 +408,17 @@ type ObjectInstanceCheck struct {
 func (ObjectInstanceCheck) IsNode()            {}
 func (this ObjectInstanceCheck) GetID() string { return this.ID }
 
+type ObjectInstanceCheckConnection struct {
+	Edges      []*ObjectInstanceCheckEdge `json:"edges"`
+	PageInfo   *PageInfo                  `json:"pageInfo"`
+	TotalCount int                        `json:"totalCount"`
+}
+
+type ObjectInstanceCheckEdge struct {
+	Cursor string               `json:"cursor"`
+	Node   *ObjectInstanceCheck `json:"node"`
+}
+
 type ObjectInstanceCheckList struct {
 	Items      []*ObjectInstanceCheck `json:"items"`
 	TotalItems int                    `json:"totalItems"`
@@ -259,6 +447,17 @@ type ObjectInstanceCheckListOptions struct {
 	Search           *string                     `json:"search,omitempty"`
 }
 
+type ObjectInstanceConnection struct {
+	Edges      []*ObjectInstanceEdge `json:"edges"`
+	PageInfo   *PageInfo             `json:"pageInfo"`
+	TotalCount int                   `json:"totalCount"`
+}
+
+type ObjectInstanceEdge struct {
+	Cursor string          `json:"cursor"`
+	Node   *ObjectInstance `json:"node"`
+}
+
 type ObjectInstanceList struct {
 	Items      []*ObjectInstance `json:"items"`
 	TotalItems int               `json:"totalItems"`
@@ -315,6 +514,44 @@ type ObjectListOptions struct {
 	Search        *string        `json:"search,omitempty"`
 }
 
+type OverdueObjectInstance struct {
+	ID                  string          `json:"id"`
+	ObjectInstance      *ObjectInstance `json:"objectInstance"`
+	CollectionID        string          `json:"collectionId"`
+	StorageLocationID   string          `json:"storageLocationId"`
+	LastSuccessfulCheck *string         `json:"lastSuccessfulCheck,omitempty"`
+	MaxAgeDays          float64         `json:"maxAgeDays"`
+	OverdueDays         float64         `json:"overdueDays"`
+}
+
+func (OverdueObjectInstance) IsNode()            {}
+func (this OverdueObjectInstance) GetID() string { return this.ID }
+
+type OverdueObjectInstanceList struct {
+	Items      []*OverdueObjectInstance `json:"items"`
+	TotalItems int                      `json:"totalItems"`
+}
+
+func (OverdueObjectInstanceList) IsPaginatedList() {}
+func (this OverdueObjectInstanceList) GetItems() []Node {
+	if this.Items == nil {
+		return nil
+	}
+	interfaceSlice := make([]Node, 0, len(this.Items))
+	for _, concrete := range this.Items {
+		interfaceSlice = append(interfaceSlice, concrete)
+	}
+	return interfaceSlice
+}
+func (this OverdueObjectInstanceList) GetTotalItems() int { return this.TotalItems }
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor,omitempty"`
+	EndCursor       *string `json:"endCursor,omitempty"`
+}
+
 type PronomID struct {
 	ID        string  `json:"id"`
 	FileCount int     `json:"fileCount"`
@@ -354,43 +591,128 @@ type PronomIDListOptions struct {
 type Query struct {
 }
 
+type ReplicaStatus struct {
+	ObjectInstanceID     string `json:"objectInstanceId"`
+	StorageLocationID    string `json:"storageLocationId"`
+	StorageLocationAlias string `json:"storageLocationAlias"`
+	Quality              int    `json:"quality"`
+	Status               string `json:"status"`
+}
+
+type ReplicationGap struct {
+	Objects                int    `json:"objects"`
+	UnderReplicatedObjects int    `json:"underReplicatedObjects"`
+	MissingQuality         int    `json:"missingQuality"`
+	LargestGap             int    `json:"largestGap"`
+	ComputedAt             string `json:"computedAt"`
+}
+
+type S3Connection struct {
+	Endpoint           string `json:"endpoint"`
+	Bucket             string `json:"bucket"`
+	Region             string `json:"region"`
+	AccessKeyID        string `json:"accessKeyId"`
+	HasSecretAccessKey bool   `json:"hasSecretAccessKey"`
+}
+
+type S3ConnectionInput struct {
+	Endpoint        string  `json:"endpoint"`
+	Bucket          string  `json:"bucket"`
+	Region          *string `json:"region,omitempty"`
+	AccessKeyID     string  `json:"accessKeyId"`
+	SecretAccessKey *string `json:"secretAccessKey,omitempty"`
+}
+
+type SFTPConnection struct {
+	Host          string `json:"host"`
+	Port          int    `json:"port"`
+	User          string `json:"user"`
+	HasPassword   bool   `json:"hasPassword"`
+	HasPrivateKey bool   `json:"hasPrivateKey"`
+}
+
+type SFTPConnectionInput struct {
+	Host       string  `json:"host"`
+	Port       *int    `json:"port,omitempty"`
+	User       string  `json:"user"`
+	Password   *string `json:"password,omitempty"`
+	PrivateKey *string `json:"privateKey,omitempty"`
+}
+
+type StorageConnection struct {
+	Folder      string          `json:"folder"`
+	Backend     StorageBackend  `json:"backend"`
+	S3          *S3Connection   `json:"s3,omitempty"`
+	Sftp        *SFTPConnection `json:"sftp,omitempty"`
+	OtherFields []string        `json:"otherFields"`
+}
+
+type StorageConnectionInput struct {
+	Folder string               `json:"folder"`
+	S3     *S3ConnectionInput   `json:"s3,omitempty"`
+	Sftp   *SFTPConnectionInput `json:"sftp,omitempty"`
+}
+
+type StorageConnectionTest struct {
+	Ok               bool     `json:"ok"`
+	Path             string   `json:"path"`
+	LatencyMs        float64  `json:"latencyMs"`
+	PermissionDenied bool     `json:"permissionDenied"`
+	FreeSpace        *float64 `json:"freeSpace,omitempty"`
+	Error            *string  `json:"error,omitempty"`
+}
+
 type StorageLocation struct {
-	ID                  string                `json:"id"`
-	Alias               string                `json:"alias"`
-	Type                string                `json:"type"`
-	Vault               string                `json:"vault"`
-	Connection          string                `json:"connection"`
-	Quality             int                   `json:"quality"`
-	Price               int                   `json:"price"`
-	SecurityCompliency  string                `json:"securityCompliency"`
-	FillFirst           bool                  `json:"fillFirst"`
-	OcflType            string                `json:"ocflType"`
-	TenantID            string                `json:"tenantId"`
-	Tenant              *Tenant               `json:"tenant"`
-	NumberOfThreads     int                   `json:"numberOfThreads"`
-	TotalFilesSize      float64               `json:"totalFilesSize"`
-	TotalExistingVolume float64               `json:"totalExistingVolume"`
-	StoragePartitions   *StoragePartitionList `json:"storagePartitions"`
-	AmountOfErrors      int                   `json:"amountOfErrors"`
-	AmountOfObjects     int                   `json:"amountOfObjects"`
+	ID                     string                `json:"id"`
+	Alias                  string                `json:"alias"`
+	Type                   string                `json:"type"`
+	Vault                  string                `json:"vault"`
+	Connection             string                `json:"connection"`
+	ConnectionConfig       *StorageConnection    `json:"connectionConfig,omitempty"`
+	Quality                int                   `json:"quality"`
+	Price                  int                   `json:"price"`
+	SecurityCompliency     string                `json:"securityCompliency"`
+	FillFirst              bool                  `json:"fillFirst"`
+	OcflType               string                `json:"ocflType"`
+	TenantID               string                `json:"tenantId"`
+	Tenant                 *Tenant               `json:"tenant"`
+	NumberOfThreads        int                   `json:"numberOfThreads"`
+	TotalFilesSize         float64               `json:"totalFilesSize"`
+	TotalExistingVolume    float64               `json:"totalExistingVolume"`
+	StoragePartitions      *StoragePartitionList `json:"storagePartitions"`
+	AmountOfErrors         int                   `json:"amountOfErrors"`
+	AmountOfObjects        int                   `json:"amountOfObjects"`
+	DeleteImpact           *DeleteImpact         `json:"deleteImpact,omitempty"`
+	CapacityForecast       *CapacityForecast     `json:"capacityForecast,omitempty"`
+	OverdueObjectInstances int                   `json:"overdueObjectInstances"`
 }
 
 func (StorageLocation) IsNode()            {}
 func (this StorageLocation) GetID() string { return this.ID }
 
+type StorageLocationCost struct {
+	StorageLocationID string  `json:"storageLocationId"`
+	Alias             string  `json:"alias"`
+	Price             int     `json:"price"`
+	ObjectInstances   int     `json:"objectInstances"`
+	AverageSize       float64 `json:"averageSize"`
+	Cost              float64 `json:"cost"`
+}
+
 type StorageLocationInput struct {
-	ID                 string `json:"id"`
-	Alias              string `json:"alias"`
-	Type               string `json:"type"`
-	Vault              string `json:"vault"`
-	Connection         string `json:"connection"`
-	Quality            int    `json:"quality"`
-	Price              int    `json:"price"`
-	SecurityCompliency string `json:"securityCompliency"`
-	FillFirst          bool   `json:"fillFirst"`
-	OcflType           string `json:"ocflType"`
-	TenantID           string `json:"tenantId"`
-	NumberOfThreads    int    `json:"numberOfThreads"`
+	ID                 string                  `json:"id"`
+	Alias              string                  `json:"alias"`
+	Type               string                  `json:"type"`
+	Vault              string                  `json:"vault"`
+	Connection         *string                 `json:"connection,omitempty"`
+	ConnectionConfig   *StorageConnectionInput `json:"connectionConfig,omitempty"`
+	Quality            int                     `json:"quality"`
+	Price              int                     `json:"price"`
+	SecurityCompliency string                  `json:"securityCompliency"`
+	FillFirst          bool                    `json:"fillFirst"`
+	OcflType           string                  `json:"ocflType"`
+	TenantID           string                  `json:"tenantId"`
+	NumberOfThreads    int                     `json:"numberOfThreads"`
 }
 
 type StorageLocationList struct {
@@ -422,16 +744,20 @@ type StorageLocationListOptions struct {
 }
 
 type StoragePartition struct {
-	ID                string              `json:"id"`
-	Alias             string              `json:"alias"`
-	Name              string              `json:"name"`
-	MaxSize           int                 `json:"maxSize"`
-	MaxObjects        int                 `json:"maxObjects"`
-	CurrentSize       int                 `json:"currentSize"`
-	CurrentObjects    int                 `json:"currentObjects"`
-	StorageLocationID string              `json:"storageLocationId"`
-	StorageLocation   *StorageLocation    `json:"storageLocation"`
-	ObjectInstances   *ObjectInstanceList `json:"objectInstances"`
+	ID                string                     `json:"id"`
+	Alias             string                     `json:"alias"`
+	Name              string                     `json:"name"`
+	MaxSize           int                        `json:"maxSize"`
+	MaxObjects        int                        `json:"maxObjects"`
+	CurrentSize       int                        `json:"currentSize"`
+	CurrentObjects    int                        `json:"currentObjects"`
+	StorageLocationID string                     `json:"storageLocationId"`
+	StorageLocation   *StorageLocation           `json:"storageLocation"`
+	ObjectInstances   *ObjectInstanceList        `json:"objectInstances"`
+	DeleteImpact      *DeleteImpact              `json:"deleteImpact,omitempty"`
+	State             StoragePartitionState      `json:"state"`
+	Lifecycle         *StoragePartitionLifecycle `json:"lifecycle,omitempty"`
+	CapacityForecast  *CapacityForecast          `json:"capacityForecast,omitempty"`
 }
 
 func (StoragePartition) IsNode()            {}
@@ -448,6 +774,16 @@ type StoragePartitionInput struct {
 	StorageLocationID string `json:"storageLocationId"`
 }
 
+type StoragePartitionLifecycle struct {
+	State         StoragePartitionState `json:"state"`
+	Changed       string                `json:"changed"`
+	ChangedBy     string                `json:"changedBy"`
+	Reason        string                `json:"reason"`
+	MaxSize       int                   `json:"maxSize"`
+	MaxObjects    int                   `json:"maxObjects"`
+	DrainProgress *DrainProgress        `json:"drainProgress,omitempty"`
+}
+
 type StoragePartitionList struct {
 	Items      []*StoragePartition `json:"items"`
 	TotalItems int                 `json:"totalItems"`
@@ -476,6 +812,9 @@ type StoragePartitionListOptions struct {
 	Search            *string                  `json:"search,omitempty"`
 }
 
+type Subscription struct {
+}
+
 type Tenant struct {
 	ID                   string               `json:"id"`
 	Name                 string               `json:"name"`
@@ -487,11 +826,31 @@ type Tenant struct {
 	Collections          *CollectionList      `json:"collections"`
 	StorageLocations     *StorageLocationList `json:"storageLocations"`
 	Permissions          []string             `json:"permissions,omitempty"`
+	ReplicationGap       *ReplicationGap      `json:"replicationGap"`
 }
 
 func (Tenant) IsNode()            {}
 func (this Tenant) GetID() string { return this.ID }
 
+type TenantConnection struct {
+	Edges      []*TenantEdge `json:"edges"`
+	PageInfo   *PageInfo     `json:"pageInfo"`
+	TotalCount int           `json:"totalCount"`
+}
+
+type TenantEdge struct {
+	Cursor string  `json:"cursor"`
+	Node   *Tenant `json:"node"`
+}
+
+type TenantInput struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Alias  string `json:"alias"`
+	Person string `json:"person"`
+	Email  string `json:"email"`
+}
+
 type TenantList struct {
 	Items      []*Tenant `json:"items"`
 	TotalItems int       `json:"totalItems"`
@@ -518,6 +877,38 @@ type TenantListOptions struct {
 	Search        *string        `json:"search,omitempty"`
 }
 
+type UnderReplicatedObject struct {
+	ID                      string             `json:"id"`
+	Object                  *Object            `json:"object"`
+	CollectionID            string             `json:"collectionId"`
+	NeededQuality           int                `json:"neededQuality"`
+	ResultingQuality        int                `json:"resultingQuality"`
+	QualityGap              int                `json:"qualityGap"`
+	Instances               []*ReplicaStatus   `json:"instances"`
+	MissingStorageLocations []*StorageLocation `json:"missingStorageLocations"`
+}
+
+func (UnderReplicatedObject) IsNode()            {}
+func (this UnderReplicatedObject) GetID() string { return this.ID }
+
+type UnderReplicatedObjectList struct {
+	Items      []*UnderReplicatedObject `json:"items"`
+	TotalItems int                      `json:"totalItems"`
+}
+
+func (UnderReplicatedObjectList) IsPaginatedList() {}
+func (this UnderReplicatedObjectList) GetItems() []Node {
+	if this.Items == nil {
+		return nil
+	}
+	interfaceSlice := make([]Node, 0, len(this.Items))
+	for _, concrete := range this.Items {
+		interfaceSlice = append(interfaceSlice, concrete)
+	}
+	return interfaceSlice
+}
+func (this UnderReplicatedObjectList) GetTotalItems() int { return this.TotalItems }
+
 type User struct {
 	Username string    `json:"username"`
 	Email    string    `json:"email"`
@@ -657,6 +1048,63 @@ func (e FileSortKey) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type IntegrityReportBucket string
+
+const (
+	IntegrityReportBucketDay   IntegrityReportBucket = "DAY"
+	IntegrityReportBucketWeek  IntegrityReportBucket = "WEEK"
+	IntegrityReportBucketMonth IntegrityReportBucket = "MONTH"
+)
+
+var AllIntegrityReportBucket = []IntegrityReportBucket{
+	IntegrityReportBucketDay,
+	IntegrityReportBucketWeek,
+	IntegrityReportBucketMonth,
+}
+
+func (e IntegrityReportBucket) IsValid() bool {
+	switch e {
+	case IntegrityReportBucketDay, IntegrityReportBucketWeek, IntegrityReportBucketMonth:
+		return true
+	}
+	return false
+}
+
+func (e IntegrityReportBucket) String() string {
+	return string(e)
+}
+
+func (e *IntegrityReportBucket) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = IntegrityReportBucket(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid IntegrityReportBucket", str)
+	}
+	return nil
+}
+
+func (e IntegrityReportBucket) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *IntegrityReportBucket) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e IntegrityReportBucket) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type MimeTypeSortKey string
 
 const (
@@ -1023,6 +1471,63 @@ func (e SortDirection) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type StorageBackend string
+
+const (
+	StorageBackendFilesystem StorageBackend = "FILESYSTEM"
+	StorageBackendS3         StorageBackend = "S3"
+	StorageBackendSftp       StorageBackend = "SFTP"
+)
+
+var AllStorageBackend = []StorageBackend{
+	StorageBackendFilesystem,
+	StorageBackendS3,
+	StorageBackendSftp,
+}
+
+func (e StorageBackend) IsValid() bool {
+	switch e {
+	case StorageBackendFilesystem, StorageBackendS3, StorageBackendSftp:
+		return true
+	}
+	return false
+}
+
+func (e StorageBackend) String() string {
+	return string(e)
+}
+
+func (e *StorageBackend) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StorageBackend(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StorageBackend", str)
+	}
+	return nil
+}
+
+func (e StorageBackend) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *StorageBackend) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e StorageBackend) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type StorageLocationSortKey string
 
 const (
@@ -1141,6 +1646,179 @@ func (e StoragePartitionSortKey) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+type StoragePartitionState string
+
+const (
+	StoragePartitionStateActive   StoragePartitionState = "ACTIVE"
+	StoragePartitionStateSealed   StoragePartitionState = "SEALED"
+	StoragePartitionStateDraining StoragePartitionState = "DRAINING"
+	StoragePartitionStateRetired  StoragePartitionState = "RETIRED"
+)
+
+var AllStoragePartitionState = []StoragePartitionState{
+	StoragePartitionStateActive,
+	StoragePartitionStateSealed,
+	StoragePartitionStateDraining,
+	StoragePartitionStateRetired,
+}
+
+func (e StoragePartitionState) IsValid() bool {
+	switch e {
+	case StoragePartitionStateActive, StoragePartitionStateSealed, StoragePartitionStateDraining, StoragePartitionStateRetired:
+		return true
+	}
+	return false
+}
+
+func (e StoragePartitionState) String() string {
+	return string(e)
+}
+
+func (e *StoragePartitionState) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = StoragePartitionState(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid StoragePartitionState", str)
+	}
+	return nil
+}
+
+func (e StoragePartitionState) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *StoragePartitionState) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e StoragePartitionState) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type TenantAction string
+
+const (
+	TenantActionRead   TenantAction = "READ"
+	TenantActionCreate TenantAction = "CREATE"
+	TenantActionUpdate TenantAction = "UPDATE"
+	TenantActionDelete TenantAction = "DELETE"
+)
+
+var AllTenantAction = []TenantAction{
+	TenantActionRead,
+	TenantActionCreate,
+	TenantActionUpdate,
+	TenantActionDelete,
+}
+
+func (e TenantAction) IsValid() bool {
+	switch e {
+	case TenantActionRead, TenantActionCreate, TenantActionUpdate, TenantActionDelete:
+		return true
+	}
+	return false
+}
+
+func (e TenantAction) String() string {
+	return string(e)
+}
+
+func (e *TenantAction) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TenantAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TenantAction", str)
+	}
+	return nil
+}
+
+func (e TenantAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TenantAction) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TenantAction) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type TenantDeleteMode string
+
+const (
+	TenantDeleteModeSafe    TenantDeleteMode = "SAFE"
+	TenantDeleteModeCascade TenantDeleteMode = "CASCADE"
+)
+
+var AllTenantDeleteMode = []TenantDeleteMode{
+	TenantDeleteModeSafe,
+	TenantDeleteModeCascade,
+}
+
+func (e TenantDeleteMode) IsValid() bool {
+	switch e {
+	case TenantDeleteModeSafe, TenantDeleteModeCascade:
+		return true
+	}
+	return false
+}
+
+func (e TenantDeleteMode) String() string {
+	return string(e)
+}
+
+func (e *TenantDeleteMode) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TenantDeleteMode(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TenantDeleteMode", str)
+	}
+	return nil
+}
+
+func (e TenantDeleteMode) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *TenantDeleteMode) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e TenantDeleteMode) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
 type TenantSortKey string
 
 const (
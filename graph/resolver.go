@@ -2,6 +2,12 @@ package graph
 
 import (
 	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
+	"github.com/ocfl-archive/dlza-manager-clerk/events"
+	"github.com/ocfl-archive/dlza-manager-clerk/forecast"
+	"github.com/ocfl-archive/dlza-manager-clerk/lifecycle"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/service"
 	pb "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
 	storagepb "github.com/ocfl-archive/dlza-manager-storage-handler/storagehandlerproto"
 )
@@ -15,4 +21,14 @@ type Resolver struct {
 	ClientClerkHandler        pb.ClerkHandlerServiceClient
 	ClientClerkStorageHandler storagepb.ClerkStorageHandlerServiceClient
 	Logger                    zLogger.ZLogger
+	Events                    events.Source
+	Watcher                   *service.EventWatcher
+	Audit                     *audit.Log
+	Validation                models.ValidationConfig
+	PartitionStates           *lifecycle.Store
+	CapacityHistory           *forecast.History
+	Forecast                  models.ForecastConfig
+	Billing                   models.BillingConfig
+	Fixity                    *service.FixityScanner
+	ReplicationGaps           *service.ReplicationScanner
 }
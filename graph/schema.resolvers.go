@@ -1,17 +1,19 @@
 package graph
 
-// This file will be automatically regenerated based on the schema, any resolver implementations
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
 // will be copied through when generating and any unknown code will be moved to the end.
-// Code generated by github.com/99designs/gqlgen version v0.17.74
+// Code generated by github.com/99designs/gqlgen version v0.17.88
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
 
+	"github.com/ocfl-archive/dlza-manager-clerk/events"
 	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
 	"github.com/ocfl-archive/dlza-manager-clerk/middleware"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
 	"github.com/ocfl-archive/dlza-manager-clerk/service"
 )
 
@@ -20,7 +22,7 @@ func (r *collectionResolver) Objects(ctx context.Context, obj *model.Collection,
 	r.Logger.Debug().Msg("Objects function in resolver was called")
 	collections, err := service.GetObjectsForCollection(ctx, r.ClientClerkHandler, obj, options, r.Logger)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetObjectsForCollection: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectsForCollection: %w", err), ctx, http.StatusInternalServerError)
 	}
 	r.Logger.Debug().Msg("Objects function in resolver returns objects")
 	return collections, nil
@@ -30,27 +32,45 @@ func (r *collectionResolver) Objects(ctx context.Context, obj *model.Collection,
 func (r *collectionResolver) Files(ctx context.Context, obj *model.Collection, options *model.FileListOptions) (*model.FileList, error) {
 	files, err := service.GetFilesForCollection(ctx, r.ClientClerkHandler, obj, options)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetFilesForCollection: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetFilesForCollection: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return files, nil
 }
 
+// ReplicationGap is the resolver for the replicationGap field.
+func (r *collectionResolver) ReplicationGap(ctx context.Context, obj *model.Collection) (*model.ReplicationGap, error) {
+	replicationGap, err := service.GetReplicationGap(r.ReplicationGaps, nil, &obj.ID)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetReplicationGap: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return replicationGap, nil
+}
+
+// OverdueObjectInstances is the resolver for the overdueObjectInstances field.
+func (r *collectionResolver) OverdueObjectInstances(ctx context.Context, obj *model.Collection) (int, error) {
+	overdue, err := service.CountOverdueObjectInstances(r.Fixity, &obj.ID, nil)
+	if err != nil {
+		return 0, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not CountOverdueObjectInstances: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return overdue, nil
+}
+
 // Login is the resolver for the login field.
 func (r *mutationResolver) Login(ctx context.Context, code string) (*model.User, error) {
 	gc, err := middleware.GinContextFromContext(ctx)
 	if err != nil {
-		fmt.Println("gc err", err, "\n", ctx)
+		r.Logger.Error().Msgf("login cannot retrieve gin context: %v", err)
 		return nil, middleware.GraphqlErrorWrapper(err, ctx, http.StatusInternalServerError)
 	}
 
 	err = middleware.Callback(ctx, gc, code)
 	if err != nil {
-		fmt.Println("Callback err", err, "\n", ctx)
+		r.Logger.Error().Msgf("login callback failed: %v", err)
 		return nil, middleware.GraphqlErrorWrapper(err, ctx, http.StatusInternalServerError)
 	}
 	userClaim, err := middleware.GetUser(gc)
 	if err != nil {
-		fmt.Println("GetUser err", err, "\n", ctx)
+		r.Logger.Error().Msgf("login cannot read user: %v", err)
 		middleware.GraphqlErrorWrapper(err, ctx, http.StatusUnauthorized)
 	}
 	user := model.User{
@@ -72,111 +92,177 @@ func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+// CreateTenant is the resolver for the createTenant field.
+func (r *mutationResolver) CreateTenant(ctx context.Context, input *model.TenantInput) (*model.Tenant, error) {
+	tenant, err := service.CreateTenant(ctx, r.ClientClerkHandler, input)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not CreateTenant: %w", err), ctx, http.StatusInternalServerError)
+	}
+	r.record(ctx, policy.Create, policy.KindTenant, tenant.ID, nil, tenant)
+	return tenant, nil
+}
+
+// UpdateTenant is the resolver for the updateTenant field.
+func (r *mutationResolver) UpdateTenant(ctx context.Context, input *model.TenantInput) (*model.Tenant, error) {
+	var before any
+	if input != nil {
+		before = r.snapshot(ctx, policy.KindTenant, input.ID)
+	}
+	tenant, err := service.UpdateTenant(ctx, r.ClientClerkHandler, input)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not UpdateTenant: %w", err), ctx, http.StatusInternalServerError)
+	}
+	r.record(ctx, policy.Update, policy.KindTenant, tenant.ID, before, tenant)
+	return tenant, nil
+}
+
+// DeleteTenant is the resolver for the deleteTenant field.
+func (r *mutationResolver) DeleteTenant(ctx context.Context, id string, mode *model.TenantDeleteMode, confirm *string) (*model.Tenant, error) {
+	deleteMode := model.TenantDeleteModeSafe
+	if mode != nil {
+		deleteMode = *mode
+	}
+	var confirmation string
+	if confirm != nil {
+		confirmation = *confirm
+	}
+	tenant, err := service.DeleteTenant(ctx, r.ClientClerkHandler, r.PartitionStates, id, deleteMode, confirmation)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not DeleteTenant: %w", err), ctx, http.StatusInternalServerError)
+	}
+	r.record(ctx, policy.Delete, policy.KindTenant, id, tenant, nil)
+	return tenant, nil
+}
+
 // CreateCollection is the resolver for the createCollection field.
 func (r *mutationResolver) CreateCollection(ctx context.Context, input *model.CollectionInput) (*model.Collection, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	collection, err := service.CreateCollection(ctx, r.ClientClerkHandler, input)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not CreateCollection: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not CreateCollection: %w", err), ctx, http.StatusInternalServerError)
 	}
+	r.record(ctx, policy.Create, policy.KindCollection, collection.ID, nil, collection)
 	return collection, nil
 }
 
 // UpdateCollection is the resolver for the updateCollection field.
 func (r *mutationResolver) UpdateCollection(ctx context.Context, input *model.CollectionInput) (*model.Collection, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
+	var before any
+	if input != nil {
+		before = r.snapshot(ctx, policy.KindCollection, input.ID)
 	}
 	collection, err := service.UpdateCollection(ctx, r.ClientClerkHandler, input)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not UpdateCollection: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not UpdateCollection: %w", err), ctx, http.StatusInternalServerError)
 	}
+	r.record(ctx, policy.Update, policy.KindCollection, collection.ID, before, collection)
 	return collection, nil
 }
 
 // DeleteCollection is the resolver for the deleteCollection field.
-func (r *mutationResolver) DeleteCollection(ctx context.Context, id string) (*model.Collection, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
-	collection, err := service.DeleteCollection(ctx, r.ClientClerkHandler, id)
+func (r *mutationResolver) DeleteCollection(ctx context.Context, id string, dryRun *bool) (*model.Collection, error) {
+	collection, err := service.DeleteCollection(ctx, r.ClientClerkHandler, id, dryRun != nil && *dryRun)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not DeleteCollection: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not DeleteCollection: %w", err), ctx, http.StatusInternalServerError)
+	}
+	if dryRun == nil || !*dryRun {
+		r.record(ctx, policy.Delete, policy.KindCollection, id, collection, nil)
 	}
 	return collection, nil
 }
 
 // CreateStorageLocation is the resolver for the createStorageLocation field.
-func (r *mutationResolver) CreateStorageLocation(ctx context.Context, input *model.StorageLocationInput) (*model.StorageLocation, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
-	storageLocation, err := service.CreateStorageLocation(ctx, r.ClientClerkHandler, input)
+func (r *mutationResolver) CreateStorageLocation(ctx context.Context, input *model.StorageLocationInput, verify *bool) (*model.StorageLocation, error) {
+	storageLocation, err := service.CreateStorageLocation(ctx, r.ClientClerkHandler, r.ClientClerkStorageHandler, input, r.Validation, verify != nil && *verify)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not CreateStorageLocation: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not CreateStorageLocation: %w", err), ctx, http.StatusInternalServerError)
 	}
+	r.record(ctx, policy.Create, policy.KindStorageLocation, storageLocation.ID, nil, storageLocation)
 	return storageLocation, nil
 }
 
 // UpdateStorageLocation is the resolver for the updateStorageLocation field.
-func (r *mutationResolver) UpdateStorageLocation(ctx context.Context, input *model.StorageLocationInput) (*model.StorageLocation, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
+func (r *mutationResolver) UpdateStorageLocation(ctx context.Context, input *model.StorageLocationInput, verify *bool) (*model.StorageLocation, error) {
+	var before any
+	if input != nil {
+		before = r.snapshot(ctx, policy.KindStorageLocation, input.ID)
 	}
-	storageLocation, err := service.UpdateStorageLocation(ctx, r.ClientClerkHandler, input)
+	storageLocation, err := service.UpdateStorageLocation(ctx, r.ClientClerkHandler, r.ClientClerkStorageHandler, input, r.Validation, verify != nil && *verify)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not UpdateStorageLocation: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not UpdateStorageLocation: %w", err), ctx, http.StatusInternalServerError)
 	}
+	r.record(ctx, policy.Update, policy.KindStorageLocation, storageLocation.ID, before, storageLocation)
 	return storageLocation, nil
 }
 
-// DeleteStorageLocation is the resolver for the deleteStorageLocation field.
-func (r *mutationResolver) DeleteStorageLocation(ctx context.Context, id string) (*model.StorageLocation, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
+// TestStorageLocationConnection is the resolver for the testStorageLocationConnection field.
+func (r *mutationResolver) TestStorageLocationConnection(ctx context.Context, input *model.StorageLocationInput) (*model.StorageConnectionTest, error) {
+	result, err := service.TestStorageLocationConnection(ctx, r.ClientClerkHandler, r.ClientClerkStorageHandler, input, r.Validation)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not TestStorageLocationConnection: %w", err), ctx, http.StatusInternalServerError)
 	}
-	storageLocation, err := service.DeleteStorageLocation(ctx, r.ClientClerkHandler, id)
+	return result, nil
+}
+
+// DeleteStorageLocation is the resolver for the deleteStorageLocation field.
+func (r *mutationResolver) DeleteStorageLocation(ctx context.Context, id string, dryRun *bool) (*model.StorageLocation, error) {
+	storageLocation, err := service.DeleteStorageLocation(ctx, r.ClientClerkHandler, r.PartitionStates, id, dryRun != nil && *dryRun)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not DeleteStorageLocation: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not DeleteStorageLocation: %w", err), ctx, http.StatusInternalServerError)
+	}
+	if dryRun == nil || !*dryRun {
+		r.record(ctx, policy.Delete, policy.KindStorageLocation, id, storageLocation, nil)
 	}
 	return storageLocation, nil
 }
 
 // CreateStoragePartition is the resolver for the createStoragePartition field.
 func (r *mutationResolver) CreateStoragePartition(ctx context.Context, input *model.StoragePartitionInput) (*model.StoragePartition, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	storagePartition, err := service.CreateStoragePartition(ctx, r.ClientClerkHandler, r.ClientClerkStorageHandler, input)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not CreateStoragePartition: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not CreateStoragePartition: %w", err), ctx, http.StatusInternalServerError)
 	}
+	r.record(ctx, policy.Create, policy.KindStoragePartition, storagePartition.ID, nil, storagePartition)
 	return storagePartition, nil
 }
 
 // UpdateStoragePartition is the resolver for the updateStoragePartition field.
 func (r *mutationResolver) UpdateStoragePartition(ctx context.Context, input *model.StoragePartitionInput) (*model.StoragePartition, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
+	var before any
+	if input != nil {
+		before = r.snapshot(ctx, policy.KindStoragePartition, input.ID)
 	}
-	storagePartition, err := service.UpdateStoragePartition(ctx, r.ClientClerkHandler, input)
+	storagePartition, err := service.UpdateStoragePartition(ctx, r.ClientClerkHandler, r.PartitionStates, input)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not UpdateStoragePartition: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not UpdateStoragePartition: %w", err), ctx, http.StatusInternalServerError)
 	}
+	r.record(ctx, policy.Update, policy.KindStoragePartition, storagePartition.ID, before, storagePartition)
 	return storagePartition, nil
 }
 
 // DeleteStoragePartition is the resolver for the deleteStoragePartition field.
-func (r *mutationResolver) DeleteStoragePartition(ctx context.Context, id string) (*model.StoragePartition, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
+func (r *mutationResolver) DeleteStoragePartition(ctx context.Context, id string, dryRun *bool) (*model.StoragePartition, error) {
+	storagePartition, err := service.DeleteStoragePartition(ctx, r.ClientClerkHandler, r.PartitionStates, id, dryRun != nil && *dryRun)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not DeleteStoragePartition: %w", err), ctx, http.StatusInternalServerError)
+	}
+	if dryRun == nil || !*dryRun {
+		r.record(ctx, policy.Delete, policy.KindStoragePartition, id, storagePartition, nil)
 	}
-	storagePartition, err := service.DeleteStoragePartition(ctx, r.ClientClerkHandler, id)
+	return storagePartition, nil
+}
+
+// SetStoragePartitionState is the resolver for the setStoragePartitionState field.
+func (r *mutationResolver) SetStoragePartitionState(ctx context.Context, id string, state model.StoragePartitionState, reason *string) (*model.StoragePartition, error) {
+	var reasonText string
+	if reason != nil {
+		reasonText = *reason
+	}
+	before := r.snapshot(ctx, policy.KindStoragePartition, id)
+	storagePartition, err := service.SetStoragePartitionState(ctx, r.ClientClerkHandler, r.PartitionStates, id, state, reasonText, actorOf(ctx).Name)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not DeleteStoragePartition: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not SetStoragePartitionState: %w", err), ctx, http.StatusInternalServerError)
 	}
+	r.record(ctx, policy.Update, policy.KindStoragePartition, id, before, storagePartition)
 	return storagePartition, nil
 }
 
@@ -184,7 +270,7 @@ func (r *mutationResolver) DeleteStoragePartition(ctx context.Context, id string
 func (r *objectResolver) ObjectInstances(ctx context.Context, obj *model.Object, options *model.ObjectInstanceListOptions) (*model.ObjectInstanceList, error) {
 	objectInstances, err := service.GetObjectInstancesForObject(ctx, r.ClientClerkHandler, obj, options)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetObjectInstancesForObject: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectInstancesForObject: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return objectInstances, nil
 }
@@ -193,7 +279,7 @@ func (r *objectResolver) ObjectInstances(ctx context.Context, obj *model.Object,
 func (r *objectResolver) Files(ctx context.Context, obj *model.Object, options *model.FileListOptions) (*model.FileList, error) {
 	files, err := service.GetFilesForObject(ctx, r.ClientClerkHandler, obj, options)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetFilesForObject: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetFilesForObject: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return files, nil
 }
@@ -202,7 +288,7 @@ func (r *objectResolver) Files(ctx context.Context, obj *model.Object, options *
 func (r *objectInstanceResolver) ObjectInstanceChecks(ctx context.Context, obj *model.ObjectInstance, options *model.ObjectInstanceCheckListOptions) (*model.ObjectInstanceCheckList, error) {
 	objectInstanceChecks, err := service.GetObjectInstanceChecksForObjectInstance(ctx, r.ClientClerkHandler, obj, options)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetObjectInstanceChecksForObjectInstance: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectInstanceChecksForObjectInstance: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return objectInstanceChecks, nil
 }
@@ -245,243 +331,416 @@ func (r *queryResolver) User(ctx context.Context) (*model.User, error) {
 
 // Tenants is the resolver for the tenants field.
 func (r *queryResolver) Tenants(ctx context.Context, options *model.TenantListOptions) (*model.TenantList, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	tenants, err := service.GetTenants(ctx, r.ClientClerkHandler, options, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not FindAllTenants: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not FindAllTenants: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return tenants, nil
+}
+
+// TenantsConnection is the resolver for the tenantsConnection field.
+func (r *queryResolver) TenantsConnection(ctx context.Context, options *model.TenantListOptions, first *int, after *string, last *int, before *string) (*model.TenantConnection, error) {
+	tenants, err := service.GetTenantsConnection(ctx, r.ClientClerkHandler, options, first, after, last, before, r.AllowedTenants)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetTenantsConnection: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return tenants, nil
 }
 
 // Tenant is the resolver for the tenant field.
 func (r *queryResolver) Tenant(ctx context.Context, id string) (*model.Tenant, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	tenant, err := service.GetTenantById(ctx, r.ClientClerkHandler, id, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetTenantById: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetTenantById: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return tenant, nil
 }
 
 // Collections is the resolver for the collections field.
 func (r *queryResolver) Collections(ctx context.Context, options *model.CollectionListOptions) (*model.CollectionList, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	collections, err := service.GetCollectionsForTenantId(ctx, r.ClientClerkHandler, options, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetCollectionsForTenantId: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetCollectionsForTenantId: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return collections, nil
+}
+
+// CollectionsConnection is the resolver for the collectionsConnection field.
+func (r *queryResolver) CollectionsConnection(ctx context.Context, options *model.CollectionListOptions, first *int, after *string, last *int, before *string) (*model.CollectionConnection, error) {
+	collections, err := service.GetCollectionsConnection(ctx, r.ClientClerkHandler, options, first, after, last, before, r.AllowedTenants)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetCollectionsConnection: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return collections, nil
 }
 
 // Collection is the resolver for the collection field.
 func (r *queryResolver) Collection(ctx context.Context, id string) (*model.Collection, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	collection, err := service.GetCollectionById(ctx, r.ClientClerkHandler, id)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetCollectionById: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetCollectionById: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return collection, nil
 }
 
 // Objects is the resolver for the objects field.
 func (r *queryResolver) Objects(ctx context.Context, options *model.ObjectListOptions) (*model.ObjectList, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	objects, err := service.GetObjectsForCollectionId(ctx, r.ClientClerkHandler, options, r.AllowedTenants, r.Logger)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetObjectsForCollectionId: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectsForCollectionId: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return objects, nil
+}
+
+// ObjectsConnection is the resolver for the objectsConnection field.
+func (r *queryResolver) ObjectsConnection(ctx context.Context, options *model.ObjectListOptions, first *int, after *string, last *int, before *string) (*model.ObjectConnection, error) {
+	objects, err := service.GetObjectsConnection(ctx, r.ClientClerkHandler, options, first, after, last, before, r.AllowedTenants, r.Logger)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectsConnection: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return objects, nil
 }
 
 // Object is the resolver for the object field.
 func (r *queryResolver) Object(ctx context.Context, id string) (*model.Object, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	object, err := service.GetObjectById(ctx, r.ClientClerkHandler, id)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetObjectById: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectById: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return object, nil
 }
 
 // ObjectInstances is the resolver for the objectInstances field.
 func (r *queryResolver) ObjectInstances(ctx context.Context, options *model.ObjectInstanceListOptions) (*model.ObjectInstanceList, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	objectInstances, err := service.GetObjectInstancesForObjectId(ctx, r.ClientClerkHandler, options, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetObjectInstancesForObjectId: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectInstancesForObjectId: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return objectInstances, nil
+}
+
+// ObjectInstancesConnection is the resolver for the objectInstancesConnection field.
+func (r *queryResolver) ObjectInstancesConnection(ctx context.Context, options *model.ObjectInstanceListOptions, first *int, after *string, last *int, before *string) (*model.ObjectInstanceConnection, error) {
+	objectInstances, err := service.GetObjectInstancesConnection(ctx, r.ClientClerkHandler, options, first, after, last, before, r.AllowedTenants)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectInstancesConnection: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return objectInstances, nil
 }
 
 // ObjectInstance is the resolver for the objectInstance field.
 func (r *queryResolver) ObjectInstance(ctx context.Context, id string) (*model.ObjectInstance, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	objectInstance, err := service.GetObjectInstanceById(ctx, r.ClientClerkHandler, id)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetObjectInstanceById: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectInstanceById: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return objectInstance, nil
 }
 
 // ObjectInstanceChecks is the resolver for the objectInstanceChecks field.
 func (r *queryResolver) ObjectInstanceChecks(ctx context.Context, options *model.ObjectInstanceCheckListOptions) (*model.ObjectInstanceCheckList, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	objectInstanceChecks, err := service.GetObjectInstanceChecksForObjectInstanceId(ctx, r.ClientClerkHandler, options, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetObjectInstanceChecksForObjectInstanceId: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectInstanceChecksForObjectInstanceId: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return objectInstanceChecks, nil
+}
+
+// ObjectInstanceChecksConnection is the resolver for the objectInstanceChecksConnection field.
+func (r *queryResolver) ObjectInstanceChecksConnection(ctx context.Context, options *model.ObjectInstanceCheckListOptions, first *int, after *string, last *int, before *string) (*model.ObjectInstanceCheckConnection, error) {
+	objectInstanceChecks, err := service.GetObjectInstanceChecksConnection(ctx, r.ClientClerkHandler, options, first, after, last, before, r.AllowedTenants)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectInstanceChecksConnection: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return objectInstanceChecks, nil
 }
 
 // ObjectInstanceCheck is the resolver for the objectInstanceCheck field.
 func (r *queryResolver) ObjectInstanceCheck(ctx context.Context, id string) (*model.ObjectInstanceCheck, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	objectInstanceCheck, err := service.GetObjectInstanceCheckById(ctx, r.ClientClerkHandler, id)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetObjectInstanceCheckById: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectInstanceCheckById: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return objectInstanceCheck, nil
 }
 
 // Files is the resolver for the files field.
 func (r *queryResolver) Files(ctx context.Context, options *model.FileListOptions) (*model.FileList, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	files, err := service.GetFilesForObjectId(ctx, r.ClientClerkHandler, options, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetFilesForObjectId: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetFilesForObjectId: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return files, nil
+}
+
+// FilesConnection is the resolver for the filesConnection field.
+func (r *queryResolver) FilesConnection(ctx context.Context, options *model.FileListOptions, first *int, after *string, last *int, before *string) (*model.FileConnection, error) {
+	files, err := service.GetFilesConnection(ctx, r.ClientClerkHandler, options, first, after, last, before, r.AllowedTenants)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetFilesConnection: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return files, nil
 }
 
 // File is the resolver for the file field.
 func (r *queryResolver) File(ctx context.Context, id string) (*model.File, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	file, err := service.GetFileById(ctx, r.ClientClerkHandler, id)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetFileById: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetFileById: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return file, nil
 }
 
 // StorageLocations is the resolver for the storageLocations field.
 func (r *queryResolver) StorageLocations(ctx context.Context, options *model.StorageLocationListOptions) (*model.StorageLocationList, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	storageLocations, err := service.GetStorageLocationsForTenantOrCollectionId(ctx, r.ClientClerkHandler, options, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetStorageLocationsForTenantId: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetStorageLocationsForTenantId: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return storageLocations, nil
 }
 
 // StorageLocation is the resolver for the storageLocation field.
 func (r *queryResolver) StorageLocation(ctx context.Context, id string) (*model.StorageLocation, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	storageLocation, err := service.GetStorageLocationById(ctx, r.ClientClerkHandler, id)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetStorageLocationById: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetStorageLocationById: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return storageLocation, nil
 }
 
 // StoragePartitions is the resolver for the storagePartitions field.
 func (r *queryResolver) StoragePartitions(ctx context.Context, options *model.StoragePartitionListOptions) (*model.StoragePartitionList, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	storagePartitions, err := service.GetStoragePartitionsForLocationId(ctx, r.ClientClerkHandler, options, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetStoragePartitionsForLocationId: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetStoragePartitionsForLocationId: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return storagePartitions, nil
 }
 
 // StoragePartition is the resolver for the storagePartition field.
 func (r *queryResolver) StoragePartition(ctx context.Context, id string) (*model.StoragePartition, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	storagePartition, err := service.GetStoragePartitionById(ctx, r.ClientClerkHandler, id)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetStoragePartitionById: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetStoragePartitionById: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return storagePartition, nil
 }
 
 // MimeTypes is the resolver for the mimeTypes field.
 func (r *queryResolver) MimeTypes(ctx context.Context, options *model.MimeTypeListOptions) (*model.MimeTypeList, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	mimeTypes, err := service.GetMimeTypesForCollectionId(ctx, r.ClientClerkHandler, options, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetMimeTypesForCollectionId: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetMimeTypesForCollectionId: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return mimeTypes, nil
 }
 
 // PronomIds is the resolver for the pronomIds field.
 func (r *queryResolver) PronomIds(ctx context.Context, options *model.PronomIDListOptions) (*model.PronomIDList, error) {
-	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
-		return nil, middleware.GraphqlErrorWrapper(errM, ctx, http.StatusUnauthorized)
-	}
 	pronoms, err := service.GetPronomsForCollectionId(ctx, r.ClientClerkHandler, options, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetPronomsForCollectionId: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetPronomsForCollectionId: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return pronoms, nil
 }
 
+// AuditEvents is the resolver for the auditEvents field.
+func (r *queryResolver) AuditEvents(ctx context.Context, options *model.AuditEventListOptions) (*model.AuditEventList, error) {
+	auditEvents, err := service.GetAuditEvents(ctx, r.Audit, options)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetAuditEvents: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return auditEvents, nil
+}
+
+// StorageForecasts is the resolver for the storageForecasts field.
+func (r *queryResolver) StorageForecasts(ctx context.Context, days int, tenantID *string) ([]*model.StorageLocation, error) {
+	storageLocations, err := service.StorageForecasts(ctx, r.ClientClerkHandler, r.CapacityHistory, r.Forecast, days, tenantID)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not StorageForecasts: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return storageLocations, nil
+}
+
+// CostReport is the resolver for the costReport field.
+func (r *queryResolver) CostReport(ctx context.Context, tenantID string, period string) (*model.CostReport, error) {
+	costReport, err := service.CostReport(ctx, r.ClientClerkHandler, r.Billing, tenantID, period)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not CostReport: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return costReport, nil
+}
+
+// UnderReplicatedObjects is the resolver for the underReplicatedObjects field.
+func (r *queryResolver) UnderReplicatedObjects(ctx context.Context, tenantID *string, collectionID *string, skip *int, take *int) (*model.UnderReplicatedObjectList, error) {
+	underReplicatedObjects, err := service.GetUnderReplicatedObjects(ctx, r.ClientClerkHandler, r.ReplicationGaps, tenantID, collectionID, skip, take)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetUnderReplicatedObjects: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return underReplicatedObjects, nil
+}
+
+// IntegrityReport is the resolver for the integrityReport field.
+func (r *queryResolver) IntegrityReport(ctx context.Context, tenantID *string, collectionID *string, storageLocationID *string, from string, to string, bucket *model.IntegrityReportBucket) (*model.IntegrityReport, error) {
+	args := service.IntegrityReportArguments{TenantID: tenantID, CollectionID: collectionID, StorageLocationID: storageLocationID, From: from, To: to}
+	if bucket != nil {
+		args.Bucket = *bucket
+	}
+	report, err := service.IntegrityReport(ctx, r.ClientClerkHandler, args)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not IntegrityReport: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return report, nil
+}
+
+// OverdueObjectInstances is the resolver for the overdueObjectInstances field.
+func (r *queryResolver) OverdueObjectInstances(ctx context.Context, tenantID *string, collectionID *string, storageLocationID *string, skip *int, take *int) (*model.OverdueObjectInstanceList, error) {
+	overdueObjectInstances, err := service.GetOverdueObjectInstances(r.Fixity, tenantID, collectionID, storageLocationID, skip, take)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetOverdueObjectInstances: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return overdueObjectInstances, nil
+}
+
 // StoragePartitions is the resolver for the storagePartitions field.
 func (r *storageLocationResolver) StoragePartitions(ctx context.Context, obj *model.StorageLocation, options *model.StoragePartitionListOptions) (*model.StoragePartitionList, error) {
 	storagePartitions, err := service.GetStoragePartitionsForLocation(ctx, r.ClientClerkHandler, obj, options)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetStoragePartitionsForLocation: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetStoragePartitionsForLocation: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return storagePartitions, nil
 }
 
+// CapacityForecast is the resolver for the capacityForecast field.
+func (r *storageLocationResolver) CapacityForecast(ctx context.Context, obj *model.StorageLocation) (*model.CapacityForecast, error) {
+	return service.GetCapacityForecast(ctx, r.CapacityHistory, policy.KindStorageLocation, obj.ID, int64(obj.TotalFilesSize), int64(obj.TotalExistingVolume), r.Forecast.Window), nil
+}
+
+// OverdueObjectInstances is the resolver for the overdueObjectInstances field.
+func (r *storageLocationResolver) OverdueObjectInstances(ctx context.Context, obj *model.StorageLocation) (int, error) {
+	overdue, err := service.CountOverdueObjectInstances(r.Fixity, nil, &obj.ID)
+	if err != nil {
+		return 0, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not CountOverdueObjectInstances: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return overdue, nil
+}
+
 // ObjectInstances is the resolver for the objectInstances field.
 func (r *storagePartitionResolver) ObjectInstances(ctx context.Context, obj *model.StoragePartition, options *model.ObjectInstanceListOptions) (*model.ObjectInstanceList, error) {
 	objectInstances, err := service.GetObjectInstancesForStoragePartition(ctx, r.ClientClerkHandler, obj, options)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetObjectInstancesForStoragePartition: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetObjectInstancesForStoragePartition: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return objectInstances, nil
 }
 
+// State is the resolver for the state field.
+func (r *storagePartitionResolver) State(ctx context.Context, obj *model.StoragePartition) (model.StoragePartitionState, error) {
+	return model.StoragePartitionState(r.PartitionStates.Get(ctx, obj.ID).State), nil
+}
+
+// Lifecycle is the resolver for the lifecycle field.
+func (r *storagePartitionResolver) Lifecycle(ctx context.Context, obj *model.StoragePartition) (*model.StoragePartitionLifecycle, error) {
+	return service.GetStoragePartitionLifecycle(ctx, r.PartitionStates, obj), nil
+}
+
+// CapacityForecast is the resolver for the capacityForecast field.
+func (r *storagePartitionResolver) CapacityForecast(ctx context.Context, obj *model.StoragePartition) (*model.CapacityForecast, error) {
+	return service.GetCapacityForecast(ctx, r.CapacityHistory, policy.KindStoragePartition, obj.ID, int64(obj.CurrentSize), int64(obj.MaxSize), r.Forecast.Window), nil
+}
+
+// ArchivingStatus is the resolver for the archivingStatus field.
+func (r *subscriptionResolver) ArchivingStatus(ctx context.Context, jobID string) (<-chan *model.ArchivingStatus, error) {
+	eventsCh, err := r.Events.Subscribe(ctx, events.ArchivingStatusTopic(jobID))
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not subscribe to archiving status: %w", err), ctx, http.StatusInternalServerError)
+	}
+	status, err := service.GetArchivingStatus(ctx, r.ClientClerkHandler, jobID)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(err, ctx, http.StatusInternalServerError)
+	}
+	r.Watcher.WatchArchivingStatus(ctx, jobID)
+	statuses := make(chan *model.ArchivingStatus, 1)
+	statuses <- status
+	go func() {
+		defer close(statuses)
+		last := *status
+		for event := range eventsCh {
+			if status, ok := event.Payload.(*model.ArchivingStatus); ok && *status != last {
+				last = *status
+				select {
+				case statuses <- status:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return statuses, nil
+}
+
+// ObjectInstanceCheckErrors is the resolver for the objectInstanceCheckErrors field.
+func (r *subscriptionResolver) ObjectInstanceCheckErrors(ctx context.Context, tenantID *string, collectionID *string) (<-chan *model.CheckErrorEvent, error) {
+	collectionIds, err := service.GetCollectionIdsToWatch(ctx, r.ClientClerkHandler, tenantID, collectionID)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(err, ctx, http.StatusInternalServerError)
+	}
+	topics := make([]string, 0, len(collectionIds))
+	for _, id := range collectionIds {
+		topics = append(topics, events.CheckErrorsTopic(id))
+	}
+	eventsCh, err := r.Events.Subscribe(ctx, topics...)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not subscribe to check errors: %w", err), ctx, http.StatusInternalServerError)
+	}
+	for _, id := range collectionIds {
+		r.Watcher.WatchCheckErrors(ctx, id)
+	}
+	checkErrors := make(chan *model.CheckErrorEvent)
+	go func() {
+		defer close(checkErrors)
+		for event := range eventsCh {
+			if checkError, ok := event.Payload.(*model.CheckErrorEvent); ok {
+				select {
+				case checkErrors <- checkError:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return checkErrors, nil
+}
+
+// StoragePartitionFillLevel is the resolver for the storagePartitionFillLevel field.
+func (r *subscriptionResolver) StoragePartitionFillLevel(ctx context.Context, storageLocationID string) (<-chan *model.StoragePartition, error) {
+	eventsCh, err := r.Events.Subscribe(ctx, events.FillLevelTopic(storageLocationID))
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not subscribe to fill levels: %w", err), ctx, http.StatusInternalServerError)
+	}
+	r.Watcher.WatchFillLevels(ctx, storageLocationID)
+	storagePartitions := make(chan *model.StoragePartition)
+	go func() {
+		defer close(storagePartitions)
+		for event := range eventsCh {
+			if storagePartition, ok := event.Payload.(*model.StoragePartition); ok {
+				select {
+				case storagePartitions <- storagePartition:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return storagePartitions, nil
+}
+
 // Collections is the resolver for the collections field.
 func (r *tenantResolver) Collections(ctx context.Context, obj *model.Tenant, options *model.CollectionListOptions) (*model.CollectionList, error) {
 	collections, err := service.GetCollectionsForTenant(ctx, r.ClientClerkHandler, obj, options)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetCollectionsForTenant: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetCollectionsForTenant: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return collections, nil
 }
@@ -490,11 +749,20 @@ func (r *tenantResolver) Collections(ctx context.Context, obj *model.Tenant, opt
 func (r *tenantResolver) StorageLocations(ctx context.Context, obj *model.Tenant, options *model.StorageLocationListOptions) (*model.StorageLocationList, error) {
 	storageLocations, err := service.GetStorageLocationsForTenant(ctx, r.ClientClerkHandler, obj, options)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not GetStorageLocationsForTenant: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetStorageLocationsForTenant: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return storageLocations, nil
 }
 
+// ReplicationGap is the resolver for the replicationGap field.
+func (r *tenantResolver) ReplicationGap(ctx context.Context, obj *model.Tenant) (*model.ReplicationGap, error) {
+	replicationGap, err := service.GetReplicationGap(r.ReplicationGaps, &obj.ID, nil)
+	if err != nil {
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not GetReplicationGap: %w", err), ctx, http.StatusInternalServerError)
+	}
+	return replicationGap, nil
+}
+
 // Tenants is the resolver for the tenants field.
 func (r *userResolver) Tenants(ctx context.Context, obj *model.User) ([]*model.Tenant, error) {
 	if errM := middleware.GraphqlVerifyToken(ctx); errM != nil {
@@ -502,7 +770,7 @@ func (r *userResolver) Tenants(ctx context.Context, obj *model.User) ([]*model.T
 	}
 	tenants, err := service.GetTenants(ctx, r.ClientClerkHandler, nil, r.AllowedTenants)
 	if err != nil {
-		return nil, middleware.GraphqlErrorWrapper(errors.New("Could not FindAllTenants: "+err.Error()), ctx, http.StatusInternalServerError)
+		return nil, middleware.GraphqlErrorWrapper(fmt.Errorf("Could not FindAllTenants: %w", err), ctx, http.StatusInternalServerError)
 	}
 	return tenants.Items, err
 }
@@ -528,6 +796,9 @@ func (r *Resolver) StorageLocation() StorageLocationResolver { return &storageLo
 // StoragePartition returns StoragePartitionResolver implementation.
 func (r *Resolver) StoragePartition() StoragePartitionResolver { return &storagePartitionResolver{r} }
 
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
 // Tenant returns TenantResolver implementation.
 func (r *Resolver) Tenant() TenantResolver { return &tenantResolver{r} }
 
@@ -541,5 +812,6 @@ type objectInstanceResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type storageLocationResolver struct{ *Resolver }
 type storagePartitionResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
 type tenantResolver struct{ *Resolver }
 type userResolver struct{ *Resolver }
@@ -0,0 +1,65 @@
+package lifecycle
+
+import (
+	"slices"
+	"time"
+
+	"emperror.dev/errors"
+)
+
+// State of a storage partition. Partitions without a record are active.
+type State string
+
+const (
+	// Active partitions take new object instances
+	Active State = "ACTIVE"
+	// Sealed partitions are read-only, their limits are pinned to the current fill
+	Sealed State = "SEALED"
+	// Draining partitions are read-only and their object instances are being moved away
+	Draining State = "DRAINING"
+	// Retired partitions are empty and may be deleted
+	Retired State = "RETIRED"
+)
+
+var transitions = map[State][]State{
+	Active:   {Sealed, Retired},
+	Sealed:   {Active, Draining, Retired},
+	Draining: {Sealed, Retired},
+	Retired:  {},
+}
+
+// CheckTransition reports whether a partition may move from one state to the other.
+// Whether it is empty enough to be retired is up to the caller.
+func CheckTransition(from State, to State) error {
+	allowed, ok := transitions[from]
+	if !ok {
+		return errors.Errorf("unknown state %s", from)
+	}
+	if _, ok := transitions[to]; !ok {
+		return errors.Errorf("unknown state %s", to)
+	}
+	if !slices.Contains(allowed, to) {
+		return errors.Errorf("Cannot move storage partition from %s to %s", from, to)
+	}
+	return nil
+}
+
+// Writable reports whether new object instances may be placed on a partition in the state
+func (s State) Writable() bool {
+	return s == Active
+}
+
+// Record is the lifecycle of a storage partition
+type Record struct {
+	PartitionID string    `json:"partitionId"`
+	State       State     `json:"state"`
+	Changed     time.Time `json:"changed"`
+	ChangedBy   string    `json:"changedBy"`
+	Reason      string    `json:"reason,omitempty"`
+	// Limits of the partition when it stopped being active, restored when it is activated again
+	MaxSize    int64 `json:"maxSize"`
+	MaxObjects int64 `json:"maxObjects"`
+	// Fill of the partition when draining started, to report the progress
+	DrainStartSize    int64 `json:"drainStartSize,omitempty"`
+	DrainStartObjects int64 `json:"drainStartObjects,omitempty"`
+}
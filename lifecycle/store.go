@@ -0,0 +1,150 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/atomicfile"
+)
+
+// Store keeps the lifecycle records of the partitions. With a file the records are written as one json
+// document on every change and read again once another clerk sharing the file changed it. Every change is merged
+// into the file under its lock, so clerks changing different partitions at once keep each others records.
+// Without a file they only live as long as the process, so state changes are refused.
+type Store struct {
+	lock    sync.Mutex
+	path    string
+	modTime time.Time
+	records map[string]Record
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, records: map[string]Record{}}
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Persistent reports whether the records are kept in a file
+func (s *Store) Persistent() bool {
+	return s.path != ""
+}
+
+// refresh reads the file again if it was changed since it was last read or written
+func (s *Store) refresh() error {
+	if s.path == "" {
+		return nil
+	}
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot read partition state file %s", s.path)
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read partition state file %s", s.path)
+	}
+	records, err := parseRecords(data)
+	if err != nil {
+		return errors.Wrapf(err, "cannot parse partition state file %s", s.path)
+	}
+	s.records = records
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// Get returns the record of the partition, an active one if it has none
+// A file which cannot be read again keeps the records last read.
+func (s *Store) Get(ctx context.Context, partitionId string) Record {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_ = s.refresh()
+	record, ok := s.records[partitionId]
+	if !ok {
+		return Record{PartitionID: partitionId, State: Active}
+	}
+	return record
+}
+
+// Known reports whether the state of the partition was ever changed
+func (s *Store) Known(ctx context.Context, partitionId string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_ = s.refresh()
+	_, ok := s.records[partitionId]
+	return ok
+}
+
+func (s *Store) Put(ctx context.Context, record Record) error {
+	return s.update(func(records map[string]Record) bool {
+		records[record.PartitionID] = record
+		return true
+	})
+}
+
+// Delete forgets the partition once it is deleted
+func (s *Store) Delete(ctx context.Context, partitionId string) error {
+	return s.update(func(records map[string]Record) bool {
+		if _, ok := records[partitionId]; !ok {
+			return false
+		}
+		delete(records, partitionId)
+		return true
+	})
+}
+
+// update applies change to the records as currently in the file, read and written under the lock of the file.
+// change reports whether it changed anything.
+func (s *Store) update(change func(records map[string]Record) bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.path == "" {
+		change(s.records)
+		return nil
+	}
+	var records map[string]Record
+	err := atomicfile.Update(s.path, func(data []byte) ([]byte, error) {
+		var err error
+		records, err = parseRecords(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse partition state file %s", s.path)
+		}
+		if !change(records) {
+			return nil, nil
+		}
+		data, err = json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot marshal partition states")
+		}
+		return data, nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot write partition state file %s", s.path)
+	}
+	s.records = records
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+func parseRecords(data []byte) (map[string]Record, error) {
+	records := map[string]Record{}
+	if len(data) == 0 {
+		return records, nil
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
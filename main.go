@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"embed"
@@ -20,13 +21,20 @@ import (
 	"emperror.dev/errors"
 	configutil "github.com/je4/utils/v2/pkg/config"
 	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
+	"github.com/ocfl-archive/dlza-manager-clerk/auth"
 	"github.com/ocfl-archive/dlza-manager-clerk/certs"
 	"github.com/ocfl-archive/dlza-manager-clerk/config"
 	"github.com/ocfl-archive/dlza-manager-clerk/controller"
 	"github.com/ocfl-archive/dlza-manager-clerk/data/web"
+	"github.com/ocfl-archive/dlza-manager-clerk/events"
+	"github.com/ocfl-archive/dlza-manager-clerk/forecast"
+	"github.com/ocfl-archive/dlza-manager-clerk/lifecycle"
 	"github.com/ocfl-archive/dlza-manager-clerk/models"
 	"github.com/ocfl-archive/dlza-manager-clerk/router"
 	graphqlServer "github.com/ocfl-archive/dlza-manager-clerk/server"
+	"github.com/ocfl-archive/dlza-manager-clerk/service"
+	"github.com/ocfl-archive/dlza-manager-clerk/statusowner"
 	handlerClientProto "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
 	storageHandlerClientProto "github.com/ocfl-archive/dlza-manager-storage-handler/storagehandlerproto"
 	ublogger "gitlab.switch.ch/ub-unibas/go-ublogger/v2"
@@ -36,6 +44,9 @@ import (
 
 var configFile = flag.String("config", "", "config file in toml format")
 
+// auditMemoryEvents is the number of audit events kept when no audit file is configured
+const auditMemoryEvents = 10000
+
 //go:embed all:dlza-frontend/build
 var uiFS embed.FS
 
@@ -141,13 +152,67 @@ func main() {
 		logger.Panic().Msgf("cannot create clientClerkStorageHandler grpc client: %v", err)
 	}
 
-	tenantController := controller.NewTenantController(clientClerkHandler)
-	storageLocationController := controller.NewStorageLocationController(clientClerkHandler)
-	collectionController := controller.NewCollectionController(clientClerkHandler)
-	statusController := controller.NewStatusController(clientClerkHandler)
-	objectInstanceController := controller.NewObjectInstanceController(clientClerkHandler)
-	objectController := controller.NewObjectController(clientClerkHandler)
-	routes := router.NewRouter(conf.Jwt, tenantController, storageLocationController, collectionController, statusController, objectInstanceController, objectController)
+	auditSinks := []audit.Sink{}
+	if conf.Audit.File != "" {
+		auditFile, err := audit.NewFileSink(conf.Audit.File)
+		if err != nil {
+			logger.Panic().Msgf("cannot create audit sink: %v", err)
+		}
+		defer auditFile.Close()
+		auditSinks = append(auditSinks, auditFile)
+	} else {
+		logger.Warn().Msgf("no audit file configured, auditEvents only returns the last %d events since the start", auditMemoryEvents)
+		auditSinks = append(auditSinks, audit.NewMemorySink(auditMemoryEvents))
+	}
+	if conf.Audit.Logstash {
+		auditSinks = append(auditSinks, audit.NewLoggerSink(logger))
+	}
+	auditLog := audit.NewLog(logger, auditSinks...)
+
+	if conf.Partitions.StateFile == "" {
+		logger.Warn().Msg("no partition state file configured, storage partition states cannot be changed")
+	}
+	partitionStates, err := lifecycle.NewStore(conf.Partitions.StateFile)
+	if err != nil {
+		logger.Panic().Msgf("cannot load partition states: %v", err)
+	}
+	if conf.Forecast.Retention <= 0 {
+		conf.Forecast.Retention = service.DefaultSampleRetention
+	}
+	if conf.Forecast.File == "" {
+		logger.Warn().Msg("no capacity history file configured, the forecasts start over on restart")
+	}
+	capacityHistory, err := forecast.NewHistory(conf.Forecast.File, conf.Forecast.Retention)
+	if err != nil {
+		logger.Panic().Msgf("cannot load capacity history: %v", err)
+	}
+
+	if conf.Status.OwnerFile == "" {
+		logger.Warn().Msg("no status owner file configured, ingest tokens lose access to their statuses on restart")
+	}
+	statusOwners, err := statusowner.NewStore(conf.Status.OwnerFile, conf.Status.Retention)
+	if err != nil {
+		logger.Panic().Msgf("cannot load status owners: %v", err)
+	}
+
+	if conf.JwtAuth.LegacyAccess {
+		logger.Warn().Msg("legacyaccess is deprecated: tokens without groups, tenant_list and scope act as admin, issue tokens with tenant permissions and disable it")
+	}
+	authorizer := controller.NewAuthorizer(clientClerkHandler, conf.JwtAuth.LegacyAccess, statusOwners)
+	tenantController := controller.NewTenantController(clientClerkHandler, authorizer, auditLog)
+	storageLocationController := controller.NewStorageLocationController(clientClerkHandler, authorizer, auditLog)
+	collectionController := controller.NewCollectionController(clientClerkHandler, authorizer, auditLog)
+	eventSource := events.NewInProcess(events.DefaultBuffer)
+	statusController := controller.NewStatusController(clientClerkHandler, authorizer, eventSource, auditLog)
+	objectInstanceController := controller.NewObjectInstanceController(clientClerkHandler, authorizer)
+	objectController := controller.NewObjectController(clientClerkHandler, authorizer, auditLog)
+	billingController := controller.NewBillingController(clientClerkHandler, authorizer, conf.Billing)
+	integrityController := controller.NewIntegrityController(clientClerkHandler, authorizer)
+	jwtVerifier, err := auth.NewVerifier(conf.JwtAuth, conf.Jwt, conf.GraphQLConfig.Keycloak)
+	if err != nil {
+		logger.Panic().Msgf("cannot create jwt verifier: %v", err)
+	}
+	routes := router.NewRouter(jwtVerifier, tenantController, storageLocationController, collectionController, statusController, objectInstanceController, objectController, billingController, integrityController)
 
 	// find static fs
 	var staticFS fs.FS
@@ -212,13 +277,15 @@ func main() {
 	}
 	graphqlServer.UiFS = uiFS
 	graphqlServer.SchemaFS = schemaFS
+	fixity := service.NewFixityScanner(clientClerkHandler, conf.Fixity, logger)
+	replicationGaps := service.NewReplicationScanner(clientClerkHandler, conf.Replication.Interval, logger)
 	srv, err := graphqlServer.NewServer(conf.GraphQLConfig.Addr, conf.GraphQLConfig.ExtAddr, cert, addCA, staticFS, logger, models.Keycloak{
 		Addr:         conf.GraphQLConfig.Keycloak.Addr,
 		Realm:        conf.GraphQLConfig.Keycloak.Realm,
 		Callback:     conf.GraphQLConfig.Keycloak.Callback,
 		ClientId:     conf.GraphQLConfig.Keycloak.ClientId,
 		ClientSecret: conf.GraphQLConfig.Keycloak.ClientSecret,
-	}, clientClerkHandler, clientClerkStorageHandler, routes, conf.GraphQLConfig.Domain)
+	}, clientClerkHandler, clientClerkStorageHandler, routes, conf.GraphQLConfig.Domain, conf.Session, eventSource, auditLog, conf.Validation, partitionStates, capacityHistory, conf.Forecast, conf.Billing, fixity, replicationGaps)
 	if err != nil {
 		emperror.Panic(errors.Wrap(err, "cannot create server"))
 	}
@@ -228,6 +295,18 @@ func main() {
 	}
 	defer cancel()
 
+	samplerCtx, samplerCancel := context.WithCancel(context.Background())
+	defer samplerCancel()
+	service.NewCapacitySampler(clientClerkHandler, capacityHistory, conf.Forecast.Interval, logger).Start(samplerCtx)
+	replicationGaps.Start(samplerCtx)
+	fixity.Start(samplerCtx)
+
+	if conf.Provisioning.Enabled {
+		capacityCtx, capacityCancel := context.WithCancel(context.Background())
+		defer capacityCancel()
+		service.NewCapacityWatcher(clientClerkHandler, clientClerkStorageHandler, partitionStates, auditLog, conf.Provisioning, logger).Start(capacityCtx)
+	}
+
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL)
 	fmt.Println("press ctrl+c to stop server")
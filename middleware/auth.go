@@ -18,6 +18,7 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/ocfl-archive/dlza-manager-clerk/constants"
 	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/validation"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 	"golang.org/x/oauth2"
 )
@@ -40,7 +41,9 @@ func VerifyToken(ctx context.Context, keycloak models.Keycloak, verifier *oidc.I
 				c.Error(errors.Errorf("VerifyToken : RefreshToken not possible %d, err : %s", http.StatusUnauthorized, err))
 				urlPath := c.Request.URL.Path
 				session.Set("url_path", urlPath)
-				session.Save()
+				if err := session.Save(); err != nil {
+					c.Error(errors.Wrap(err, "VerifyToken : cannot save session"))
+				}
 				// c.Redirect(http.StatusFound, "/auth/login")
 				// c.Redirect(http.StatusFound, "/auth/login?url="+urlPath)
 				c.Abort()
@@ -86,7 +89,9 @@ func VerifyToken(ctx context.Context, keycloak models.Keycloak, verifier *oidc.I
 					c.Error(errors.Errorf("VerifyToken : RefreshToken not possible %d, err : %s", http.StatusUnauthorized, err))
 					urlPath := c.Request.URL.Path
 					session.Set("url_path", urlPath)
-					session.Save()
+					if err := session.Save(); err != nil {
+						c.Error(errors.Wrap(err, "VerifyToken : cannot save session"))
+					}
 					// c.Redirect(http.StatusFound, "/auth/login")
 					// c.Redirect(http.StatusFound, "/auth/login?url="+urlPath)
 					c.Abort()
@@ -111,7 +116,9 @@ func VerifyToken(ctx context.Context, keycloak models.Keycloak, verifier *oidc.I
 
 			urlPath := c.Request.URL.Path
 			session.Set("url_path", urlPath)
-			session.Save()
+			if err := session.Save(); err != nil {
+				c.Error(errors.Wrap(err, "VerifyToken : cannot save session"))
+			}
 			// c.Redirect(http.StatusFound, "/auth/login")
 			c.Abort()
 			return
@@ -135,7 +142,11 @@ func VerifyToken(ctx context.Context, keycloak models.Keycloak, verifier *oidc.I
 
 		session.Set("username", userClaim.PreferredUsername)
 		session.Set("userClaim", userClaim)
-		session.Save()
+		if err := session.Save(); err != nil {
+			c.Error(errors.Wrap(err, "VerifyToken : cannot save session"))
+			c.Abort()
+			return
+		}
 		c.Set("userClaim", userClaim)
 		c.Set(constants.KEYCLOAK_GROUPS_CTX, userClaim.Groups)
 		c.Set(constants.ADMIN_ROLE, keycloak.AdminRole)
@@ -198,16 +209,18 @@ func GetAuthCodeURL(c *gin.Context) (string, error) {
 	session.Set("state", state)
 	nonce := GenerateStateOauth()
 	session.Set("nonce", nonce)
-	session.Save()
-
-	var keycloak models.Keycloak
-	if c.Value("keycloak") != nil {
-		keycloak = c.Value("keycloak").(models.Keycloak)
-	} else {
-		return "", errors.New("could't retrieve keycloak informations")
+	if err := session.Save(); err != nil {
+		return "", errors.Wrap(err, "cannot save session")
 	}
 
-	oauth2Config := GetOauth2Config(keycloak)
+	oidcClient, err := OidcClientFromContext(c)
+	if err != nil {
+		return "", err
+	}
+	oauth2Config, err := oidcClient.Oauth2Config()
+	if err != nil {
+		return "", err
+	}
 	return oauth2Config.AuthCodeURL(state, oidc.Nonce(nonce)), nil
 }
 
@@ -223,28 +236,30 @@ func Callback(ctx context.Context, c *gin.Context, code string) error {
 	} else {
 		return errors.New("could't retrieve keycloak informations")
 	}
-
-	oauth2Config := GetOauth2Config(keycloak)
+	oidcClient, err := OidcClientFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	oauth2Config, err := oidcClient.Oauth2Config()
+	if err != nil {
+		return err
+	}
 	oauth2Token, err := oauth2Config.Exchange(ctx, code)
 	if err != nil {
-		fmt.Println("oauth2Config.Exchange", err)
-		fmt.Println(" code ", code)
-		fmt.Println("ctx ", ctx)
-		fmt.Println("gincontext ", c)
-		fmt.Println("oauth2Config.RedirectURL ", oauth2Config.RedirectURL)
-		fmt.Println("keycloak ", keycloak)
+		oidcClient.logger.Error().Msgf("cannot exchange authorization code of realm %s with redirect url %s: %v", keycloak.Realm, oauth2Config.RedirectURL, err)
 		return err
 	}
 	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
 	if !ok {
 		return errors.New("No id_token field in oauth2 token")
 	}
-	verifier := GetVerifier(keycloak)
+	verifier, err := oidcClient.Verifier()
+	if err != nil {
+		return err
+	}
 	idToken, err := verifier.Verify(ctx, rawIDToken)
 	if err != nil {
-		fmt.Println("verifier error", err)
-		fmt.Println(" keycloak.Callback ", keycloak.Callback)
-		fmt.Println("oauth2Config.RedirectURL ", oauth2Config.RedirectURL)
+		oidcClient.logger.Error().Msgf("cannot verify id token, callback %s, redirect url %s: %v", keycloak.Callback, oauth2Config.RedirectURL, err)
 		return err
 	}
 
@@ -282,36 +297,6 @@ func Callback(ctx context.Context, c *gin.Context, code string) error {
 	return nil
 }
 
-func GetOauth2Config(keycloak models.Keycloak) oauth2.Config {
-
-	// Keycloak configuration
-	provider := GetProvider(keycloak)
-
-	return oauth2.Config{
-		ClientID:     keycloak.ClientId,
-		ClientSecret: keycloak.ClientSecret,
-		RedirectURL:  keycloak.Callback + "auth/callback",
-		// Discovery returns the OAuth2 endpoints.
-		Endpoint: provider.Endpoint(),
-		// "openid" is a required scope for OpenID Connect flows.
-		Scopes: []string{oidc.ScopeOpenID, "profile", "email"},
-	}
-
-}
-
-func GetProvider(keycloak models.Keycloak) *oidc.Provider {
-
-	// Keycloak configuration
-	ctx := context.Background()
-	provider, err := oidc.NewProvider(ctx, keycloak.Addr+keycloak.Realm)
-	if err != nil {
-		panic(err)
-	}
-
-	return provider
-
-}
-
 func GetOidcConfig(keycloak models.Keycloak) *oidc.Config {
 
 	return &oidc.Config{
@@ -319,11 +304,6 @@ func GetOidcConfig(keycloak models.Keycloak) *oidc.Config {
 	}
 }
 
-func GetVerifier(keycloak models.Keycloak) *oidc.IDTokenVerifier {
-	provider := GetProvider(keycloak)
-	return provider.Verifier(GetOidcConfig(keycloak))
-}
-
 func ResetSession(c *gin.Context) error {
 	session := sessions.Default(c)
 	// session.Set("access_token", nil)
@@ -335,6 +315,9 @@ func ResetSession(c *gin.Context) error {
 }
 
 func GetUser(c *gin.Context) (*models.KeyCloakToken, error) {
+	if claims, ok, err := BearerClaims(c); ok || err != nil {
+		return claims, err
+	}
 	session := sessions.Default(c)
 	var userClaim models.KeyCloakToken
 
@@ -351,6 +334,9 @@ func GraphqlVerifyToken(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if _, ok, err := BearerClaims(c); ok || err != nil {
+		return err
+	}
 	var keycloak models.Keycloak
 	if ctx.Value("keycloak") != nil {
 		keycloak = ctx.Value("keycloak").(models.Keycloak)
@@ -358,8 +344,16 @@ func GraphqlVerifyToken(ctx context.Context) error {
 		return errors.New("could't retrieve keycloak informations")
 	}
 
+	oidcClient, err := OidcClientFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	oauth2Config, err := oidcClient.Oauth2Config()
+	if err != nil {
+		return err
+	}
+
 	session := sessions.Default(c)
-	oauth2Config := GetOauth2Config(keycloak)
 	if session.Get("access_token") == nil {
 		// refreshedToken, err := RefreshToken(c, ctx, oauth2Config)
 		// if err != nil {
@@ -399,12 +393,13 @@ func GraphqlVerifyToken(ctx context.Context) error {
 	if rawAccessToken == "" {
 		return errors.New("access_token not set in session")
 	}
-	verifier := GetVerifier(keycloak)
+	verifier, err := oidcClient.Verifier()
+	if err != nil {
+		return err
+	}
 	_, err = verifier.Verify(context.Background(), rawAccessToken)
 	if err != nil {
-		fmt.Println("GraphqlVerifyToken verifier error", err)
-		fmt.Println(" keycloak.Callback ", keycloak.Callback)
-		fmt.Println("oauth2Config.RedirectURL ", oauth2Config.RedirectURL)
+		oidcClient.logger.Warn().Msgf("GraphqlVerifyToken cannot verify access token, callback %s, redirect url %s: %v", keycloak.Callback, oauth2Config.RedirectURL, err)
 		return err
 	}
 
@@ -420,7 +415,9 @@ func GraphqlVerifyToken(ctx context.Context) error {
 	session.Set("userClaim", userClaim)
 	session.Set("keycloak_group", userClaim.Groups)
 	session.Set("tenant_list", userClaim.TenantList)
-	session.Save()
+	if err := session.Save(); err != nil {
+		return errors.Wrap(err, "cannot save session")
+	}
 	return nil
 }
 
@@ -432,6 +429,12 @@ func TenantGroups(ctx context.Context) ([]string, []models.Tenant, error) {
 	if err != nil {
 		return nil, nil, err
 	}
+	if claims, ok, err := BearerClaims(c); ok || err != nil {
+		if err != nil {
+			return nil, nil, err
+		}
+		return claims.Groups, claims.TenantList, nil
+	}
 	session := sessions.Default(c)
 	if session.Get("keycloak_group") != nil {
 		keyCloakGroup = session.Get("keycloak_group").([]string)
@@ -444,19 +447,77 @@ func TenantGroups(ctx context.Context) ([]string, []models.Tenant, error) {
 	return keyCloakGroup, tenantList, nil
 }
 
+// BearerClaims verifies the access token of the Authorization header, which headless clients send instead
+// of the session cookie. The claims are kept for the rest of the request. ok is false without such a header.
+func BearerClaims(c *gin.Context) (claims *models.KeyCloakToken, ok bool, err error) {
+	if value, exists := c.Get(bearerClaimsKey); exists {
+		return value.(*models.KeyCloakToken), true, nil
+	}
+	rawAccessToken, found := strings.CutPrefix(c.Request.Header.Get("Authorization"), "Bearer ")
+	if !found || strings.TrimSpace(rawAccessToken) == "" {
+		return nil, false, nil
+	}
+	oidcClient, err := OidcClientFromContext(c)
+	if err != nil {
+		return nil, true, err
+	}
+	verifier, err := oidcClient.Verifier()
+	if err != nil {
+		return nil, true, err
+	}
+	idToken, err := verifier.Verify(c.Request.Context(), strings.TrimSpace(rawAccessToken))
+	if err != nil {
+		return nil, true, errors.Wrap(err, "Access denied : invalid bearer token")
+	}
+	var userClaim models.KeyCloakToken
+	if err := idToken.Claims(&userClaim); err != nil {
+		return nil, true, errors.Wrap(err, "Access denied : cannot read bearer token claims")
+	}
+	c.Set(bearerClaimsKey, &userClaim)
+	return &userClaim, true, nil
+}
+
+const bearerClaimsKey = "bearer_claims"
+
 func GraphqlErrorWrapper(err error, ctx context.Context, httpStatus int) *gqlerror.Error {
 
-	if strings.Contains(err.Error(), "You are not allowed to retrieve datas") {
+	var invalid *validation.Errors
+	if errors.As(err, &invalid) {
+		httpStatus = http.StatusBadRequest
+	} else if strings.Contains(err.Error(), "You are not allowed to retrieve datas") {
+		httpStatus = http.StatusForbidden
+	} else if strings.Contains(err.Error(), "You are not allowed to proceed") {
 		httpStatus = http.StatusForbidden
+	} else if strings.Contains(err.Error(), "could not be found") {
+		httpStatus = http.StatusNotFound
 	} else if strings.Contains(err.Error(), "You could not retrieve more than 1000") {
 		httpStatus = http.StatusBadRequest
+	} else if strings.Contains(err.Error(), "Invalid pagination arguments") {
+		httpStatus = http.StatusBadRequest
+	} else if strings.Contains(err.Error(), "Invalid tenant input") {
+		httpStatus = http.StatusBadRequest
+	} else if strings.Contains(err.Error(), "still owns") || strings.Contains(err.Error(), "below their needed quality") ||
+		strings.Contains(err.Error(), "the last copy of") {
+		httpStatus = http.StatusConflict
+	} else if strings.Contains(err.Error(), "Cannot move storage partition") || strings.Contains(err.Error(), "still holds") ||
+		strings.Contains(err.Error(), "limits are kept") {
+		httpStatus = http.StatusConflict
+	} else if strings.Contains(err.Error(), "failed the connection test") {
+		httpStatus = http.StatusBadGateway
+	} else if strings.Contains(err.Error(), "not computed yet") {
+		httpStatus = http.StatusServiceUnavailable
+	}
+	extensions := map[string]interface{}{
+		"code": httpStatus,
+	}
+	if invalid != nil {
+		// the violated rules per input field, for forms to show them next to the fields
+		extensions["fields"] = invalid.Fields
 	}
 	return &gqlerror.Error{
-		Err:     err,
-		Path:    graphql.GetPath(ctx),
-		Message: err.Error(),
-		Extensions: map[string]interface{}{
-			"code": httpStatus,
-		},
+		Err:        err,
+		Path:       graphql.GetPath(ctx),
+		Message:    err.Error(),
+		Extensions: extensions,
 	}
 }
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/coreos/go-oidc"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/constants"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// DefaultOidcRefresh is the interval the provider metadata and signing keys are rediscovered in
+	DefaultOidcRefresh = 15 * time.Minute
+	oidcRetry          = 10 * time.Second
+	// oidcTimeout bounds every request to the identity provider, an unreachable keycloak must not hold up the start
+	oidcTimeout = 10 * time.Second
+)
+
+// Provider is the part of the discovered identity provider the clerk uses, so tests can stand in for keycloak
+type Provider interface {
+	Endpoint() oauth2.Endpoint
+	Verifier(config *oidc.Config) *oidc.IDTokenVerifier
+}
+
+// Discover looks up the provider of an issuer
+type Discover func(ctx context.Context, issuer string) (Provider, error)
+
+// DiscoverProvider runs the openid connect discovery against the issuer
+func DiscoverProvider(ctx context.Context, issuer string) (Provider, error) {
+	return oidc.NewProvider(ctx, issuer)
+}
+
+// OidcClient holds the provider of the keycloak realm for the lifetime of the server.
+// The provider is rediscovered in the background, a failing discovery keeps the last known provider.
+// As long as no provider could be discovered, the login and token checks fail with an error.
+type OidcClient struct {
+	keycloak models.Keycloak
+	logger   zLogger.ZLogger
+	discover Discover
+
+	lock         sync.RWMutex
+	verifier     *oidc.IDTokenVerifier
+	oauth2Config oauth2.Config
+	err          error
+}
+
+func NewOidcClient(keycloak models.Keycloak, logger zLogger.ZLogger, discover Discover) *OidcClient {
+	if discover == nil {
+		discover = DiscoverProvider
+	}
+	return &OidcClient{
+		keycloak: keycloak,
+		logger:   logger,
+		discover: discover,
+		err:      errors.New("identity provider not discovered yet"),
+	}
+}
+
+// Start discovers the provider and keeps it up to date until ctx is done
+func (o *OidcClient) Start(ctx context.Context, refresh time.Duration) {
+	if refresh <= 0 {
+		refresh = DefaultOidcRefresh
+	}
+	if err := o.Refresh(ctx); err != nil {
+		o.logger.Warn().Msgf("identity provider not available, retrying in background: %v", err)
+	}
+	go func() {
+		for {
+			interval := refresh
+			if !o.Available() {
+				interval = min(refresh, oidcRetry)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+			if err := o.Refresh(ctx); err != nil {
+				o.logger.Warn().Msgf("cannot refresh identity provider: %v", err)
+			}
+		}
+	}()
+}
+
+// Refresh runs the discovery and replaces the provider on success.
+// The new provider fetches the current signing keys of the realm, so rotated keys are picked up.
+// The provider keeps ctx for fetching the keys, so the requests are bounded by the client timeout
+// instead of a deadline on ctx.
+func (o *OidcClient) Refresh(ctx context.Context) error {
+	ctx = oidc.ClientContext(ctx, &http.Client{Timeout: oidcTimeout})
+	provider, err := o.discover(ctx, o.keycloak.Addr+o.keycloak.Realm)
+	if err != nil {
+		err = errors.Wrapf(err, "cannot discover identity provider %s%s", o.keycloak.Addr, o.keycloak.Realm)
+		o.lock.Lock()
+		if o.verifier == nil {
+			o.err = err
+		}
+		o.lock.Unlock()
+		return err
+	}
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.verifier = provider.Verifier(GetOidcConfig(o.keycloak))
+	o.oauth2Config = oauth2.Config{
+		ClientID:     o.keycloak.ClientId,
+		ClientSecret: o.keycloak.ClientSecret,
+		RedirectURL:  o.keycloak.Callback + "auth/callback",
+		// Discovery returns the OAuth2 endpoints.
+		Endpoint: provider.Endpoint(),
+		// "openid" is a required scope for OpenID Connect flows.
+		Scopes: []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+	o.err = nil
+	return nil
+}
+
+// Available reports whether a provider was discovered
+func (o *OidcClient) Available() bool {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	return o.verifier != nil
+}
+
+func (o *OidcClient) Verifier() (*oidc.IDTokenVerifier, error) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	if o.verifier == nil {
+		return nil, o.err
+	}
+	return o.verifier, nil
+}
+
+func (o *OidcClient) Oauth2Config() (oauth2.Config, error) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+	if o.verifier == nil {
+		return oauth2.Config{}, o.err
+	}
+	return o.oauth2Config, nil
+}
+
+// OidcClientFromContext retrieves the client the graphql handler attached to the request
+func OidcClientFromContext(ctx context.Context) (*OidcClient, error) {
+	client, ok := ctx.Value(constants.OIDC_CLIENT_CTX).(*OidcClient)
+	if !ok || client == nil {
+		return nil, errors.New("could't retrieve identity provider")
+	}
+	return client, nil
+}
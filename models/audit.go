@@ -0,0 +1,6 @@
+package models
+
+type AuditConfig struct {
+	File     string `toml:"file"`     // append-only jsonl file of the audit trail, needed for the auditEvents query
+	Logstash bool   `toml:"logstash"` // also send the audit events to the log stash
+}
@@ -0,0 +1,6 @@
+package models
+
+type BillingConfig struct {
+	Unit     string `toml:"unit"`     // bytes the price of the storage locations refers to: GB, TB, GiB or TiB, defaults to GB
+	Currency string `toml:"currency"` // shown with the costs, defaults to CHF
+}
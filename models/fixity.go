@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// FixityConfig is the maximum age of the last successful check of an object instance. The ones of the
+// collection and of the storage location of the instance, by id, take the place of the default, the
+// smaller one if both are set. 0 exempts from the policy. The overdue instances are found every interval,
+// an hour by default.
+type FixityConfig struct {
+	Interval         time.Duration            `toml:"interval"`
+	MaxAge           time.Duration            `toml:"maxage"`
+	Collections      map[string]time.Duration `toml:"collections"`
+	StorageLocations map[string]time.Duration `toml:"storagelocations"`
+}
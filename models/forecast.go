@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type ForecastConfig struct {
+	File      string        `toml:"file"`      // json file keeping the size samples, without one they are lost on restart
+	Interval  time.Duration `toml:"interval"`  // how often the sizes are sampled, defaults to an hour
+	Retention time.Duration `toml:"retention"` // how long samples are kept, defaults to 90 days
+	Window    int           `toml:"window"`    // samples the moving average is taken over, defaults to 24
+}
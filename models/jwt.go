@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type JwtConfig struct {
+	Mode       string        `toml:"mode"`       // hmac (shared jwt key) or jwks
+	JWKSURL    string        `toml:"jwksurl"`    // defaults to the certs endpoint of the keycloak realm
+	Algorithms []string      `toml:"algorithms"` // accepted signing algorithms in jwks mode, defaults to RS256 and ES256
+	Audience   []string      `toml:"audience"`   // token must be issued for one of them, checked against aud and azp
+	Issuer     string        `toml:"issuer"`     // defaults to the keycloak realm in jwks mode
+	ClockSkew  time.Duration `toml:"clockskew"`  // tolerance for exp and nbf
+	// tokens without groups, tenant_list and scope act as admin on the REST API. Only meant for the migration
+	// of clients using shared key tokens issued before the tenant permissions
+	LegacyAccess bool `toml:"legacyaccess"`
+}
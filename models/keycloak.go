@@ -55,6 +55,9 @@ type KeyCloakToken struct {
 	Sid               string                 `json:"sid,omitempty"`
 	// Aud               string                 `json:"aud,omitempty"`
 	TenantList []Tenant `json:"tenant_list,omitempty"`
+	Scope      string   `json:"scope,omitempty"`
+	// aliases of the collections a scoped ingest token may deliver to
+	IngestCollections []string `json:"ingest_collections,omitempty"`
 }
 
 type ServiceRole struct {
@@ -0,0 +1,5 @@
+package models
+
+type PartitionConfig struct {
+	StateFile string `toml:"statefile"` // json file keeping the lifecycle states of the storage partitions
+}
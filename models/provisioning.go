@@ -0,0 +1,18 @@
+package models
+
+import "github.com/je4/utils/v2/pkg/config"
+
+// ProvisioningConfig drives the capacity watcher creating the next partition of a filling storage location
+type ProvisioningConfig struct {
+	Enabled   bool            `toml:"enabled"`
+	Interval  config.Duration `toml:"interval"`  // how often the fill levels are checked, defaults to 5 minutes
+	Timeout   config.Duration `toml:"timeout"`   // bound of every call to the handlers, defaults to a minute
+	Threshold float64         `toml:"threshold"` // share of maxSize or maxObjects a partition counts as full at, defaults to 0.9
+	// alias of the new partitions with the number as fmt verb, defaults to vol/%04d
+	Template string `toml:"template"`
+	// limits of the new partitions, without them the ones of the latest partition are used
+	MaxSize    int64 `toml:"maxsize"`
+	MaxObjects int64 `toml:"maxobjects"`
+	// aliases of the storage locations to watch, all storage locations if empty
+	StorageLocations []string `toml:"storagelocations"`
+}
@@ -0,0 +1,7 @@
+package models
+
+import "time"
+
+type ReplicationConfig struct {
+	Interval time.Duration `toml:"interval"` // how often the replication gaps are computed, defaults to an hour
+}
@@ -0,0 +1,26 @@
+package models
+
+type SessionConfig struct {
+	Backend string `toml:"backend"` // memory, cookie, file or redis
+	Name    string `toml:"name"`    // name of the session cookie
+	// key pairs "<authentication key>:<encryption key>", base64 encoded. The first pair signs and encrypts new
+	// sessions, the following ones are only used to decode sessions created before a key rotation
+	Keys   []string           `toml:"keys"`
+	MaxAge int                `toml:"maxage"` // lifetime of a session in seconds
+	File   SessionFileConfig  `toml:"file"`
+	Redis  SessionRedisConfig `toml:"redis"`
+}
+
+type SessionFileConfig struct {
+	Path string `toml:"path"` // folder shared by all clerk instances
+}
+
+type SessionRedisConfig struct {
+	Network   string `toml:"network"` // tcp or unix
+	Addr      string `toml:"addr"`
+	Username  string `toml:"username"`
+	Password  string `toml:"password"`
+	DB        string `toml:"db"`
+	PoolSize  int    `toml:"poolsize"` // maximum number of idle connections
+	KeyPrefix string `toml:"keyprefix"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type StatusConfig struct {
+	// json file keeping which ingest token created a status, without one the creators are forgotten on restart
+	// and only admins can read the statuses created before. Clerks running side by side have to share the file.
+	OwnerFile string        `toml:"ownerfile"`
+	Retention time.Duration `toml:"retention"` // how long the creator of a status is kept, defaults to 30 days
+}
@@ -0,0 +1,8 @@
+package models
+
+// ValidationConfig lists the values the storage location inputs accept, an empty list accepts any value
+type ValidationConfig struct {
+	StorageLocationTypes []string `toml:"storagelocationtypes"`
+	OcflTypes            []string `toml:"ocfltypes"`
+	SecurityCompliencies []string `toml:"securitycompliencies"`
+}
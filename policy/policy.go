@@ -0,0 +1,172 @@
+package policy
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/middleware"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+)
+
+type Action string
+
+const (
+	Read   Action = "READ"
+	Create Action = "CREATE"
+	Update Action = "UPDATE"
+	Delete Action = "DELETE"
+)
+
+const AdminGroup = "dlza-admin"
+
+// IngestScope marks service tokens of ingest robots, which may only deliver objects to their collections
+const IngestScope = "dlza-ingest"
+
+// Kinds of entities the engine can resolve the owning tenant for
+const (
+	KindTenant              = "Tenant"
+	KindCollection          = "Collection"
+	KindStorageLocation     = "StorageLocation"
+	KindStoragePartition    = "StoragePartition"
+	KindObject              = "Object"
+	KindObjectInstance      = "ObjectInstance"
+	KindObjectInstanceCheck = "ObjectInstanceCheck"
+	KindFile                = "File"
+)
+
+// Subject is the caller as seen by the policy engine
+type Subject struct {
+	Groups  []string
+	Tenants []models.Tenant
+	// Ingest is set for scoped ingest tokens, which carry no tenant permissions
+	Ingest            bool
+	IngestCollections []string
+}
+
+func (s Subject) IsAdmin() bool {
+	return slices.Contains(s.Groups, AdminGroup)
+}
+
+// Allows checks the CRUD flag of the tenant entry matching action
+func (s Subject) Allows(tenantId string, action Action) bool {
+	if s.IsAdmin() {
+		return true
+	}
+	for _, tenant := range s.Tenants {
+		if tenant.Id != tenantId {
+			continue
+		}
+		switch action {
+		case Read:
+			return tenant.Read
+		case Create:
+			return tenant.Create
+		case Update:
+			return tenant.Update
+		case Delete:
+			return tenant.Delete
+		}
+	}
+	return false
+}
+
+// ReadableTenants lists the tenants the subject may read, as the AllowedTenants filter of list queries.
+// Admins get an empty list, which the handler reads as all tenants.
+func (s Subject) ReadableTenants() ([]string, error) {
+	if s.IsAdmin() {
+		return []string{}, nil
+	}
+	allowedTenants := make([]string, 0, len(s.Tenants))
+	for _, tenant := range s.Tenants {
+		if s.Allows(tenant.Id, Read) {
+			allowedTenants = append(allowedTenants, tenant.Id)
+		}
+	}
+	if len(allowedTenants) == 0 {
+		return nil, forbidden(Read, "")
+	}
+	return allowedTenants, nil
+}
+
+// SubjectFromContext reads groups and tenant permissions of the logged-in user
+func SubjectFromContext(ctx context.Context) (Subject, error) {
+	groups, tenants, err := middleware.TenantGroups(ctx)
+	if err != nil {
+		return Subject{}, err
+	}
+	return Subject{Groups: groups, Tenants: tenants}, nil
+}
+
+// SubjectFromClaims reads groups and tenant permissions from the claims of a bearer token
+func SubjectFromClaims(claims *models.KeyCloakToken) Subject {
+	if slices.Contains(strings.Fields(claims.Scope), IngestScope) {
+		return Subject{Ingest: true, IngestCollections: claims.IngestCollections}
+	}
+	return Subject{Groups: claims.Groups, Tenants: claims.TenantList}
+}
+
+// MayIngest checks whether a scoped ingest token may deliver to the collection with alias
+func (s Subject) MayIngest(alias string) bool {
+	return s.Ingest && alias != "" && slices.Contains(s.IngestCollections, alias)
+}
+
+// Target is an entity an action is performed on
+type Target struct {
+	Kind string
+	ID   string
+}
+
+// OwnerResolver finds the tenant owning an entity
+type OwnerResolver interface {
+	TenantOf(ctx context.Context, kind string, id string) (string, error)
+}
+
+// Engine is the single place where tenant permissions are decided
+type Engine struct {
+	owners OwnerResolver
+}
+
+func NewEngine(owners OwnerResolver) *Engine {
+	return &Engine{owners: owners}
+}
+
+// Authorize checks action on all targets. Without targets, only callers which may act on some tenant pass,
+// the list queries restrict the result to the allowed tenants themselves.
+func (e *Engine) Authorize(ctx context.Context, subject Subject, action Action, targets []Target) error {
+	if len(targets) == 0 {
+		if subject.IsAdmin() {
+			return nil
+		}
+		if action == Read {
+			_, err := subject.ReadableTenants()
+			return err
+		}
+		return forbidden(action, "")
+	}
+	for _, target := range targets {
+		tenantId := target.ID
+		if target.Kind != KindTenant {
+			var err error
+			tenantId, err = e.owners.TenantOf(ctx, target.Kind, target.ID)
+			if err != nil {
+				return errors.Wrapf(err, "Could not resolve tenant of %s %s", target.Kind, target.ID)
+			}
+		}
+		if !subject.Allows(tenantId, action) {
+			return forbidden(action, tenantId)
+		}
+	}
+	return nil
+}
+
+func forbidden(action Action, tenantId string) error {
+	if action == Read {
+		return errors.New("You are not allowed to retrieve datas")
+	}
+	if tenantId == "" {
+		return errors.Errorf("You are not allowed to proceed with %s", strings.ToLower(string(action)))
+	}
+	return errors.Errorf("You are not allowed to proceed with %s for tenant %s", strings.ToLower(string(action)), tenantId)
+}
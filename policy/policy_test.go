@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+)
+
+type ownersMap map[string]string
+
+func (o ownersMap) TenantOf(ctx context.Context, kind string, id string) (string, error) {
+	tenantId, ok := o[kind+"/"+id]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return tenantId, nil
+}
+
+func TestAuthorize(t *testing.T) {
+	engine := NewEngine(ownersMap{KindCollection + "/c1": "t1", KindCollection + "/c2": "t2"})
+	admin := Subject{Groups: []string{AdminGroup}}
+	reader := Subject{Tenants: []models.Tenant{{Id: "t1", Read: true}}}
+	editor := Subject{Tenants: []models.Tenant{{Id: "t1", Read: true, Update: true}}}
+	ingest := Subject{Ingest: true, IngestCollections: []string{"c1"}}
+	tests := []struct {
+		name    string
+		subject Subject
+		action  Action
+		targets []Target
+		allowed bool
+	}{
+		{"admin without targets", admin, Delete, nil, true},
+		{"admin on any tenant", admin, Delete, []Target{{Kind: KindCollection, ID: "c2"}}, true},
+		{"reader lists", reader, Read, nil, true},
+		{"tenant without read flag lists", Subject{Tenants: []models.Tenant{{Id: "t1", Update: true}}}, Read, nil, false},
+		{"reader creates without target", reader, Create, nil, false},
+		{"reader reads own tenant", reader, Read, []Target{{Kind: KindTenant, ID: "t1"}}, true},
+		{"reader reads other tenant", reader, Read, []Target{{Kind: KindTenant, ID: "t2"}}, false},
+		{"reader updates own collection", reader, Update, []Target{{Kind: KindCollection, ID: "c1"}}, false},
+		{"editor updates own collection", editor, Update, []Target{{Kind: KindCollection, ID: "c1"}}, true},
+		{"editor updates every target", editor, Update, []Target{{Kind: KindCollection, ID: "c1"}, {Kind: KindCollection, ID: "c2"}}, false},
+		{"unknown owner", editor, Read, []Target{{Kind: KindCollection, ID: "c3"}}, false},
+		{"ingest token has no tenant permissions", ingest, Read, []Target{{Kind: KindCollection, ID: "c1"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.Authorize(context.Background(), tt.subject, tt.action, tt.targets)
+			if (err == nil) != tt.allowed {
+				t.Errorf("Authorize = %v, want allowed %v", err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestMayIngest(t *testing.T) {
+	ingest := Subject{Ingest: true, IngestCollections: []string{"archive"}}
+	tests := []struct {
+		name    string
+		subject Subject
+		alias   string
+		allowed bool
+	}{
+		{"own collection", ingest, "archive", true},
+		{"other collection", ingest, "backup", false},
+		{"no alias", ingest, "", false},
+		{"no ingest token", Subject{IngestCollections: []string{"archive"}}, "archive", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allowed := tt.subject.MayIngest(tt.alias); allowed != tt.allowed {
+				t.Errorf("MayIngest(%s) = %v, want %v", tt.alias, allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestReadableTenants(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject Subject
+		want    []string
+		allowed bool
+	}{
+		{"admin reads all tenants", Subject{Groups: []string{AdminGroup}}, []string{}, true},
+		{"reader", Subject{Tenants: []models.Tenant{{Id: "t1", Read: true}, {Id: "t2", Read: true}}}, []string{"t1", "t2"}, true},
+		{"tenant without read flag is left out", Subject{Tenants: []models.Tenant{{Id: "t1", Read: true}, {Id: "t2", Update: true}}}, []string{"t1"}, true},
+		{"no read flag at all", Subject{Tenants: []models.Tenant{{Id: "t1", Update: true, Delete: true}}}, nil, false},
+		{"no tenants", Subject{}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.subject.ReadableTenants()
+			if (err == nil) != tt.allowed {
+				t.Fatalf("ReadableTenants error = %v, want allowed %v", err, tt.allowed)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ReadableTenants = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
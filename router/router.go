@@ -8,14 +8,14 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func NewRouter(key string, controllers ...controller.Controller) *gin.Engine {
+func NewRouter(verifier auth.Verifier, controllers ...controller.Controller) *gin.Engine {
 	router := gin.Default()
 
 	//Swagger
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	baseRouter := router.Group("/api")
-	baseRouter.Use(auth.JwtAuthMiddleware(key))
+	baseRouter.Use(auth.JwtAuthMiddleware(verifier))
 
 	for _, cntr := range controllers {
 		subRouter := baseRouter.Group(cntr.Path())
@@ -11,25 +11,38 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	"emperror.dev/errors"
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/memstore"
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
 	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
 	"github.com/ocfl-archive/dlza-manager-clerk/constants"
+	"github.com/ocfl-archive/dlza-manager-clerk/dataloader"
+	"github.com/ocfl-archive/dlza-manager-clerk/events"
+	"github.com/ocfl-archive/dlza-manager-clerk/forecast"
 	"github.com/ocfl-archive/dlza-manager-clerk/graph"
+	"github.com/ocfl-archive/dlza-manager-clerk/lifecycle"
 	"github.com/ocfl-archive/dlza-manager-clerk/middleware"
 	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	"github.com/ocfl-archive/dlza-manager-clerk/service"
+	"github.com/ocfl-archive/dlza-manager-clerk/sessionstore"
 	pb "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
 	storagepb "github.com/ocfl-archive/dlza-manager-storage-handler/storagehandlerproto"
+	"github.com/vektah/gqlparser/v2/ast"
 	"golang.org/x/net/http2"
 )
 
-func NewServer(addr, extAddr string, cert tls.Certificate, addCAs []*x509.Certificate, staticFS fs.FS, logger zLogger.ZLogger, keycloak models.Keycloak, clientClerkHandler pb.ClerkHandlerServiceClient, clientClerkStorageHandler storagepb.ClerkStorageHandlerServiceClient, router *gin.Engine, domain string) (*Server, error) {
+func NewServer(addr, extAddr string, cert tls.Certificate, addCAs []*x509.Certificate, staticFS fs.FS, logger zLogger.ZLogger, keycloak models.Keycloak, clientClerkHandler pb.ClerkHandlerServiceClient, clientClerkStorageHandler storagepb.ClerkStorageHandlerServiceClient, router *gin.Engine, domain string, sessionConfig models.SessionConfig, eventSource events.Source, auditLog *audit.Log, validationConfig models.ValidationConfig, partitionStates *lifecycle.Store, capacityHistory *forecast.History, forecastConfig models.ForecastConfig, billingConfig models.BillingConfig, fixity *service.FixityScanner, replicationGaps *service.ReplicationScanner) (*Server, error) {
 	server := &Server{
 		addr:                      addr,
 		extAddr:                   extAddr,
@@ -42,6 +55,16 @@ func NewServer(addr, extAddr string, cert tls.Certificate, addCAs []*x509.Certif
 		ClientClerkStorageHandler: clientClerkStorageHandler,
 		router:                    router,
 		domain:                    domain,
+		sessionConfig:             sessionConfig,
+		eventSource:               eventSource,
+		auditLog:                  auditLog,
+		validationConfig:          validationConfig,
+		partitionStates:           partitionStates,
+		capacityHistory:           capacityHistory,
+		forecastConfig:            forecastConfig,
+		billingConfig:             billingConfig,
+		fixity:                    fixity,
+		replicationGaps:           replicationGaps,
 	}
 	return server, nil
 }
@@ -59,6 +82,23 @@ type Server struct {
 	ClientClerkStorageHandler storagepb.ClerkStorageHandlerServiceClient
 	router                    *gin.Engine
 	domain                    string
+	sessionConfig             models.SessionConfig
+	oidcClient                *middleware.OidcClient
+	eventSource               events.Source
+	auditLog                  *audit.Log
+	validationConfig          models.ValidationConfig
+	partitionStates           *lifecycle.Store
+	capacityHistory           *forecast.History
+	forecastConfig            models.ForecastConfig
+	billingConfig             models.BillingConfig
+	fixity                    *service.FixityScanner
+	replicationGaps           *service.ReplicationScanner
+	discover                  middleware.Discover
+}
+
+// SetDiscover replaces the openid connect discovery, e.g. to run against a stand-in identity provider
+func (srv *Server) SetDiscover(discover middleware.Discover) {
+	srv.discover = discover
 }
 
 var UiFS embed.FS
@@ -77,17 +117,9 @@ func (srv *Server) Startup() (context.CancelFunc, error) {
 	// // Keycloak configuration
 	// ctx := context.Background()
 
-	provider := middleware.GetProvider(srv.keycloak)
-	var claims struct {
-		EndSessionURL string `json:"end_session_endpoint"`
-	}
-
-	// oauth2Config := middleware.GetOauth2Config(srv.keycloak)
-
-	err := provider.Claims(&claims)
-	if err != nil {
-		return nil, err
-	}
+	oidcCtx, oidcCancel := context.WithCancel(context.Background())
+	srv.oidcClient = middleware.NewOidcClient(srv.keycloak, srv.logger, srv.discover)
+	srv.oidcClient.Start(oidcCtx, middleware.DefaultOidcRefresh)
 
 	// oidcConfig := &oidc.Config{
 	// 	ClientID: srv.keycloak.ClientId,
@@ -101,12 +133,26 @@ func (srv *Server) Startup() (context.CancelFunc, error) {
 
 	router := srv.router
 
-	store := memstore.NewStore([]byte("secretToBeChangedWithKubernetesSecret"))
+	store, err := sessionstore.NewStore(srv.sessionConfig)
+	if err != nil {
+		oidcCancel()
+		return nil, errors.Wrap(err, "cannot create session store")
+	}
+	if len(srv.sessionConfig.Keys) == 0 {
+		srv.logger.Warn().Msg("no session keys configured, sessions are lost on restart and cannot be shared between instances")
+	}
 	// store.Options(sessions.Options{Secure: true, SameSite: http.SameSiteLaxMode, HttpOnly: true})
-	store.Options(sessions.Options{Secure: true, SameSite: http.SameSiteNoneMode, HttpOnly: true})
+	store.Options(sessions.Options{Secure: true, SameSite: http.SameSiteNoneMode, HttpOnly: true, MaxAge: srv.sessionConfig.MaxAge})
 	gob.Register(models.KeyCloakToken{})
 	gob.Register([]models.Tenant{})
-	router.Use(sessions.Sessions("mysession", store))
+	sessionName := srv.sessionConfig.Name
+	if sessionName == "" {
+		sessionName = sessionstore.DefaultName
+	}
+	if sweeper, ok := store.(sessionstore.Sweeper); ok {
+		sweeper.Start(oidcCtx, sessionstore.DefaultSweepInterval, srv.logger)
+	}
+	router.Use(sessions.Sessions(sessionName, store))
 	// router.NoRoute(func(c *gin.Context) {
 	// 	fmt.Println("test")
 	// 	fmt.Printf("%s doesn't exists, redirect on / ", c.Request.URL.Path)
@@ -145,8 +191,11 @@ func (srv *Server) Startup() (context.CancelFunc, error) {
 
 	graphql := router.Group("/graphql")
 	{
-		graphql.POST("", srv.graphqlHandler(srv.ClientClerkHandler, srv.ClientClerkStorageHandler))
-		graphql.OPTIONS("", srv.graphqlHandler(srv.ClientClerkHandler, srv.ClientClerkStorageHandler))
+		graphqlHandler := srv.graphqlHandler(srv.ClientClerkHandler, srv.ClientClerkStorageHandler)
+		graphql.POST("", graphqlHandler)
+		graphql.OPTIONS("", graphqlHandler)
+		// websocket upgrade of the subscriptions
+		graphql.GET("", graphqlHandler)
 	}
 
 	embedFolder, err := static.EmbedFolder(UiFS, "dlza-frontend/build")
@@ -196,6 +245,7 @@ func (srv *Server) Startup() (context.CancelFunc, error) {
 	}
 
 	if err := http2.ConfigureServer(&srv.server, nil); err != nil {
+		oidcCancel()
 		return nil, errors.Wrap(err, "cannot configure http2 server")
 	}
 
@@ -208,6 +258,7 @@ func (srv *Server) Startup() (context.CancelFunc, error) {
 		}
 	}()
 	return func() {
+		oidcCancel()
 		if err := srv.server.Close(); err != nil {
 			srv.logger.Error().Msgf("error closing server: %v", err)
 		}
@@ -228,7 +279,29 @@ func (srv *Server) graphqlHandler(clientClerkHandler pb.ClerkHandlerServiceClien
 	// NewExecutableSchema and Config are in the generated.go file
 	// Resolver is in the resolver.go file
 
-	h := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{ClientClerkHandler: clientClerkHandler, ClientClerkStorageHandler: clientClerkStorageHandler, Logger: srv.logger}}))
+	engine := policy.NewEngine(service.NewTenantOwners(clientClerkHandler))
+	watcher := service.NewEventWatcher(clientClerkHandler, srv.eventSource, service.DefaultEventInterval, srv.logger)
+	h := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{ClientClerkHandler: clientClerkHandler, ClientClerkStorageHandler: clientClerkStorageHandler, Logger: srv.logger, Events: srv.eventSource, Watcher: watcher, Audit: srv.auditLog, Validation: srv.validationConfig, PartitionStates: srv.partitionStates, CapacityHistory: srv.capacityHistory, Forecast: srv.forecastConfig, Billing: srv.billingConfig, Fixity: srv.fixity, ReplicationGaps: srv.replicationGaps}, Directives: graph.NewDirectiveRoot(engine)}))
+	// subscriptions are served over server-sent events and websockets, sse has to be checked before plain POST
+	h.AddTransport(transport.SSE{})
+	h.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              websocketInit,
+	})
+	h.AddTransport(transport.Options{})
+	h.AddTransport(transport.GET{})
+	h.AddTransport(transport.POST{})
+	h.AddTransport(transport.MultipartForm{})
+	h.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	h.Use(extension.Introspection{})
+	// every response gets its own loaders: a query is one response, a subscription one per event, so nested
+	// fields of later events are not served from the cache of the first one
+	h.AroundResponses(func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		return next(dataloader.WithLoaders(ctx, dataloader.NewLoaders(clientClerkHandler)))
+	})
+	h.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](100),
+	})
 	return func(c *gin.Context) {
 		// fmt.Println("test before")
 		// c.Header("Access-Control-Allow-Origin", "https://localhost:9087")
@@ -240,6 +313,19 @@ func (srv *Server) graphqlHandler(clientClerkHandler pb.ClerkHandlerServiceClien
 		// fmt.Println("test after")
 		ctx := context.WithValue(c, constants.Needed, "Needed to attach context")
 		c.Set("keycloak", srv.keycloak)
+		c.Set(constants.OIDC_CLIENT_CTX, srv.oidcClient)
 		h.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
 	}
 }
+
+// websocketInit takes the bearer token from the connection_init payload, as browsers cannot set headers on websockets
+func websocketInit(ctx context.Context, initPayload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+	if authorization := initPayload.Authorization(); authorization != "" {
+		c, err := middleware.GinContextFromContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.Request.Header.Set("Authorization", authorization)
+	}
+	return ctx, &initPayload, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/dataloader"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/validation"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+const (
+	DefaultPriceUnit = "GB"
+	DefaultCurrency  = "CHF"
+)
+
+var (
+	priceUnits = map[string]float64{
+		"GB":  1e9,
+		"TB":  1e12,
+		"GiB": 1 << 30,
+		"TiB": 1 << 40,
+	}
+	periodPattern = regexp.MustCompile(`^\d{4}-(0[1-9]|1[0-2])$`)
+)
+
+// CostReport computes the cost of the object instances of the tenant in the month period, per collection and
+// storage location. An instance created within the month counts for the days it existed. The handler keeps no
+// deleted instances, so instances deleted within or after the month are not known anymore and cost nothing.
+func CostReport(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, conf models.BillingConfig, tenantId string, period string) (*model.CostReport, error) {
+	if err := validation.Validate("cost report arguments",
+		validation.Field("tenantId", tenantId, validation.Required),
+		validation.Field("period", period, validation.Match(periodPattern, "should be a month as YYYY-MM"), validation.Func("past", func(value any) string {
+			if value.(string) > time.Now().Format("2006-01") {
+				return "should not be in the future"
+			}
+			return ""
+		})),
+	); err != nil {
+		return nil, err
+	}
+	unit, currency := conf.Unit, conf.Currency
+	if unit == "" {
+		unit = DefaultPriceUnit
+	}
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	unitBytes, ok := priceUnits[unit]
+	if !ok {
+		return nil, errors.Errorf("unknown price unit %s, use GB, TB, GiB or TiB", unit)
+	}
+	start, err := time.Parse("2006-01", period)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot parse period %s", period)
+	}
+	end := start.AddDate(0, 1, 0)
+	collectionsPb, err := clientClerkHandler.GetCollectionsByTenantId(ctx, &pb.Id{Id: tenantId})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetCollectionsByTenantId: %v", err)
+	}
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	report := &model.CostReport{TenantID: tenantId, Period: period, Unit: unit, Currency: currency, Collections: make([]*model.CollectionCost, 0)}
+	for _, collectionPb := range collectionsPb.Collections {
+		collectionCost := &model.CollectionCost{CollectionID: collectionPb.Id, Alias: collectionPb.Alias, StorageLocations: make([]*model.StorageLocationCost, 0)}
+		locationCosts := make(map[string]*model.StorageLocationCost)
+		err := scanObjectInstances(ctx, clientClerkHandler, []*pb.Collection{collectionPb}, func(collectionPb *pb.Collection, objectPb *pb.Object, objectInstancesPb []*pb.ObjectInstance) error {
+			for _, objectInstancePb := range objectInstancesPb {
+				share, err := storedShare(objectInstancePb.Created, start, end)
+				if err != nil {
+					return errors.Wrapf(err, "Could not bill object instance %s", objectInstancePb.Id)
+				}
+				if share == 0 {
+					continue
+				}
+				storagePartitionPb, err := loaders.StoragePartition.Load(ctx, objectInstancePb.StoragePartitionId)
+				if err != nil {
+					return errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
+				}
+				locationCost, ok := locationCosts[storagePartitionPb.StorageLocationId]
+				if !ok {
+					storageLocationPb, err := loaders.StorageLocation.Load(ctx, storagePartitionPb.StorageLocationId)
+					if err != nil {
+						return errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
+					}
+					locationCost = &model.StorageLocationCost{StorageLocationID: storageLocationPb.Id, Alias: storageLocationPb.Alias, Price: int(storageLocationPb.Price)}
+					locationCosts[storageLocationPb.Id] = locationCost
+					collectionCost.StorageLocations = append(collectionCost.StorageLocations, locationCost)
+				}
+				locationCost.ObjectInstances++
+				locationCost.AverageSize += float64(objectInstancePb.Size) * share
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, locationCost := range collectionCost.StorageLocations {
+			locationCost.Cost = locationCost.AverageSize / unitBytes * float64(locationCost.Price)
+			collectionCost.Total += locationCost.Cost
+		}
+		slices.SortFunc(collectionCost.StorageLocations, func(a, b *model.StorageLocationCost) int {
+			return cmp.Compare(a.Alias, b.Alias)
+		})
+		report.Total += collectionCost.Total
+		report.Collections = append(report.Collections, collectionCost)
+	}
+	slices.SortFunc(report.Collections, func(a, b *model.CollectionCost) int {
+		return cmp.Compare(a.Alias, b.Alias)
+	})
+	return report, nil
+}
+
+// storedShare is the part of the month [start, end) the instance existed in. A creation time which cannot be
+// read is an error, the instance could not be billed right.
+func storedShare(created string, start time.Time, end time.Time) (float64, error) {
+	createdAt, ok := parseHandlerTime(created)
+	if !ok {
+		return 0, errors.Errorf("cannot read creation time %q", created)
+	}
+	if !createdAt.Before(end) {
+		return 0, nil
+	}
+	if createdAt.Before(start) {
+		return 1, nil
+	}
+	return float64(end.Sub(createdAt)) / float64(end.Sub(start)), nil
+}
+
+// WriteCostReportCSV writes one line per collection and storage location
+func WriteCostReportCSV(w io.Writer, report *model.CostReport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"period", "tenant_id", "collection_id", "collection", "storage_location_id", "storage_location", "object_instances", "average_size", "price", "unit", "currency", "cost"}); err != nil {
+		return errors.Wrap(err, "cannot write cost report")
+	}
+	for _, collectionCost := range report.Collections {
+		for _, locationCost := range collectionCost.StorageLocations {
+			record := []string{
+				report.Period,
+				report.TenantID,
+				collectionCost.CollectionID,
+				collectionCost.Alias,
+				locationCost.StorageLocationID,
+				locationCost.Alias,
+				strconv.Itoa(locationCost.ObjectInstances),
+				strconv.FormatFloat(locationCost.AverageSize, 'f', 0, 64),
+				strconv.Itoa(locationCost.Price),
+				report.Unit,
+				report.Currency,
+				strconv.FormatFloat(locationCost.Cost, 'f', 2, 64),
+			}
+			if err := writer.Write(record); err != nil {
+				return errors.Wrap(err, "cannot write cost report")
+			}
+		}
+	}
+	writer.Flush()
+	return errors.Wrap(writer.Error(), "cannot write cost report")
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoredShare(t *testing.T) {
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	tests := []struct {
+		name    string
+		created string
+		share   float64
+		wantErr bool
+	}{
+		{"before the month", "2026-08-20T00:00:00Z", 1, false},
+		{"at the start", "2026-09-01T00:00:00Z", 1, false},
+		{"half of the month", "2026-09-16T00:00:00Z", 0.5, false},
+		{"handler layout", "2026-09-16 00:00:00.000000", 0.5, false},
+		{"at the end", "2026-10-01T00:00:00Z", 0, false},
+		{"after the month", "2026-10-05T00:00:00Z", 0, false},
+		{"unreadable", "yesterday", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			share, err := storedShare(tt.created, start, end)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storedShare(%q) error = %v, want error %v", tt.created, err, tt.wantErr)
+			}
+			if share != tt.share {
+				t.Errorf("storedShare(%q) = %v, want %v", tt.created, share, tt.share)
+			}
+		})
+	}
+}
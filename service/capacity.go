@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/config"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	"github.com/ocfl-archive/dlza-manager-clerk/lifecycle"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pbStorageHandler "github.com/ocfl-archive/dlza-manager-storage-handler/storagehandlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+const (
+	DefaultCapacityInterval  = 5 * time.Minute
+	DefaultCapacityTimeout   = time.Minute
+	DefaultCapacityThreshold = 0.9
+	DefaultPartitionTemplate = "vol/%04d"
+	// partitionTemplateAttempts bounds the search for a free alias
+	partitionTemplateAttempts = 1000
+)
+
+// capacityWatcherActor is the actor of the partitions the watcher creates in the audit trail
+var capacityWatcherActor = audit.Actor{Name: "capacity watcher", Source: audit.SourceWatcher}
+
+// CapacityWatcher checks the fill levels of the partitions per storage location. Once all active partitions of a
+// location crossed the threshold, it creates the next partition from the template, the way createStoragePartition
+// does. Locations without active partitions are left alone, they were sealed on purpose.
+// Clerks running side by side may both create the next partition. The alias is the same for both, so after a
+// create the empty duplicates of the alias are deleted and the partition with the lowest id is kept.
+type CapacityWatcher struct {
+	ClientClerkHandler        pbHandler.ClerkHandlerServiceClient
+	ClientClerkStorageHandler pbStorageHandler.ClerkStorageHandlerServiceClient
+	PartitionStates           *lifecycle.Store
+	Audit                     *audit.Log
+	Config                    models.ProvisioningConfig
+	Logger                    zLogger.ZLogger
+}
+
+func NewCapacityWatcher(clientClerkHandler pbHandler.ClerkHandlerServiceClient, clientClerkStorageHandler pbStorageHandler.ClerkStorageHandlerServiceClient, partitionStates *lifecycle.Store, auditLog *audit.Log, conf models.ProvisioningConfig, logger zLogger.ZLogger) *CapacityWatcher {
+	if conf.Interval <= 0 {
+		conf.Interval = config.Duration(DefaultCapacityInterval)
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = config.Duration(DefaultCapacityTimeout)
+	}
+	if conf.Threshold <= 0 || conf.Threshold > 1 {
+		conf.Threshold = DefaultCapacityThreshold
+	}
+	if conf.Template == "" {
+		conf.Template = DefaultPartitionTemplate
+	}
+	return &CapacityWatcher{
+		ClientClerkHandler:        clientClerkHandler,
+		ClientClerkStorageHandler: clientClerkStorageHandler,
+		PartitionStates:           partitionStates,
+		Audit:                     auditLog,
+		Config:                    conf,
+		Logger:                    logger,
+	}
+}
+
+// Start checks the storage locations every interval until ctx is done
+func (w *CapacityWatcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Duration(w.Config.Interval))
+		defer ticker.Stop()
+		for {
+			if err := w.Check(ctx); err != nil && ctx.Err() == nil {
+				w.Logger.Warn().Msgf("cannot check partition capacity: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Check provisions a partition for every watched storage location which filled up. A failing tenant or location
+// does not keep the others from being checked, the errors of the tenants are returned together.
+func (w *CapacityWatcher) Check(ctx context.Context) error {
+	tenantsPb, err := call(ctx, w.Config.Timeout, func(ctx context.Context) (*pb.Tenants, error) {
+		return w.ClientClerkHandler.FindAllTenants(ctx, &pb.NoParam{})
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Could not FindAllTenants: %v", err)
+	}
+	var result error
+	for _, tenantPb := range tenantsPb.Tenants {
+		storageLocationsPb, err := call(ctx, w.Config.Timeout, func(ctx context.Context) (*pb.StorageLocations, error) {
+			return w.ClientClerkHandler.GetStorageLocationsByTenantId(ctx, &pb.Id{Id: tenantPb.Id})
+		})
+		if err != nil {
+			result = errors.Append(result, errors.Wrapf(err, "Could not GetStorageLocationsByTenantId for tenant %s: %v", tenantPb.Alias, err))
+			continue
+		}
+		for _, storageLocationPb := range storageLocationsPb.StorageLocations {
+			if len(w.Config.StorageLocations) > 0 && !slices.Contains(w.Config.StorageLocations, storageLocationPb.Alias) {
+				continue
+			}
+			if err := w.checkStorageLocation(ctx, storageLocationPb); err != nil {
+				w.Logger.Warn().Msgf("cannot provision partition for storage location %s: %v", storageLocationPb.Alias, err)
+			}
+		}
+	}
+	return result
+}
+
+// call bounds fn with timeout
+func call[T any](ctx context.Context, timeout config.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	callCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout))
+	defer cancel()
+	return fn(callCtx)
+}
+
+func (w *CapacityWatcher) checkStorageLocation(ctx context.Context, storageLocationPb *pb.StorageLocation) error {
+	storagePartitionsPb, err := call(ctx, w.Config.Timeout, func(ctx context.Context) ([]*pb.StoragePartition, error) {
+		return getAllStoragePartitionsForLocation(ctx, w.ClientClerkHandler, storageLocationPb.Id)
+	})
+	if err != nil {
+		return err
+	}
+	var latest *pb.StoragePartition
+	active := 0
+	for _, storagePartitionPb := range storagePartitionsPb {
+		if !w.PartitionStates.Get(ctx, storagePartitionPb.Id).State.Writable() {
+			continue
+		}
+		if !w.full(storagePartitionPb) {
+			return nil
+		}
+		active++
+		latest = storagePartitionPb
+	}
+	if active == 0 {
+		return nil
+	}
+	alias, err := w.nextAlias(storagePartitionsPb)
+	if err != nil {
+		return err
+	}
+	input := &model.StoragePartitionInput{
+		Alias:             alias,
+		Name:              alias,
+		MaxSize:           int(w.Config.MaxSize),
+		MaxObjects:        int(w.Config.MaxObjects),
+		StorageLocationID: storageLocationPb.Id,
+	}
+	if input.MaxSize == 0 {
+		input.MaxSize = int(latest.MaxSize)
+	}
+	if input.MaxObjects == 0 {
+		input.MaxObjects = int(latest.MaxObjects)
+	}
+	storagePartition, err := call(ctx, w.Config.Timeout, func(ctx context.Context) (*model.StoragePartition, error) {
+		return CreateStoragePartition(ctx, w.ClientClerkHandler, w.ClientClerkStorageHandler, input)
+	})
+	if err != nil {
+		return err
+	}
+	w.Logger.Info().Msgf("created partition %s of storage location %s, its active partitions crossed %.0f%% of their limits", alias, storageLocationPb.Alias, w.Config.Threshold*100)
+	w.Audit.Record(ctx, capacityWatcherActor, string(policy.Create), policy.KindStoragePartition, storagePartition.ID, nil, storagePartition)
+	return w.removeDuplicates(ctx, storageLocationPb, alias)
+}
+
+// removeDuplicates deletes the empty partitions another clerk created with the same alias, keeping the one with
+// the lowest id. Every clerk comes to the same result, so one of the partitions is left.
+func (w *CapacityWatcher) removeDuplicates(ctx context.Context, storageLocationPb *pb.StorageLocation, alias string) error {
+	storagePartitionsPb, err := call(ctx, w.Config.Timeout, func(ctx context.Context) ([]*pb.StoragePartition, error) {
+		return getAllStoragePartitionsForLocation(ctx, w.ClientClerkHandler, storageLocationPb.Id)
+	})
+	if err != nil {
+		return err
+	}
+	duplicates := slices.DeleteFunc(storagePartitionsPb, func(storagePartitionPb *pb.StoragePartition) bool {
+		return storagePartitionPb.Alias != alias
+	})
+	slices.SortFunc(duplicates, func(a, b *pb.StoragePartition) int { return strings.Compare(a.Id, b.Id) })
+	for _, duplicate := range duplicates[min(1, len(duplicates)):] {
+		_, err := call(ctx, w.Config.Timeout, func(ctx context.Context) (*pb.Status, error) {
+			if err := refuseNonEmptyPartition(ctx, w.ClientClerkHandler, duplicate); err != nil {
+				return nil, err
+			}
+			return w.ClientClerkHandler.DeleteStoragePartitionById(ctx, &pb.Id{Id: duplicate.Id})
+		})
+		if err != nil {
+			return errors.Wrapf(err, "Could not delete duplicate partition %s of storage location %s", alias, storageLocationPb.Alias)
+		}
+		w.Logger.Info().Msgf("deleted duplicate partition %s (%s) of storage location %s", alias, duplicate.Id, storageLocationPb.Alias)
+		w.Audit.Record(ctx, capacityWatcherActor, string(policy.Delete), policy.KindStoragePartition, duplicate.Id, storagePartitionToGraphQlStoragePartition(duplicate), nil)
+	}
+	return nil
+}
+
+// full reports whether the partition crossed the threshold of one of its limits, a limit of 0 is not checked
+func (w *CapacityWatcher) full(storagePartitionPb *pb.StoragePartition) bool {
+	if storagePartitionPb.MaxSize > 0 && float64(storagePartitionPb.CurrentSize) >= w.Config.Threshold*float64(storagePartitionPb.MaxSize) {
+		return true
+	}
+	return storagePartitionPb.MaxObjects > 0 && float64(storagePartitionPb.CurrentObjects) >= w.Config.Threshold*float64(storagePartitionPb.MaxObjects)
+}
+
+// nextAlias numbers the new partition one above the highest partition following the template
+func (w *CapacityWatcher) nextAlias(storagePartitionsPb []*pb.StoragePartition) (string, error) {
+	taken := make(map[string]bool, len(storagePartitionsPb))
+	next := 1
+	for _, storagePartitionPb := range storagePartitionsPb {
+		taken[storagePartitionPb.Alias] = true
+		var number int
+		if _, err := fmt.Sscanf(storagePartitionPb.Alias, w.Config.Template, &number); err == nil && fmt.Sprintf(w.Config.Template, number) == storagePartitionPb.Alias {
+			next = max(next, number+1)
+		}
+	}
+	for i := 0; i < partitionTemplateAttempts; i++ {
+		alias := fmt.Sprintf(w.Config.Template, next+i)
+		if !taken[alias] {
+			return alias, nil
+		}
+	}
+	return "", errors.Errorf("no free partition alias for template %s", w.Config.Template)
+}
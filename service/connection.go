@@ -0,0 +1,380 @@
+package service
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+)
+
+const (
+	defaultConnectionSize = 10
+	maxConnectionSize     = 1000
+	// edges looked at on either side of the offset a cursor was issued at to find its node again
+	cursorWindow = 100
+)
+
+// connectionArgs holds the relay pagination arguments of a connection field.
+type connectionArgs struct {
+	first  *int
+	after  *string
+	last   *int
+	before *string
+}
+
+// listOrder is the sort key and direction of the list a connection pages through
+type listOrder struct {
+	sortKey    string
+	descending bool
+}
+
+func newListOrder[K ~string](sortKey *K, direction *model.SortDirection) listOrder {
+	order := listOrder{sortKey: "id"}
+	if sortKey != nil {
+		order.sortKey = string(*sortKey)
+	}
+	order.descending = direction != nil && *direction == model.SortDirectionDescending
+	return order
+}
+
+// cursor is the keyset of an edge: the value of the sort key and the id of its node. The handler only
+// knows skip/take, so it carries the offset the edge was at as well. The node is looked up by its id around
+// that offset, rows added or removed in front of it do not shift the following page. This only addresses
+// page shifts: deep pages still cost skip/take on the database until the handler gets keyset lookups.
+type cursor struct {
+	SortKey string          `json:"k"`
+	Value   json.RawMessage `json:"v,omitempty"`
+	ID      string          `json:"id"`
+	Offset  int             `json:"o"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (cursor, error) {
+	var c cursor
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, errors.Errorf("Invalid pagination arguments: malformed cursor %q", encoded)
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" || c.Offset < 0 {
+		return c, errors.Errorf("Invalid pagination arguments: malformed cursor %q", encoded)
+	}
+	return c, nil
+}
+
+// cursorOf reads the keyset of the node at offset from its json fields, which are named like the sort keys
+func cursorOf(node any, order listOrder, offset int) (cursor, error) {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return cursor{}, errors.Wrap(err, "cannot marshal node for its cursor")
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return cursor{}, errors.Wrap(err, "cannot unmarshal node for its cursor")
+	}
+	c := cursor{SortKey: order.sortKey, Value: fields[order.sortKey], Offset: offset}
+	if err := json.Unmarshal(fields["id"], &c.ID); err != nil {
+		return cursor{}, errors.Wrap(err, "cannot read id of node for its cursor")
+	}
+	return c, nil
+}
+
+// compare orders two keysets like the handler sorts the list, null values last in both directions
+func (o listOrder) compare(a cursor, b cursor) int {
+	var valueA, valueB any
+	_ = json.Unmarshal(a.Value, &valueA)
+	_ = json.Unmarshal(b.Value, &valueB)
+	c := 0
+	switch {
+	case valueA == nil && valueB == nil:
+	case valueA == nil:
+		return 1
+	case valueB == nil:
+		return -1
+	default:
+		c = compareJSON(valueA, valueB, a.Value, b.Value)
+	}
+	if c == 0 {
+		c = cmp.Compare(a.ID, b.ID)
+	}
+	if o.descending {
+		return -c
+	}
+	return c
+}
+
+func compareJSON(a any, b any, rawA json.RawMessage, rawB json.RawMessage) int {
+	switch valueA := a.(type) {
+	case float64:
+		if valueB, ok := b.(float64); ok {
+			return cmp.Compare(valueA, valueB)
+		}
+	case string:
+		if valueB, ok := b.(string); ok {
+			return cmp.Compare(valueA, valueB)
+		}
+	}
+	return bytes.Compare(rawA, rawB)
+}
+
+// locateCursor returns the position of the edge of the cursor in the list. If its node is gone, it is the
+// position of the last node in front of its keyset.
+func locateCursor[T any](c cursor, order listOrder, fetch func(skip int, take int) ([]T, int, error)) (int, error) {
+	skip := max(0, c.Offset-cursorWindow)
+	items, _, err := fetch(skip, 2*cursorWindow+1)
+	if err != nil {
+		return 0, err
+	}
+	keysets := make([]cursor, 0, len(items))
+	for i, item := range items {
+		keyset, err := cursorOf(item, order, skip+i)
+		if err != nil {
+			return 0, err
+		}
+		if keyset.ID == c.ID {
+			return skip + i, nil
+		}
+		keysets = append(keysets, keyset)
+	}
+	if c.SortKey != order.sortKey {
+		return 0, errors.Errorf("Invalid pagination arguments: cursor of node %s is not sorted by %s", c.ID, order.sortKey)
+	}
+	for i, keyset := range keysets {
+		if order.compare(keyset, c) > 0 {
+			return skip + i - 1, nil
+		}
+	}
+	return skip + len(items) - 1, nil
+}
+
+// paginate translates the relay arguments into a skip/take window, fetches it and wraps its items into edges.
+// fetch returns the items of the window and the total amount of items of the list.
+// It returns the edges, the page info and the total amount of items.
+func paginate[T any, E any](args connectionArgs, order listOrder, fetch func(skip int, take int) ([]T, int, error), newEdge func(cursor string, node T) E) ([]E, *model.PageInfo, int, error) {
+	if args.first != nil && *args.first < 0 {
+		return nil, nil, 0, errors.New("Invalid pagination arguments: first must not be negative")
+	}
+	if args.last != nil && *args.last < 0 {
+		return nil, nil, 0, errors.New("Invalid pagination arguments: last must not be negative")
+	}
+	start := 0
+	end := -1
+	if args.after != nil {
+		after, err := decodeCursor(*args.after)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		position, err := locateCursor(after, order, fetch)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		start = position + 1
+	}
+	if args.before != nil {
+		before, err := decodeCursor(*args.before)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		position, err := locateCursor(before, order, fetch)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		end = max(position, start)
+	}
+	if args.first == nil && args.last == nil {
+		if end < 0 || end-start > defaultConnectionSize {
+			end = start + defaultConnectionSize
+		}
+	}
+	if args.first != nil && (end < 0 || start+*args.first < end) {
+		end = start + *args.first
+	}
+	total := -1
+	if args.last != nil {
+		if end < 0 {
+			// counting backwards from the end of the list requires its length
+			_, count, err := fetch(start, 1)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			total = count
+			end = max(total, start)
+		}
+		if end-*args.last > start {
+			start = end - *args.last
+		}
+	}
+	if end-start > maxConnectionSize {
+		return nil, nil, 0, errors.Errorf("You could not retrieve more than %d items", maxConnectionSize)
+	}
+	items := make([]T, 0)
+	if end == start {
+		if total < 0 {
+			_, count, err := fetch(start, 1)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			total = count
+		}
+	} else {
+		var err error
+		items, total, err = fetch(start, end-start)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	edges := make([]E, 0, len(items))
+	cursors := make([]string, 0, len(items))
+	for i, item := range items {
+		keyset, err := cursorOf(item, order, start+i)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		cursors = append(cursors, encodeCursor(keyset))
+		edges = append(edges, newEdge(cursors[i], item))
+	}
+	pageInfo := &model.PageInfo{
+		HasPreviousPage: start > 0,
+		HasNextPage:     start+len(items) < total,
+	}
+	if len(cursors) > 0 {
+		pageInfo.StartCursor = &cursors[0]
+		pageInfo.EndCursor = &cursors[len(cursors)-1]
+	}
+	return edges, pageInfo, total, nil
+}
+
+func GetTenantsConnection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.TenantListOptions, first *int, after *string, last *int, before *string, allowedTenants []string) (*model.TenantConnection, error) {
+	window := model.TenantListOptions{}
+	if options != nil {
+		window = *options
+	}
+	edges, pageInfo, total, err := paginate(connectionArgs{first: first, after: after, last: last, before: before}, newListOrder(window.SortKey, window.SortDirection), func(skip int, take int) ([]*model.Tenant, int, error) {
+		window.Skip, window.Take = &skip, &take
+		tenantList, err := GetTenants(ctx, clientClerkHandler, &window, allowedTenants)
+		if err != nil {
+			return nil, 0, err
+		}
+		return tenantList.Items, tenantList.TotalItems, nil
+	}, func(cursor string, tenant *model.Tenant) *model.TenantEdge {
+		return &model.TenantEdge{Cursor: cursor, Node: tenant}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.TenantConnection{Edges: edges, PageInfo: pageInfo, TotalCount: total}, nil
+}
+
+func GetCollectionsConnection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.CollectionListOptions, first *int, after *string, last *int, before *string, allowedTenants []string) (*model.CollectionConnection, error) {
+	window := model.CollectionListOptions{}
+	if options != nil {
+		window = *options
+	}
+	edges, pageInfo, total, err := paginate(connectionArgs{first: first, after: after, last: last, before: before}, newListOrder(window.SortKey, window.SortDirection), func(skip int, take int) ([]*model.Collection, int, error) {
+		window.Skip, window.Take = &skip, &take
+		collectionList, err := GetCollectionsForTenantId(ctx, clientClerkHandler, &window, allowedTenants)
+		if err != nil {
+			return nil, 0, err
+		}
+		return collectionList.Items, collectionList.TotalItems, nil
+	}, func(cursor string, collection *model.Collection) *model.CollectionEdge {
+		return &model.CollectionEdge{Cursor: cursor, Node: collection}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.CollectionConnection{Edges: edges, PageInfo: pageInfo, TotalCount: total}, nil
+}
+
+func GetObjectsConnection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.ObjectListOptions, first *int, after *string, last *int, before *string, allowedTenants []string, logger zLogger.ZLogger) (*model.ObjectConnection, error) {
+	window := model.ObjectListOptions{}
+	if options != nil {
+		window = *options
+	}
+	edges, pageInfo, total, err := paginate(connectionArgs{first: first, after: after, last: last, before: before}, newListOrder(window.SortKey, window.SortDirection), func(skip int, take int) ([]*model.Object, int, error) {
+		window.Skip, window.Take = &skip, &take
+		objectList, err := GetObjectsForCollectionId(ctx, clientClerkHandler, &window, allowedTenants, logger)
+		if err != nil {
+			return nil, 0, err
+		}
+		return objectList.Items, objectList.TotalItems, nil
+	}, func(cursor string, object *model.Object) *model.ObjectEdge {
+		return &model.ObjectEdge{Cursor: cursor, Node: object}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.ObjectConnection{Edges: edges, PageInfo: pageInfo, TotalCount: total}, nil
+}
+
+func GetObjectInstancesConnection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.ObjectInstanceListOptions, first *int, after *string, last *int, before *string, allowedTenants []string) (*model.ObjectInstanceConnection, error) {
+	window := model.ObjectInstanceListOptions{}
+	if options != nil {
+		window = *options
+	}
+	edges, pageInfo, total, err := paginate(connectionArgs{first: first, after: after, last: last, before: before}, newListOrder(window.SortKey, window.SortDirection), func(skip int, take int) ([]*model.ObjectInstance, int, error) {
+		window.Skip, window.Take = &skip, &take
+		objectInstanceList, err := GetObjectInstancesForObjectId(ctx, clientClerkHandler, &window, allowedTenants)
+		if err != nil {
+			return nil, 0, err
+		}
+		return objectInstanceList.Items, objectInstanceList.TotalItems, nil
+	}, func(cursor string, objectInstance *model.ObjectInstance) *model.ObjectInstanceEdge {
+		return &model.ObjectInstanceEdge{Cursor: cursor, Node: objectInstance}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.ObjectInstanceConnection{Edges: edges, PageInfo: pageInfo, TotalCount: total}, nil
+}
+
+func GetObjectInstanceChecksConnection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.ObjectInstanceCheckListOptions, first *int, after *string, last *int, before *string, allowedTenants []string) (*model.ObjectInstanceCheckConnection, error) {
+	window := model.ObjectInstanceCheckListOptions{}
+	if options != nil {
+		window = *options
+	}
+	edges, pageInfo, total, err := paginate(connectionArgs{first: first, after: after, last: last, before: before}, newListOrder(window.SortKey, window.SortDirection), func(skip int, take int) ([]*model.ObjectInstanceCheck, int, error) {
+		window.Skip, window.Take = &skip, &take
+		objectInstanceCheckList, err := GetObjectInstanceChecksForObjectInstanceId(ctx, clientClerkHandler, &window, allowedTenants)
+		if err != nil {
+			return nil, 0, err
+		}
+		return objectInstanceCheckList.Items, objectInstanceCheckList.TotalItems, nil
+	}, func(cursor string, objectInstanceCheck *model.ObjectInstanceCheck) *model.ObjectInstanceCheckEdge {
+		return &model.ObjectInstanceCheckEdge{Cursor: cursor, Node: objectInstanceCheck}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.ObjectInstanceCheckConnection{Edges: edges, PageInfo: pageInfo, TotalCount: total}, nil
+}
+
+func GetFilesConnection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.FileListOptions, first *int, after *string, last *int, before *string, allowedTenants []string) (*model.FileConnection, error) {
+	window := model.FileListOptions{}
+	if options != nil {
+		window = *options
+	}
+	edges, pageInfo, total, err := paginate(connectionArgs{first: first, after: after, last: last, before: before}, newListOrder(window.SortKey, window.SortDirection), func(skip int, take int) ([]*model.File, int, error) {
+		window.Skip, window.Take = &skip, &take
+		fileList, err := GetFilesForObjectId(ctx, clientClerkHandler, &window, allowedTenants)
+		if err != nil {
+			return nil, 0, err
+		}
+		return fileList.Items, fileList.TotalItems, nil
+	}, func(cursor string, file *model.File) *model.FileEdge {
+		return &model.FileEdge{Cursor: cursor, Node: file}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model.FileConnection{Edges: edges, PageInfo: pageInfo, TotalCount: total}, nil
+}
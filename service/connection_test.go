@@ -0,0 +1,38 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	valid := cursor{SortKey: "alias", Value: json.RawMessage(`"archive"`), ID: "42", Offset: 3}
+	tests := []struct {
+		name    string
+		encoded string
+		want    cursor
+		wantErr bool
+	}{
+		{"round trip", encodeCursor(valid), valid, false},
+		{"null value", encodeCursor(cursor{SortKey: "alias", ID: "42"}), cursor{SortKey: "alias", ID: "42"}, false},
+		{"no base64", "%%%", cursor{}, true},
+		{"no json", base64.StdEncoding.EncodeToString([]byte("cursor")), cursor{}, true},
+		{"no id", base64.StdEncoding.EncodeToString([]byte(`{"k":"id","o":1}`)), cursor{}, true},
+		{"negative offset", base64.StdEncoding.EncodeToString([]byte(`{"k":"id","id":"42","o":-1}`)), cursor{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.encoded)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeCursor error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.SortKey != tt.want.SortKey || got.ID != tt.want.ID || got.Offset != tt.want.Offset || string(got.Value) != string(tt.want.Value) {
+				t.Errorf("decodeCursor = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
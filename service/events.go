@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/events"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+const DefaultEventInterval = 10 * time.Second
+
+// EventWatcher polls the handler for the changes somebody subscribed to and publishes them to the event source:
+// the status of archiving jobs, new check errors of collections and the fill levels of partitions.
+// A topic is polled once, however many subscriptions watch it, and only as long as one does.
+type EventWatcher struct {
+	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+	Source             events.Source
+	Interval           time.Duration
+	Logger             zLogger.ZLogger
+
+	lock    sync.Mutex
+	watches map[string]*watch
+}
+
+type watch struct {
+	subscribers int
+	cancel      context.CancelFunc
+}
+
+func NewEventWatcher(clientClerkHandler pbHandler.ClerkHandlerServiceClient, source events.Source, interval time.Duration, logger zLogger.ZLogger) *EventWatcher {
+	if interval <= 0 {
+		interval = DefaultEventInterval
+	}
+	return &EventWatcher{
+		ClientClerkHandler: clientClerkHandler,
+		Source:             source,
+		Interval:           interval,
+		Logger:             logger,
+		watches:            map[string]*watch{},
+	}
+}
+
+// WatchArchivingStatus publishes every change of the status of the archiving job until ctx is done
+func (w *EventWatcher) WatchArchivingStatus(ctx context.Context, id string) {
+	var last *model.ArchivingStatus
+	w.watch(ctx, events.ArchivingStatusTopic(id), func(ctx context.Context) error {
+		status, err := GetArchivingStatus(ctx, w.ClientClerkHandler, id)
+		if err != nil {
+			return err
+		}
+		if last != nil && *last != *status {
+			if err := w.Source.Publish(ctx, events.Event{Topic: events.ArchivingStatusTopic(id), Payload: status}); err != nil {
+				return err
+			}
+		}
+		last = status
+		return nil
+	})
+}
+
+// WatchCheckErrors publishes the increases of the amount of check errors of the collection until ctx is done
+func (w *EventWatcher) WatchCheckErrors(ctx context.Context, collectionId string) {
+	last := -1
+	w.watch(ctx, events.CheckErrorsTopic(collectionId), func(ctx context.Context) error {
+		amountOfErrors, err := w.ClientClerkHandler.GetAmountOfErrorsByCollectionId(ctx, &pb.Id{Id: collectionId})
+		if err != nil {
+			return errors.Wrapf(err, "Could not GetAmountOfErrorsByCollectionId: %v", err)
+		}
+		current := int(amountOfErrors.Size)
+		if last >= 0 && current > last {
+			collection, err := GetCollectionById(ctx, w.ClientClerkHandler, collectionId)
+			if err != nil {
+				return err
+			}
+			event := &model.CheckErrorEvent{
+				CollectionID:   collectionId,
+				Collection:     collection,
+				AmountOfErrors: current,
+				NewErrors:      current - last,
+			}
+			if err := w.Source.Publish(ctx, events.Event{Topic: events.CheckErrorsTopic(collectionId), Payload: event}); err != nil {
+				return err
+			}
+		}
+		last = current
+		return nil
+	})
+}
+
+// WatchFillLevels publishes the partitions of the storage location whose size or limits changed until ctx is done
+func (w *EventWatcher) WatchFillLevels(ctx context.Context, storageLocationId string) {
+	var last map[string]fillLevel
+	w.watch(ctx, events.FillLevelTopic(storageLocationId), func(ctx context.Context) error {
+		storagePartitionsPb, err := getAllStoragePartitionsForLocation(ctx, w.ClientClerkHandler, storageLocationId)
+		if err != nil {
+			return err
+		}
+		current := map[string]fillLevel{}
+		for _, storagePartitionPb := range storagePartitionsPb {
+			level := fillLevel{
+				currentSize:    storagePartitionPb.CurrentSize,
+				currentObjects: storagePartitionPb.CurrentObjects,
+				maxSize:        storagePartitionPb.MaxSize,
+				maxObjects:     storagePartitionPb.MaxObjects,
+			}
+			if previous, ok := last[storagePartitionPb.Id]; last != nil && (!ok || previous != level) {
+				event := events.Event{Topic: events.FillLevelTopic(storageLocationId), Payload: storagePartitionToGraphQlStoragePartition(storagePartitionPb)}
+				if err := w.Source.Publish(ctx, event); err != nil {
+					return err
+				}
+			}
+			current[storagePartitionPb.Id] = level
+		}
+		last = current
+		return nil
+	})
+}
+
+type fillLevel struct {
+	currentSize    int64
+	currentObjects int64
+	maxSize        int64
+	maxObjects     int64
+}
+
+// watch polls topic until the contexts of all its subscriptions are done. The first poll only records the
+// current state, changes are published from the second one on.
+func (w *EventWatcher) watch(ctx context.Context, topic string, poll func(ctx context.Context) error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	current, ok := w.watches[topic]
+	if !ok {
+		pollCtx, cancel := context.WithCancel(context.Background())
+		current = &watch{cancel: cancel}
+		w.watches[topic] = current
+		go w.run(pollCtx, topic, poll)
+	}
+	current.subscribers++
+	go func() {
+		<-ctx.Done()
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		current.subscribers--
+		if current.subscribers == 0 {
+			current.cancel()
+			delete(w.watches, topic)
+		}
+	}()
+}
+
+func (w *EventWatcher) run(ctx context.Context, topic string, poll func(ctx context.Context) error) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		if err := poll(ctx); err != nil && ctx.Err() == nil {
+			w.Logger.Warn().Msgf("cannot poll %s: %v", topic, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetCollectionIdsToWatch returns the collection or, without one, all collections of the tenant
+func GetCollectionIdsToWatch(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, tenantId *string, collectionId *string) ([]string, error) {
+	if collectionId != nil && *collectionId != "" {
+		return []string{*collectionId}, nil
+	}
+	if tenantId == nil || *tenantId == "" {
+		return nil, errors.New("tenantId or collectionId is needed")
+	}
+	collectionsPb, err := clientClerkHandler.GetCollectionsByTenantId(ctx, &pb.Id{Id: *tenantId})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetCollectionsByTenantId: %v", err)
+	}
+	collectionIds := make([]string, 0, len(collectionsPb.Collections))
+	for _, collectionPb := range collectionsPb.Collections {
+		collectionIds = append(collectionIds, collectionPb.Id)
+	}
+	return collectionIds, nil
+}
+
+func GetArchivingStatus(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, id string) (*model.ArchivingStatus, error) {
+	statusPb, err := clientClerkHandler.CheckStatus(ctx, &pb.Id{Id: id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not CheckStatus: %v", err)
+	}
+	return &model.ArchivingStatus{ID: statusPb.Id, Status: statusPb.Status, LastChanged: statusPb.LastChanged}, nil
+}
+
+// PublishArchivingStatus publishes the current status of the archiving job right after it was changed
+func PublishArchivingStatus(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, source events.Source, id string) error {
+	status, err := GetArchivingStatus(ctx, clientClerkHandler, id)
+	if err != nil {
+		return err
+	}
+	return source.Publish(ctx, events.Event{Topic: events.ArchivingStatusTopic(id), Payload: status})
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/dataloader"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+// overdueObjectInstancePrefix keeps the node id apart from the one of the object instance
+const overdueObjectInstancePrefix = "overdue:"
+
+const DefaultFixityInterval = time.Hour
+
+// overdueInstance is an object instance whose last successful check is older than its policy allows
+type overdueInstance struct {
+	objectInstancePb  *pb.ObjectInstance
+	tenantId          string
+	collectionId      string
+	storageLocationId string
+	lastSuccess       time.Time
+	maxAge            time.Duration
+	// the instance is overdue since then
+	due time.Time
+}
+
+// fixityMaxAge is the maximum age of the last successful check of an instance of the collection on the
+// storage location, 0 if it is exempt
+func fixityMaxAge(conf models.FixityConfig, collectionId string, storageLocationId string) time.Duration {
+	collectionMaxAge, forCollection := conf.Collections[collectionId]
+	storageLocationMaxAge, forStorageLocation := conf.StorageLocations[storageLocationId]
+	switch {
+	case forCollection && forStorageLocation:
+		return min(collectionMaxAge, storageLocationMaxAge)
+	case forCollection:
+		return collectionMaxAge
+	case forStorageLocation:
+		return storageLocationMaxAge
+	}
+	return conf.MaxAge
+}
+
+// lastSuccessfulCheck returns the time of the newest check of the object instance without error, zero if
+// there is none. It is read once per request, the list and the counts of a query share it.
+func lastSuccessfulCheck(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, objectInstanceId string) (time.Time, error) {
+	checkPb, err := dataloader.For(ctx, clientClerkHandler).ObjectInstanceLastSuccess.Load(ctx, objectInstanceId)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "Could not GetObjectInstanceChecksByObjectInstanceIdPaginated: %v", err)
+	}
+	if checkPb == nil {
+		return time.Time{}, nil
+	}
+	checked, _ := parseHandlerTime(checkPb.CheckTime)
+	return checked, nil
+}
+
+// scanOverdueInstances visits the object instances of the collections which are overdue at now. An instance
+// never checked successfully is overdue once it is older than its maximum age.
+func scanOverdueInstances(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, conf models.FixityConfig, collectionsPb []*pb.Collection, now time.Time, visit func(overdue overdueInstance)) error {
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	return scanObjectInstances(ctx, clientClerkHandler, collectionsPb, func(collectionPb *pb.Collection, objectPb *pb.Object, objectInstancesPb []*pb.ObjectInstance) error {
+		for _, objectInstancePb := range objectInstancesPb {
+			storagePartitionPb, err := loaders.StoragePartition.Load(ctx, objectInstancePb.StoragePartitionId)
+			if err != nil {
+				return errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
+			}
+			maxAge := fixityMaxAge(conf, collectionPb.Id, storagePartitionPb.StorageLocationId)
+			if maxAge <= 0 {
+				continue
+			}
+			lastSuccess, err := lastSuccessfulCheck(ctx, clientClerkHandler, objectInstancePb.Id)
+			if err != nil {
+				return err
+			}
+			since := lastSuccess
+			if since.IsZero() {
+				created, ok := parseHandlerTime(objectInstancePb.Created)
+				if !ok {
+					// never checked and of unknown age, it is due now
+					created = now.Add(-maxAge)
+				}
+				since = created
+			}
+			due := since.Add(maxAge)
+			if due.After(now) {
+				continue
+			}
+			visit(overdueInstance{
+				objectInstancePb:  objectInstancePb,
+				tenantId:          collectionPb.TenantId,
+				collectionId:      collectionPb.Id,
+				storageLocationId: storagePartitionPb.StorageLocationId,
+				lastSuccess:       lastSuccess,
+				maxAge:            maxAge,
+				due:               due,
+			})
+		}
+		return nil
+	})
+}
+
+// FixityScanner finds the overdue object instances of all tenants every interval. The handler has no query for
+// them, so only the scan reads the newest check of every instance, the lists and counts of the queries are read
+// from its last result.
+type FixityScanner struct {
+	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+	Config             models.FixityConfig
+	Interval           time.Duration
+	Logger             zLogger.ZLogger
+	lock               sync.RWMutex
+	// tenant id -> overdue instances, nil until the first scan is done
+	tenants map[string][]overdueInstance
+}
+
+func NewFixityScanner(clientClerkHandler pbHandler.ClerkHandlerServiceClient, conf models.FixityConfig, logger zLogger.ZLogger) *FixityScanner {
+	interval := conf.Interval
+	if interval <= 0 {
+		interval = DefaultFixityInterval
+	}
+	return &FixityScanner{
+		ClientClerkHandler: clientClerkHandler,
+		Config:             conf,
+		Interval:           interval,
+		Logger:             logger,
+	}
+}
+
+// Start scans every interval until ctx is done
+func (s *FixityScanner) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Scan(ctx); err != nil && ctx.Err() == nil {
+				s.Logger.Warn().Msgf("cannot find overdue object instances: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Scan finds the overdue instances of all tenants. A failing tenant keeps the ones of the scan before, the
+// errors of the tenants are returned together.
+func (s *FixityScanner) Scan(ctx context.Context) error {
+	tenantsPb, err := s.ClientClerkHandler.FindAllTenants(ctx, &pb.NoParam{})
+	if err != nil {
+		return errors.Wrapf(err, "Could not FindAllTenants: %v", err)
+	}
+	now := time.Now()
+	tenants := make(map[string][]overdueInstance, len(tenantsPb.Tenants))
+	var result error
+	for _, tenantPb := range tenantsPb.Tenants {
+		// the loaders cache the instances and checks of one tenant only
+		tenantCtx := dataloader.WithLoaders(ctx, dataloader.NewLoaders(s.ClientClerkHandler))
+		overdues := make([]overdueInstance, 0)
+		collectionsPb, err := s.ClientClerkHandler.GetCollectionsByTenantId(tenantCtx, &pb.Id{Id: tenantPb.Id})
+		if err == nil {
+			err = scanOverdueInstances(tenantCtx, s.ClientClerkHandler, s.Config, collectionsPb.Collections, now, func(overdue overdueInstance) {
+				overdues = append(overdues, overdue)
+			})
+		}
+		if err != nil {
+			result = errors.Append(result, errors.Wrapf(err, "cannot find overdue object instances of tenant %s", tenantPb.Alias))
+			s.lock.RLock()
+			overdues = s.tenants[tenantPb.Id]
+			s.lock.RUnlock()
+		}
+		tenants[tenantPb.Id] = overdues
+	}
+	s.lock.Lock()
+	s.tenants = tenants
+	s.lock.Unlock()
+	return result
+}
+
+// overdue returns the overdue instances of the last scan matching the tenant, collection and storage location
+// which are set
+func (s *FixityScanner) overdue(tenantId *string, collectionId *string, storageLocationId *string) ([]overdueInstance, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.tenants == nil {
+		return nil, errors.New("The overdue object instances are not computed yet, retry later")
+	}
+	result := make([]overdueInstance, 0)
+	for id, overdues := range s.tenants {
+		if firstString(tenantId) != "" && id != *tenantId {
+			continue
+		}
+		for _, overdue := range overdues {
+			if firstString(collectionId) != "" && overdue.collectionId != *collectionId {
+				continue
+			}
+			if firstString(storageLocationId) != "" && overdue.storageLocationId != *storageLocationId {
+				continue
+			}
+			result = append(result, overdue)
+		}
+	}
+	return result, nil
+}
+
+// GetOverdueObjectInstances lists the object instances not checked successfully within the maximum age of the
+// fixity policy as of the last scan, the longest overdue first
+func GetOverdueObjectInstances(scanner *FixityScanner, tenantId *string, collectionId *string, storageLocationId *string, skip *int, take *int) (*model.OverdueObjectInstanceList, error) {
+	offset, limit := 0, 100
+	if skip != nil {
+		offset = *skip
+	}
+	if take != nil {
+		if *take > 1000 {
+			return nil, errors.New("You could not retrieve more than 1000 object instances")
+		}
+		limit = *take
+	}
+	if offset < 0 || limit < 0 {
+		return nil, errors.New("Invalid pagination arguments: skip and take must not be negative")
+	}
+	if firstString(tenantId, collectionId, storageLocationId) == "" {
+		return nil, errors.New("Invalid pagination arguments: tenantId, collectionId or storageLocationId is needed")
+	}
+	overdues, err := scanner.overdue(tenantId, collectionId, storageLocationId)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(overdues, func(a, b overdueInstance) int {
+		if c := a.due.Compare(b.due); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.objectInstancePb.Id, b.objectInstancePb.Id)
+	})
+	list := &model.OverdueObjectInstanceList{Items: make([]*model.OverdueObjectInstance, 0), TotalItems: len(overdues)}
+	if offset >= len(overdues) {
+		return list, nil
+	}
+	now := time.Now()
+	for _, overdue := range overdues[offset:min(len(overdues), offset+limit)] {
+		item := &model.OverdueObjectInstance{
+			ID:                overdueObjectInstancePrefix + overdue.objectInstancePb.Id,
+			ObjectInstance:    objectInstanceToGraphQlObjectInstance(overdue.objectInstancePb),
+			CollectionID:      overdue.collectionId,
+			StorageLocationID: overdue.storageLocationId,
+			MaxAgeDays:        overdue.maxAge.Hours() / 24,
+			OverdueDays:       now.Sub(overdue.due).Hours() / 24,
+		}
+		if !overdue.lastSuccess.IsZero() {
+			lastSuccess := overdue.lastSuccess.Format(time.RFC3339)
+			item.LastSuccessfulCheck = &lastSuccess
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list, nil
+}
+
+// CountOverdueObjectInstances counts the overdue object instances of the collection or the storage location as of
+// the last scan
+func CountOverdueObjectInstances(scanner *FixityScanner, collectionId *string, storageLocationId *string) (int, error) {
+	overdues, err := scanner.overdue(nil, collectionId, storageLocationId)
+	if err != nil {
+		return 0, err
+	}
+	return len(overdues), nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+func TestFixityMaxAge(t *testing.T) {
+	conf := models.FixityConfig{
+		MaxAge:           100 * time.Hour,
+		Collections:      map[string]time.Duration{"collection-1": 10 * time.Hour, "collection-exempt": 0},
+		StorageLocations: map[string]time.Duration{"location-1": 20 * time.Hour, "location-2": 5 * time.Hour},
+	}
+	tests := []struct {
+		name              string
+		collectionId      string
+		storageLocationId string
+		maxAge            time.Duration
+	}{
+		{"default", "collection-2", "location-3", 100 * time.Hour},
+		{"collection", "collection-1", "location-3", 10 * time.Hour},
+		{"storage location", "collection-2", "location-1", 20 * time.Hour},
+		{"smaller of collection", "collection-1", "location-1", 10 * time.Hour},
+		{"smaller of storage location", "collection-1", "location-2", 5 * time.Hour},
+		{"exempt collection", "collection-exempt", "location-1", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if maxAge := fixityMaxAge(conf, tt.collectionId, tt.storageLocationId); maxAge != tt.maxAge {
+				t.Errorf("fixityMaxAge(%s, %s) = %s, want %s", tt.collectionId, tt.storageLocationId, maxAge, tt.maxAge)
+			}
+		})
+	}
+}
+
+func TestOverdueObjectInstances(t *testing.T) {
+	scanner := NewFixityScanner(nil, models.FixityConfig{}, nil)
+	collectionId := "collection-1"
+	if _, err := CountOverdueObjectInstances(scanner, &collectionId, nil); err == nil || !strings.Contains(err.Error(), "not computed yet") {
+		t.Fatalf("err = %v before the first scan, want not computed yet", err)
+	}
+	now := time.Now()
+	overdue := func(id string, tenantId string, collectionId string, storageLocationId string, due time.Duration) overdueInstance {
+		return overdueInstance{objectInstancePb: &pb.ObjectInstance{Id: id}, tenantId: tenantId, collectionId: collectionId, storageLocationId: storageLocationId, maxAge: time.Hour, due: now.Add(-due)}
+	}
+	scanner.tenants = map[string][]overdueInstance{
+		"tenant-1": {overdue("i1", "tenant-1", "collection-1", "location-1", time.Hour), overdue("i2", "tenant-1", "collection-1", "location-2", 3*time.Hour)},
+		"tenant-2": {overdue("i3", "tenant-2", "collection-2", "location-1", 2*time.Hour)},
+	}
+	storageLocationId := "location-1"
+	tests := []struct {
+		name              string
+		collectionId      *string
+		storageLocationId *string
+		count             int
+	}{
+		{"collection", &collectionId, nil, 2},
+		{"storage location", nil, &storageLocationId, 2},
+		{"collection on storage location", &collectionId, &storageLocationId, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := CountOverdueObjectInstances(scanner, tt.collectionId, tt.storageLocationId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.count {
+				t.Errorf("count = %d, want %d", count, tt.count)
+			}
+		})
+	}
+	tenantId := "tenant-1"
+	list, err := GetOverdueObjectInstances(scanner, &tenantId, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.TotalItems != 2 || list.Items[0].ObjectInstance.ID != "i2" || list.Items[1].ObjectInstance.ID != "i1" {
+		t.Errorf("list = %+v, want i2 and i1, the longest overdue first", list.Items)
+	}
+}
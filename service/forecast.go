@@ -0,0 +1,210 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/forecast"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	"github.com/ocfl-archive/dlza-manager-clerk/validation"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+const (
+	DefaultSampleInterval  = time.Hour
+	DefaultSampleRetention = 90 * 24 * time.Hour
+	DefaultForecastWindow  = 24
+)
+
+// CapacitySampler records the used size of every storage location and partition every interval,
+// the capacity forecasts are projected from these samples. The partitions of a location are only listed
+// again once its size changed, until then their last sizes are sampled again.
+type CapacitySampler struct {
+	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+	History            *forecast.History
+	Interval           time.Duration
+	Logger             zLogger.ZLogger
+	// storage location id -> sizes of its partitions when the location last changed
+	partitions map[string]map[string]int64
+	sizes      map[string]int64
+}
+
+func NewCapacitySampler(clientClerkHandler pbHandler.ClerkHandlerServiceClient, history *forecast.History, interval time.Duration, logger zLogger.ZLogger) *CapacitySampler {
+	if interval <= 0 {
+		interval = DefaultSampleInterval
+	}
+	return &CapacitySampler{
+		ClientClerkHandler: clientClerkHandler,
+		History:            history,
+		Interval:           interval,
+		Logger:             logger,
+		partitions:         map[string]map[string]int64{},
+		sizes:              map[string]int64{},
+	}
+}
+
+// Start samples every interval until ctx is done
+func (s *CapacitySampler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Sample(ctx); err != nil && ctx.Err() == nil {
+				s.Logger.Warn().Msgf("cannot sample capacity: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Sample records the sizes of all tenants. A failing tenant or location does not keep the others from being
+// sampled, the errors are returned together after the history was saved.
+func (s *CapacitySampler) Sample(ctx context.Context) error {
+	now := time.Now()
+	sampled := make(map[string]bool)
+	tenantsPb, err := s.ClientClerkHandler.FindAllTenants(ctx, &pb.NoParam{})
+	if err != nil {
+		return errors.Wrapf(err, "Could not FindAllTenants: %v", err)
+	}
+	var result error
+	for _, tenantPb := range tenantsPb.Tenants {
+		storageLocationsPb, err := s.ClientClerkHandler.GetStorageLocationsByTenantId(ctx, &pb.Id{Id: tenantPb.Id})
+		if err != nil {
+			result = errors.Append(result, errors.Wrapf(err, "Could not GetStorageLocationsByTenantId for tenant %s: %v", tenantPb.Alias, err))
+			continue
+		}
+		for _, storageLocationPb := range storageLocationsPb.StorageLocations {
+			s.History.Add(ctx, forecast.Key(policy.KindStorageLocation, storageLocationPb.Id), forecast.Sample{Time: now, Size: storageLocationPb.TotalFilesSize})
+			partitionSizes, ok := s.partitions[storageLocationPb.Id]
+			if !ok || s.sizes[storageLocationPb.Id] != storageLocationPb.TotalFilesSize {
+				storagePartitionsPb, err := getAllStoragePartitionsForLocation(ctx, s.ClientClerkHandler, storageLocationPb.Id)
+				if err != nil {
+					// the cached sizes are sampled again, if there are any
+					result = errors.Append(result, errors.Wrapf(err, "cannot list partitions of storage location %s", storageLocationPb.Alias))
+					partitionSizes = s.partitions[storageLocationPb.Id]
+				} else {
+					partitionSizes = make(map[string]int64, len(storagePartitionsPb))
+					for _, storagePartitionPb := range storagePartitionsPb {
+						partitionSizes[storagePartitionPb.Id] = storagePartitionPb.CurrentSize
+					}
+					s.partitions[storageLocationPb.Id] = partitionSizes
+					s.sizes[storageLocationPb.Id] = storageLocationPb.TotalFilesSize
+				}
+			}
+			for storagePartitionId, size := range partitionSizes {
+				s.History.Add(ctx, forecast.Key(policy.KindStoragePartition, storagePartitionId), forecast.Sample{Time: now, Size: size})
+			}
+			sampled[storageLocationPb.Id] = true
+		}
+	}
+	for storageLocationId := range s.partitions {
+		if !sampled[storageLocationId] {
+			delete(s.partitions, storageLocationId)
+			delete(s.sizes, storageLocationId)
+		}
+	}
+	// deleted locations and partitions are not sampled anymore
+	s.History.Forget(ctx, now)
+	return errors.Append(result, s.History.Save(ctx))
+}
+
+// GetCapacityForecast projects the samples of the entity, nil until there are two of them
+func GetCapacityForecast(ctx context.Context, history *forecast.History, kind string, id string, used int64, capacity int64, window int) *model.CapacityForecast {
+	if history == nil {
+		return nil
+	}
+	samples := history.Samples(ctx, forecast.Key(kind, id))
+	if len(samples) < 2 {
+		return nil
+	}
+	if window <= 0 {
+		window = DefaultForecastWindow
+	}
+	now := time.Now()
+	result := &model.CapacityForecast{
+		Samples:       len(samples),
+		Since:         samples[0].Time.Format(time.RFC3339),
+		Used:          float64(used),
+		Capacity:      float64(capacity),
+		Linear:        forecastToGraphQlForecastResult(forecast.Linear(samples, used, capacity), now),
+		MovingAverage: forecastToGraphQlForecastResult(forecast.MovingAverage(samples, window, used, capacity), now),
+	}
+	for _, daysUntilFull := range []*float64{result.Linear.DaysUntilFull, result.MovingAverage.DaysUntilFull} {
+		if daysUntilFull != nil && (result.DaysUntilFull == nil || *daysUntilFull < *result.DaysUntilFull) {
+			result.DaysUntilFull = daysUntilFull
+		}
+	}
+	return result
+}
+
+func forecastToGraphQlForecastResult(f forecast.Forecast, now time.Time) *model.ForecastResult {
+	result := &model.ForecastResult{GrowthPerDay: f.GrowthPerDay, DaysUntilFull: f.DaysUntilFull}
+	if f.DaysUntilFull != nil {
+		fullAt := now.Add(time.Duration(*f.DaysUntilFull * 24 * float64(time.Hour))).Format(time.RFC3339)
+		result.FullAt = &fullAt
+	}
+	return result
+}
+
+// StorageForecasts lists the storage locations of the tenant, or of all tenants the caller may read, which are
+// predicted to be full within days
+func StorageForecasts(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, history *forecast.History, conf models.ForecastConfig, days int, tenantId *string) ([]*model.StorageLocation, error) {
+	if err := validation.Validate("forecast arguments", validation.Field("days", days, validation.NonNegative)); err != nil {
+		return nil, err
+	}
+	tenantIds := make([]string, 0)
+	if tenantId != nil && *tenantId != "" {
+		tenantIds = append(tenantIds, *tenantId)
+	} else {
+		subject, err := policy.SubjectFromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tenantsPb, err := clientClerkHandler.FindAllTenants(ctx, &pb.NoParam{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not FindAllTenants: %v", err)
+		}
+		for _, tenantPb := range tenantsPb.Tenants {
+			if subject.Allows(tenantPb.Id, policy.Read) {
+				tenantIds = append(tenantIds, tenantPb.Id)
+			}
+		}
+	}
+	type due struct {
+		storageLocation *model.StorageLocation
+		daysUntilFull   float64
+	}
+	dues := make([]due, 0)
+	for _, id := range tenantIds {
+		storageLocationsPb, err := clientClerkHandler.GetStorageLocationsByTenantId(ctx, &pb.Id{Id: id})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not GetStorageLocationsByTenantId: %v", err)
+		}
+		for _, storageLocationPb := range storageLocationsPb.StorageLocations {
+			capacityForecast := GetCapacityForecast(ctx, history, policy.KindStorageLocation, storageLocationPb.Id, storageLocationPb.TotalFilesSize, storageLocationPb.TotalExistingVolume, conf.Window)
+			if capacityForecast == nil || capacityForecast.DaysUntilFull == nil || *capacityForecast.DaysUntilFull > float64(days) {
+				continue
+			}
+			dues = append(dues, due{storageLocation: storageLocationToGraphQlStorageLocation(storageLocationPb), daysUntilFull: *capacityForecast.DaysUntilFull})
+		}
+	}
+	slices.SortFunc(dues, func(a, b due) int {
+		return cmp.Compare(a.daysUntilFull, b.daysUntilFull)
+	})
+	storageLocations := make([]*model.StorageLocation, 0, len(dues))
+	for _, d := range dues {
+		storageLocations = append(storageLocations, d.storageLocation)
+	}
+	return storageLocations, nil
+}
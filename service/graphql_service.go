@@ -11,11 +11,16 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/audit"
+	"github.com/ocfl-archive/dlza-manager-clerk/dataloader"
 	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
-	"github.com/ocfl-archive/dlza-manager-clerk/middleware"
+	"github.com/ocfl-archive/dlza-manager-clerk/lifecycle"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	"github.com/ocfl-archive/dlza-manager-clerk/validation"
 	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
 	pbStorageHandler "github.com/ocfl-archive/dlza-manager-storage-handler/storagehandlerproto"
 	"slices"
@@ -27,22 +32,13 @@ const (
 )
 
 func GetTenants(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.TenantListOptions, allowedTenants []string) (*model.TenantList, error) {
-
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	optionsPb := pb.Pagination{
 		Take:           10,
@@ -76,22 +72,25 @@ func GetTenants(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerSe
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not FindAllTenants: %v", err)
 	}
-	tenants := make([]*model.Tenant, 0)
+	tenantIds := make([]string, 0, len(tenantsPb.Tenants))
 	for _, tenantPb := range tenantsPb.Tenants {
+		tenantIds = append(tenantIds, tenantPb.Id)
+	}
+	amountsAndSizes, err := dataloader.For(ctx, clientClerkHandler).TenantAmountAndSize.LoadAll(ctx, tenantIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetAmountOfObjectsAndTotalSizeByTenantId: %v", err)
+	}
+	tenants := make([]*model.Tenant, 0)
+	for i, tenantPb := range tenantsPb.Tenants {
 		tenant := tenantToGraphQlTenant(tenantPb)
-		amountAndSize, err := clientClerkHandler.GetAmountOfObjectsAndTotalSizeByTenantId(ctx, &pb.Id{Id: tenant.ID})
-		if err != nil {
-			return nil, errors.Wrapf(err, "Could not GetAmountOfObjectsAndTotalSizeByTenantId: %v", err)
-		}
+		amountAndSize := amountsAndSizes[i]
 		tenant.TotalAmountOfObjects = int(amountAndSize.Amount)
 		tenant.TotalSize = float64(amountAndSize.Size)
 		tenant.Permissions = make([]string, 0)
-		if len(tenantList) > 0 {
-			for _, tenantKL := range tenantList {
-				if tenantKL.Id == tenant.ID {
-					if tenantKL.Update && tenantKL.Delete && tenantKL.Create && tenantKL.Read {
-						tenant.Permissions = append(tenant.Permissions, "collection", "storageLocation", "storagePartition")
-					}
+		for _, tenantKL := range subject.Tenants {
+			if tenantKL.Id == tenant.ID {
+				if tenantKL.Update && tenantKL.Delete && tenantKL.Create && tenantKL.Read {
+					tenant.Permissions = append(tenant.Permissions, "collection", "storageLocation", "storagePartition")
 				}
 			}
 		}
@@ -136,19 +135,24 @@ func GetStorageLocationsForTenant(ctx context.Context, clientClerkHandler pbHand
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetStorageLocationsByTenantOrCollectionIdPaginated: %v", err)
 	}
-	storageLocations := make([]*model.StorageLocation, 0)
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	storageLocationIds := make([]string, 0, len(storageLocationsPb.StorageLocations))
 	for _, storageLocationPb := range storageLocationsPb.StorageLocations {
+		storageLocationIds = append(storageLocationIds, storageLocationPb.Id)
+	}
+	amountsOfObjects, err := loaders.StorageLocationAmountOfObjects.LoadAll(ctx, storageLocationIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetAmountOfObjectsForStorageLocationId: %v", err)
+	}
+	amountsOfErrors, err := loaders.StorageLocationAmountOfErrors.LoadAll(ctx, storageLocationIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetAmountOfErrorsForStorageLocationId: %v", err)
+	}
+	storageLocations := make([]*model.StorageLocation, 0)
+	for i, storageLocationPb := range storageLocationsPb.StorageLocations {
 		storageLocation := storageLocationToGraphQlStorageLocation(storageLocationPb)
-		amountOfObjects, err := clientClerkHandler.GetAmountOfObjectsForStorageLocationId(ctx, &pb.Id{Id: storageLocation.ID})
-		if err != nil {
-			return nil, errors.Wrapf(err, "Could not GetAmountOfObjectsForStorageLocationId: %v", err)
-		}
-		amountOfErrors, err := clientClerkHandler.GetAmountOfErrorsForStorageLocationId(ctx, &pb.Id{Id: storageLocation.ID})
-		if err != nil {
-			return nil, errors.Wrapf(err, "Could not GetAmountOfErrorsForStorageLocationId: %v", err)
-		}
-		storageLocation.AmountOfErrors = int(amountOfErrors.Size)
-		storageLocation.AmountOfObjects = int(amountOfObjects.Size)
+		storageLocation.AmountOfErrors = int(amountsOfErrors[i])
+		storageLocation.AmountOfObjects = int(amountsOfObjects[i])
 		storageLocation.Tenant = obj
 		storageLocations = append(storageLocations, storageLocation)
 	}
@@ -188,14 +192,18 @@ func GetCollectionsForTenant(ctx context.Context, clientClerkHandler pbHandler.C
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetCollectionsByTenantID: %v", err)
 	}
-	collections := make([]*model.Collection, 0)
+	collectionIds := make([]string, 0, len(collectionsPb.Collections))
 	for _, collectionPb := range collectionsPb.Collections {
+		collectionIds = append(collectionIds, collectionPb.Id)
+	}
+	amountsOfErrors, err := dataloader.For(ctx, clientClerkHandler).CollectionAmountOfErrors.LoadAll(ctx, collectionIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetAmountOfErrorsByCollectionId: %v", err)
+	}
+	collections := make([]*model.Collection, 0)
+	for i, collectionPb := range collectionsPb.Collections {
 		collection := collectionToGraphQlCollection(collectionPb)
-		amountOfErrors, err := clientClerkHandler.GetAmountOfErrorsByCollectionId(ctx, &pb.Id{Id: collection.ID})
-		if err != nil {
-			return nil, errors.Wrapf(err, "Could not GetAmountOfErrorsByCollectionId: %v", err)
-		}
-		collection.AmountOfErrors = int(amountOfErrors.Size)
+		collection.AmountOfErrors = int(amountsOfErrors[i])
 		collection.Tenant = obj
 		collections = append(collections, collection)
 	}
@@ -203,20 +211,13 @@ func GetCollectionsForTenant(ctx context.Context, clientClerkHandler pbHandler.C
 }
 
 func GetCollectionsForTenantId(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.CollectionListOptions, allowedTenants []string) (*model.CollectionList, error) {
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	optionsPb := pb.Pagination{
 		Take:           10,
@@ -254,22 +255,29 @@ func GetCollectionsForTenantId(ctx context.Context, clientClerkHandler pbHandler
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetCollectionsByTenantID: %v", err)
 	}
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	collectionIds := make([]string, 0, len(collectionsPb.Collections))
+	tenantIds := make([]string, 0, len(collectionsPb.Collections))
+	for _, collectionPb := range collectionsPb.Collections {
+		collectionIds = append(collectionIds, collectionPb.Id)
+		tenantIds = append(tenantIds, collectionPb.TenantId)
+	}
+	amountsOfErrors, err := loaders.CollectionAmountOfErrors.LoadAll(ctx, collectionIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetAmountOfErrorsByCollectionId: %v", err)
+	}
+	tenantsPb, err := loaders.Tenant.LoadAll(ctx, tenantIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not FindTenantById: %v", err)
+	}
 	tenantsMap := make(map[string]*model.Tenant)
 	collections := make([]*model.Collection, 0)
-	for _, collectionPb := range collectionsPb.Collections {
+	for i, collectionPb := range collectionsPb.Collections {
 		collection := collectionToGraphQlCollection(collectionPb)
-		amountOfErrors, err := clientClerkHandler.GetAmountOfErrorsByCollectionId(ctx, &pb.Id{Id: collection.ID})
-		if err != nil {
-			return nil, errors.Wrapf(err, "Could not GetAmountOfErrorsByCollectionId: %v", err)
-		}
 		if tenantsMap[collection.TenantID] == nil {
-			tenantPb, err := clientClerkHandler.FindTenantById(ctx, &pb.Id{Id: collection.TenantID})
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not FindTenantById: %v", err)
-			}
-			tenantsMap[collection.TenantID] = tenantToGraphQlTenant(tenantPb)
+			tenantsMap[collection.TenantID] = tenantToGraphQlTenant(tenantsPb[i])
 		}
-		collection.AmountOfErrors = int(amountOfErrors.Size)
+		collection.AmountOfErrors = int(amountsOfErrors[i])
 		collection.Tenant = tenantsMap[collection.TenantID]
 		collections = append(collections, collection)
 	}
@@ -314,14 +322,18 @@ func GetObjectsForCollection(ctx context.Context, clientClerkHandler pbHandler.C
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetObjectsByCollectionIdPaginated: %v", err)
 	}
-	objects := make([]*model.Object, 0)
+	objectIds := make([]string, 0, len(objectsPb.Objects))
 	for _, objectPb := range objectsPb.Objects {
+		objectIds = append(objectIds, objectPb.Id)
+	}
+	statuses, err := dataloader.For(ctx, clientClerkHandler).ObjectStatus.LoadAll(ctx, objectIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetStatusForObjectId: %v", err)
+	}
+	objects := make([]*model.Object, 0)
+	for i, objectPb := range objectsPb.Objects {
 		object := objectToGraphQlObject(objectPb)
-		status, err := clientClerkHandler.GetStatusForObjectId(ctx, &pb.Id{Id: object.ID})
-		if err != nil {
-			return nil, errors.Wrapf(err, "Could not GetStatusForObjectId: %v", err)
-		}
-		object.Status = int(status.Size)
+		object.Status = int(statuses[i])
 		object.Collection = obj
 		objects = append(objects, object)
 	}
@@ -365,16 +377,20 @@ func GetFilesForCollection(ctx context.Context, clientClerkHandler pbHandler.Cle
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetFilesByCollectionIdPaginated: %v", err)
 	}
+	objectIds := make([]string, 0, len(filesPb.Files))
+	for _, filePb := range filesPb.Files {
+		objectIds = append(objectIds, filePb.ObjectId)
+	}
+	objectsPb, err := dataloader.For(ctx, clientClerkHandler).Object.LoadAll(ctx, objectIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetObjectById: %v", err)
+	}
 	objectsMap := make(map[string]*model.Object)
 	files := make([]*model.File, 0)
-	for _, filePb := range filesPb.Files {
+	for i, filePb := range filesPb.Files {
 		file := fileToGraphQlFile(filePb)
 		if objectsMap[file.ObjectID] == nil {
-			objectPb, err := clientClerkHandler.GetObjectById(ctx, &pb.Id{Id: file.ObjectID})
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not GetObjectById: %v", err)
-			}
-			objectsMap[file.ObjectID] = objectToGraphQlObject(objectPb)
+			objectsMap[file.ObjectID] = objectToGraphQlObject(objectsPb[i])
 		}
 		file.Object = objectsMap[file.ObjectID]
 		files = append(files, file)
@@ -383,20 +399,13 @@ func GetFilesForCollection(ctx context.Context, clientClerkHandler pbHandler.Cle
 }
 
 func GetObjectsForCollectionId(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.ObjectListOptions, allowedTenants []string, logger zLogger.ZLogger) (*model.ObjectList, error) {
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	optionsPb := pb.Pagination{
 		Take:           10,
@@ -439,21 +448,28 @@ func GetObjectsForCollectionId(ctx context.Context, clientClerkHandler pbHandler
 		return nil, errors.Wrapf(err, "Could not GetCollectionsByTenantID: %v", err)
 	}
 
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	objectIds := make([]string, 0, len(objectsPb.Objects))
+	collectionIds := make([]string, 0, len(objectsPb.Objects))
+	for _, objectPb := range objectsPb.Objects {
+		objectIds = append(objectIds, objectPb.Id)
+		collectionIds = append(collectionIds, objectPb.CollectionId)
+	}
+	statuses, err := loaders.ObjectStatus.LoadAll(ctx, objectIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetStatusForObjectId: %v", err)
+	}
+	collectionsPb, err := loaders.Collection.LoadAll(ctx, collectionIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetCollectionByIdFromMv: %v", err)
+	}
 	collectionsMap := make(map[string]*model.Collection)
 	objects := make([]*model.Object, 0)
-	for _, objectPb := range objectsPb.Objects {
+	for i, objectPb := range objectsPb.Objects {
 		object := objectToGraphQlObject(objectPb)
-		status, err := clientClerkHandler.GetStatusForObjectId(ctx, &pb.Id{Id: object.ID})
-		if err != nil {
-			return nil, errors.Wrapf(err, "Could not GetStatusForObjectId: %v", err)
-		}
-		object.Status = int(status.Size)
+		object.Status = int(statuses[i])
 		if collectionsMap[object.CollectionID] == nil {
-			collectionPb, err := clientClerkHandler.GetCollectionByIdFromMv(ctx, &pb.Id{Id: object.CollectionID})
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not GetCollectionByIdFromMv: %v", err)
-			}
-			collectionsMap[object.CollectionID] = collectionToGraphQlCollection(collectionPb)
+			collectionsMap[object.CollectionID] = collectionToGraphQlCollection(collectionsPb[i])
 		}
 		object.Collection = collectionsMap[object.CollectionID]
 		objects = append(objects, object)
@@ -553,20 +569,13 @@ func GetFilesForObject(ctx context.Context, clientClerkHandler pbHandler.ClerkHa
 }
 
 func GetObjectInstancesForObjectId(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.ObjectInstanceListOptions, allowedTenants []string) (*model.ObjectInstanceList, error) {
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	optionsPb := pb.Pagination{
 		Take:           10,
@@ -606,24 +615,31 @@ func GetObjectInstancesForObjectId(ctx context.Context, clientClerkHandler pbHan
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetObjectInstancesByObjectIdPaginated: %v", err)
 	}
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	objectIds := make([]string, 0, len(objectInstancesPb.ObjectInstances))
+	storagePartitionIds := make([]string, 0, len(objectInstancesPb.ObjectInstances))
+	for _, objectInstancePb := range objectInstancesPb.ObjectInstances {
+		objectIds = append(objectIds, objectInstancePb.ObjectId)
+		storagePartitionIds = append(storagePartitionIds, objectInstancePb.StoragePartitionId)
+	}
+	objectsPb, err := loaders.Object.LoadAll(ctx, objectIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetObjectById: %v", err)
+	}
+	storagePartitionsPb, err := loaders.StoragePartition.LoadAll(ctx, storagePartitionIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
+	}
 	partitionsMap := make(map[string]*model.StoragePartition)
 	objectsMap := make(map[string]*model.Object)
 	objectInstances := make([]*model.ObjectInstance, 0)
-	for _, objectInstancePb := range objectInstancesPb.ObjectInstances {
+	for i, objectInstancePb := range objectInstancesPb.ObjectInstances {
 		objectInstance := objectInstanceToGraphQlObjectInstance(objectInstancePb)
 		if objectsMap[objectInstance.ObjectID] == nil {
-			objectPb, err := clientClerkHandler.GetObjectById(ctx, &pb.Id{Id: objectInstance.ObjectID})
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not GetObjectById: %v", err)
-			}
-			objectsMap[objectInstance.ObjectID] = objectToGraphQlObject(objectPb)
+			objectsMap[objectInstance.ObjectID] = objectToGraphQlObject(objectsPb[i])
 		}
 		if partitionsMap[objectInstance.StoragePartitionID] == nil {
-			storagePartitionPb, err := clientClerkHandler.GetStoragePartitionById(ctx, &pb.Id{Id: objectInstance.StoragePartitionID})
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
-			}
-			partitionsMap[objectInstance.StoragePartitionID] = storagePartitionToGraphQlStoragePartition(storagePartitionPb)
+			partitionsMap[objectInstance.StoragePartitionID] = storagePartitionToGraphQlStoragePartition(storagePartitionsPb[i])
 		}
 		objectInstance.Object = objectsMap[objectInstance.ObjectID]
 		objectInstance.StoragePartition = partitionsMap[objectInstance.StoragePartitionID]
@@ -633,20 +649,13 @@ func GetObjectInstancesForObjectId(ctx context.Context, clientClerkHandler pbHan
 }
 
 func GetFilesForObjectId(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.FileListOptions, allowedTenants []string) (*model.FileList, error) {
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	optionsPb := pb.Pagination{
 		Take:           10,
@@ -686,16 +695,20 @@ func GetFilesForObjectId(ctx context.Context, clientClerkHandler pbHandler.Clerk
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetFilesByObjectIdPaginated: %v", err)
 	}
+	objectIds := make([]string, 0, len(filesPb.Files))
+	for _, filePb := range filesPb.Files {
+		objectIds = append(objectIds, filePb.ObjectId)
+	}
+	objectsPb, err := dataloader.For(ctx, clientClerkHandler).Object.LoadAll(ctx, objectIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetObjectById: %v", err)
+	}
 	objectsMap := make(map[string]*model.Object)
 	files := make([]*model.File, 0)
-	for _, filePb := range filesPb.Files {
+	for i, filePb := range filesPb.Files {
 		file := fileToGraphQlFile(filePb)
 		if objectsMap[file.ObjectID] == nil {
-			objectPb, err := clientClerkHandler.GetObjectById(ctx, &pb.Id{Id: file.ObjectID})
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not GetObjectById: %v", err)
-			}
-			objectsMap[file.ObjectID] = objectToGraphQlObject(objectPb)
+			objectsMap[file.ObjectID] = objectToGraphQlObject(objectsPb[i])
 		}
 		file.Object = objectsMap[file.ObjectID]
 		files = append(files, file)
@@ -749,20 +762,13 @@ func GetObjectInstanceChecksForObjectInstance(ctx context.Context, clientClerkHa
 }
 
 func GetObjectInstanceChecksForObjectInstanceId(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.ObjectInstanceCheckListOptions, allowedTenants []string) (*model.ObjectInstanceCheckList, error) {
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	optionsPb := pb.Pagination{
 		Take:           10,
@@ -802,16 +808,20 @@ func GetObjectInstanceChecksForObjectInstanceId(ctx context.Context, clientClerk
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetObjectInstanceChecksByObjectInstanceIdPaginated: %v", err)
 	}
+	objectInstanceIds := make([]string, 0, len(objectInstanceChecksPb.ObjectInstanceChecks))
+	for _, objectInstanceCheckPb := range objectInstanceChecksPb.ObjectInstanceChecks {
+		objectInstanceIds = append(objectInstanceIds, objectInstanceCheckPb.ObjectInstanceId)
+	}
+	objectInstancesPb, err := dataloader.For(ctx, clientClerkHandler).ObjectInstance.LoadAll(ctx, objectInstanceIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetObjectInstanceById: %v", err)
+	}
 	objectInstancesMap := make(map[string]*model.ObjectInstance)
 	objectInstanceChecks := make([]*model.ObjectInstanceCheck, 0)
-	for _, objectInstanceCheckPb := range objectInstanceChecksPb.ObjectInstanceChecks {
+	for i, objectInstanceCheckPb := range objectInstanceChecksPb.ObjectInstanceChecks {
 		objectInstanceCheck := objectInstanceCheckToGraphQlObjectInstanceCheck(objectInstanceCheckPb)
 		if objectInstancesMap[objectInstanceCheck.ObjectInstanceID] == nil {
-			objectInstancePb, err := clientClerkHandler.GetObjectInstanceById(ctx, &pb.Id{Id: objectInstanceCheck.ObjectInstanceID})
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not GetObjectInstanceById: %v", err)
-			}
-			objectInstancesMap[objectInstanceCheck.ObjectInstanceID] = objectInstanceToGraphQlObjectInstance(objectInstancePb)
+			objectInstancesMap[objectInstanceCheck.ObjectInstanceID] = objectInstanceToGraphQlObjectInstance(objectInstancesPb[i])
 		}
 		objectInstanceCheck.ObjectInstance = objectInstancesMap[objectInstanceCheck.ObjectInstanceID]
 		objectInstanceChecks = append(objectInstanceChecks, objectInstanceCheck)
@@ -820,20 +830,13 @@ func GetObjectInstanceChecksForObjectInstanceId(ctx context.Context, clientClerk
 }
 
 func GetStorageLocationsForTenantOrCollectionId(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.StorageLocationListOptions, allowedTenants []string) (*model.StorageLocationList, error) {
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	optionsPb := pb.Pagination{
 		Take:           10,
@@ -875,26 +878,33 @@ func GetStorageLocationsForTenantOrCollectionId(ctx context.Context, clientClerk
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetStorageLocationsForTenantOrCollectionId: %v", err)
 	}
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	storageLocationIds := make([]string, 0, len(storageLocationsPb.StorageLocations))
+	tenantIds := make([]string, 0, len(storageLocationsPb.StorageLocations))
+	for _, storageLocationPb := range storageLocationsPb.StorageLocations {
+		storageLocationIds = append(storageLocationIds, storageLocationPb.Id)
+		tenantIds = append(tenantIds, storageLocationPb.TenantId)
+	}
+	amountsOfObjects, err := loaders.StorageLocationAmountOfObjects.LoadAll(ctx, storageLocationIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetAmountOfObjectsForStorageLocationId: %v", err)
+	}
+	amountsOfErrors, err := loaders.StorageLocationAmountOfErrors.LoadAll(ctx, storageLocationIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetAmountOfErrorsForStorageLocationId: %v", err)
+	}
+	tenantsPb, err := loaders.Tenant.LoadAll(ctx, tenantIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not FindTenantById: %v", err)
+	}
 	tenantsMap := make(map[string]*model.Tenant)
 	storageLocations := make([]*model.StorageLocation, 0)
-	for _, storageLocationPb := range storageLocationsPb.StorageLocations {
+	for i, storageLocationPb := range storageLocationsPb.StorageLocations {
 		storageLocation := storageLocationToGraphQlStorageLocation(storageLocationPb)
-		amountOfObjects, err := clientClerkHandler.GetAmountOfObjectsForStorageLocationId(ctx, &pb.Id{Id: storageLocation.ID})
-		if err != nil {
-			return nil, errors.Wrapf(err, "Could not GetAmountOfObjectsForStorageLocationId: %v", err)
-		}
-		amountOfErrors, err := clientClerkHandler.GetAmountOfErrorsForStorageLocationId(ctx, &pb.Id{Id: storageLocation.ID})
-		if err != nil {
-			return nil, errors.Wrapf(err, "Could not GetAmountOfErrorsForStorageLocationId: %v", err)
-		}
-		storageLocation.AmountOfErrors = int(amountOfErrors.Size)
-		storageLocation.AmountOfObjects = int(amountOfObjects.Size)
+		storageLocation.AmountOfErrors = int(amountsOfErrors[i])
+		storageLocation.AmountOfObjects = int(amountsOfObjects[i])
 		if tenantsMap[storageLocation.TenantID] == nil {
-			tenantPb, err := clientClerkHandler.FindTenantById(ctx, &pb.Id{Id: storageLocation.TenantID})
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not FindTenantById: %v", err)
-			}
-			tenantsMap[storageLocation.TenantID] = tenantToGraphQlTenant(tenantPb)
+			tenantsMap[storageLocation.TenantID] = tenantToGraphQlTenant(tenantsPb[i])
 		}
 		storageLocation.Tenant = tenantsMap[storageLocation.TenantID]
 		storageLocations = append(storageLocations, storageLocation)
@@ -903,20 +913,13 @@ func GetStorageLocationsForTenantOrCollectionId(ctx context.Context, clientClerk
 }
 
 func GetStoragePartitionsForLocationId(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.StoragePartitionListOptions, allowedTenants []string) (*model.StoragePartitionList, error) {
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	optionsPb := pb.Pagination{
 		Take:           10,
@@ -956,16 +959,20 @@ func GetStoragePartitionsForLocationId(ctx context.Context, clientClerkHandler p
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetStoragePartitionsByLocationIdPaginated: %v", err)
 	}
+	storageLocationIds := make([]string, 0, len(storagePartitionsPb.StoragePartitions))
+	for _, storagePartitionPb := range storagePartitionsPb.StoragePartitions {
+		storageLocationIds = append(storageLocationIds, storagePartitionPb.StorageLocationId)
+	}
+	storageLocationsPb, err := dataloader.For(ctx, clientClerkHandler).StorageLocation.LoadAll(ctx, storageLocationIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
+	}
 	storageLocationsMap := make(map[string]*model.StorageLocation)
 	storagePartitions := make([]*model.StoragePartition, 0)
-	for _, storagePartitionPb := range storagePartitionsPb.StoragePartitions {
+	for i, storagePartitionPb := range storagePartitionsPb.StoragePartitions {
 		storagePartition := storagePartitionToGraphQlStoragePartition(storagePartitionPb)
 		if storageLocationsMap[storagePartition.StorageLocationID] == nil {
-			storageLocationPb, err := clientClerkHandler.GetStorageLocationById(ctx, &pb.Id{Id: storagePartition.StorageLocationID})
-			if err != nil {
-				return nil, errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
-			}
-			storageLocationsMap[storagePartition.StorageLocationID] = storageLocationToGraphQlStorageLocation(storageLocationPb)
+			storageLocationsMap[storagePartition.StorageLocationID] = storageLocationToGraphQlStorageLocation(storageLocationsPb[i])
 		}
 		storagePartition.StorageLocation = storageLocationsMap[storagePartition.StorageLocationID]
 		storagePartitions = append(storagePartitions, storagePartition)
@@ -1064,20 +1071,13 @@ func GetObjectInstancesForStoragePartition(ctx context.Context, clientClerkHandl
 }
 
 func GetTenantById(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, id string, allowedTenants []string) (*model.Tenant, error) {
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	if len(allowedTenants) != 0 {
 		if !slices.Contains(allowedTenants, id) {
@@ -1090,12 +1090,10 @@ func GetTenantById(ctx context.Context, clientClerkHandler pbHandler.ClerkHandle
 	}
 	tenant := tenantToGraphQlTenant(tenantPb)
 	tenant.Permissions = make([]string, 0)
-	if len(tenantList) > 0 {
-		for _, tenantKL := range tenantList {
-			if tenantKL.Id == id {
-				if tenantKL.Update && tenantKL.Delete && tenantKL.Create && tenantKL.Read {
-					tenant.Permissions = append(tenant.Permissions, "collection", "storageLocation", "storagePartition")
-				}
+	for _, tenantKL := range subject.Tenants {
+		if tenantKL.Id == id {
+			if tenantKL.Update && tenantKL.Delete && tenantKL.Create && tenantKL.Read {
+				tenant.Permissions = append(tenant.Permissions, "collection", "storageLocation", "storagePartition")
 			}
 		}
 	}
@@ -1240,20 +1238,13 @@ func GetStoragePartitionById(ctx context.Context, clientClerkHandler pbHandler.C
 //Statistic
 
 func GetMimeTypesForCollectionId(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.MimeTypeListOptions, allowedTenants []string) (*model.MimeTypeList, error) {
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	optionsPb := pb.Pagination{
 		Take:           10,
@@ -1300,20 +1291,13 @@ func GetMimeTypesForCollectionId(ctx context.Context, clientClerkHandler pbHandl
 }
 
 func GetPronomsForCollectionId(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, options *model.PronomIDListOptions, allowedTenants []string) (*model.PronomIDList, error) {
-	keyCloakGroup, tenantList, err := middleware.TenantGroups(ctx)
+	subject, err := policy.SubjectFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if (len(tenantList) == 0) && (!slices.Contains(keyCloakGroup, "dlza-admin")) {
-		return nil, errors.New("You are not allowed to retrieve datas")
-	} else if len(tenantList) > 0 {
-		for _, tenant := range tenantList {
-			allowedTenants = append(allowedTenants, tenant.Id)
-		}
-		// allowedTenants = tenantList
-	}
-	if slices.Contains(keyCloakGroup, "dlza-admin") {
-		allowedTenants = []string{}
+	allowedTenants, err = subject.ReadableTenants()
+	if err != nil {
+		return nil, err
 	}
 	optionsPb := pb.Pagination{
 		Take:           10,
@@ -1359,26 +1343,393 @@ func GetPronomsForCollectionId(ctx context.Context, clientClerkHandler pbHandler
 	return &model.PronomIDList{Items: pronoms, TotalItems: int(pronomsPb.TotalItems)}, nil
 }
 
-func CreateCollection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.CollectionInput) (*model.Collection, error) {
-	_, tenantList, err := middleware.TenantGroups(ctx)
+var (
+	aliasPattern                 = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	storagePartitionAliasPattern = regexp.MustCompile(`^[^/\s]+/[^/\s]+$`)
+)
+
+const aliasDescription = "may only contain lower case letters, digits, - and _"
+
+// aliasRules checks the pattern and the uniqueness of an alias on create or when it changes, so entities with an
+// alias from before the pattern can still be updated. taken is only looked up if the alias has to be checked.
+func aliasRules(alias string, current string, update bool, pattern *regexp.Regexp, description string, taken func() (map[string]string, error), self string) ([]validation.Rule, error) {
+	if update && alias == current {
+		return nil, nil
+	}
+	aliases, err := taken()
+	if err != nil {
+		return nil, err
+	}
+	return []validation.Rule{validation.Match(pattern, description), validation.Unique(aliases, self)}, nil
+}
+
+// validateTenantInput checks the mandatory fields and that the alias is not taken by another tenant.
+// current is the stored tenant on update, nil on create.
+func validateTenantInput(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.TenantInput, current *pb.Tenant) error {
+	if input == nil {
+		return errors.New("Invalid tenant input: input is missing")
+	}
+	currentAlias := ""
+	if current != nil {
+		currentAlias = current.Alias
+	}
+	alias, err := aliasRules(input.Alias, currentAlias, current != nil, aliasPattern, aliasDescription, func() (map[string]string, error) {
+		tenantsPb, err := clientClerkHandler.FindAllTenants(ctx, &pb.NoParam{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not FindAllTenants: %v", err)
+		}
+		aliases := make(map[string]string, len(tenantsPb.Tenants))
+		for _, tenantPb := range tenantsPb.Tenants {
+			aliases[tenantPb.Alias] = "tenant " + tenantPb.Id
+		}
+		return aliases, nil
+	}, "tenant "+input.ID)
+	if err != nil {
+		return err
+	}
+	return validation.Validate("tenant input",
+		validation.Field("name", input.Name, validation.Required),
+		validation.Field("alias", input.Alias, alias...),
+		validation.Field("person", input.Person, validation.Required),
+		validation.Field("email", input.Email, validation.Email),
+	)
+}
+
+// validateCollectionInput checks the fields and that the alias, which identifies the collection on ingest,
+// is not taken by another collection of any tenant
+func validateCollectionInput(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.CollectionInput, update bool) error {
+	if input == nil {
+		return errors.New("Invalid collection input: input is missing")
+	}
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	current := ""
+	if update && input.ID != "" {
+		collectionPb, err := clientClerkHandler.GetCollectionById(ctx, &pb.Id{Id: input.ID})
+		if err != nil {
+			return lookupError(err, policy.KindCollection, input.ID, "GetCollectionById")
+		}
+		current = collectionPb.Alias
+	}
+	alias, err := aliasRules(input.Alias, current, update, aliasPattern, aliasDescription, func() (map[string]string, error) {
+		tenantsPb, err := clientClerkHandler.FindAllTenants(ctx, &pb.NoParam{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not FindAllTenants: %v", err)
+		}
+		tenantIds := make([]string, 0, len(tenantsPb.Tenants))
+		for _, tenantPb := range tenantsPb.Tenants {
+			tenantIds = append(tenantIds, tenantPb.Id)
+		}
+		collectionsPb, err := loaders.TenantCollections.LoadAll(ctx, tenantIds)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not GetCollectionsByTenantId: %v", err)
+		}
+		aliases := make(map[string]string)
+		for _, tenantCollectionsPb := range collectionsPb {
+			for _, collectionPb := range tenantCollectionsPb {
+				aliases[collectionPb.Alias] = "collection " + collectionPb.Id
+			}
+		}
+		return aliases, nil
+	}, "collection "+input.ID)
+	if err != nil {
+		return err
+	}
+	return validation.Validate("collection input",
+		validation.Field("id", input.ID, requiredOnUpdate(update)...),
+		validation.Field("alias", input.Alias, alias...),
+		validation.Field("name", input.Name, validation.Required),
+		validation.Field("owner", input.Owner, validation.Required),
+		validation.Field("ownerMail", input.OwnerMail, validation.Email),
+		validation.Field("quality", input.Quality, validation.NonNegative),
+		validation.Field("tenantId", input.TenantID, validation.Required),
+	)
+}
+
+// validateStorageLocationInput checks the fields and that the alias is not taken by another storage location
+// of the tenant. It returns the connection json to save.
+func validateStorageLocationInput(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.StorageLocationInput, conf models.ValidationConfig, update bool) (string, error) {
+	if input == nil {
+		return "", errors.New("Invalid storage location input: input is missing")
+	}
+	current := ""
+	if update && input.ID != "" {
+		storageLocationPb, err := dataloader.For(ctx, clientClerkHandler).StorageLocation.Load(ctx, input.ID)
+		if err != nil {
+			return "", lookupError(err, policy.KindStorageLocation, input.ID, "GetStorageLocationById")
+		}
+		current = storageLocationPb.Alias
+	}
+	alias, err := aliasRules(input.Alias, current, update, aliasPattern, aliasDescription, func() (map[string]string, error) {
+		aliases := make(map[string]string)
+		if input.TenantID == "" {
+			return aliases, nil
+		}
+		storageLocationsPb, err := clientClerkHandler.GetStorageLocationsByTenantId(ctx, &pb.Id{Id: input.TenantID})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not GetStorageLocationsByTenantId: %v", err)
+		}
+		for _, storageLocationPb := range storageLocationsPb.StorageLocations {
+			aliases[storageLocationPb.Alias] = "storage location " + storageLocationPb.Id
+		}
+		return aliases, nil
+	}, "storage location "+input.ID)
+	if err != nil {
+		return "", err
+	}
+	rawConnection, connection, err := storageLocationConnection(ctx, clientClerkHandler, input, update)
+	if err != nil {
+		return "", err
+	}
+	fields := []validation.FieldRules{
+		validation.Field("id", input.ID, requiredOnUpdate(update)...),
+		validation.Field("alias", input.Alias, alias...),
+		validation.Field("type", input.Type, validation.Required, validation.OneOf(conf.StorageLocationTypes...)),
+		validation.Field("connectionConfig", rawConnection, validation.Required),
+		validation.Field("quality", input.Quality, validation.NonNegative),
+		validation.Field("price", input.Price, validation.NonNegative),
+		validation.Field("securityCompliency", input.SecurityCompliency, validation.OneOf(conf.SecurityCompliencies...)),
+		validation.Field("ocflType", input.OcflType, validation.OneOf(conf.OcflTypes...)),
+		validation.Field("tenantId", input.TenantID, validation.Required),
+		validation.Field("numberOfThreads", input.NumberOfThreads, validation.NonNegative),
+	}
+	if connection != nil {
+		fields = append(fields, storageConnectionRules(connection)...)
+	}
+	return rawConnection, validation.Validate("storage location input", fields...)
+}
+
+// validateStoragePartitionInput checks the fields and that the alias is not taken by another partition
+// of the storage location
+func validateStoragePartitionInput(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.StoragePartitionInput, update bool) error {
+	if input == nil {
+		return errors.New("Invalid storage partition input: input is missing")
+	}
+	current := ""
+	if update && input.ID != "" {
+		storagePartitionPb, err := dataloader.For(ctx, clientClerkHandler).StoragePartition.Load(ctx, input.ID)
+		if err != nil {
+			return lookupError(err, policy.KindStoragePartition, input.ID, "GetStoragePartitionById")
+		}
+		current = storagePartitionPb.Alias
+	}
+	alias, err := aliasRules(input.Alias, current, update, storagePartitionAliasPattern, "should have the format 'part1/part2'", func() (map[string]string, error) {
+		aliases := make(map[string]string)
+		if input.StorageLocationID == "" {
+			return aliases, nil
+		}
+		storagePartitionsPb, err := getAllStoragePartitionsForLocation(ctx, clientClerkHandler, input.StorageLocationID)
+		if err != nil {
+			return nil, err
+		}
+		for _, storagePartitionPb := range storagePartitionsPb {
+			aliases[storagePartitionPb.Alias] = "storage partition " + storagePartitionPb.Id
+		}
+		return aliases, nil
+	}, "storage partition "+input.ID)
+	if err != nil {
+		return err
+	}
+	return validation.Validate("storage partition input",
+		validation.Field("id", input.ID, requiredOnUpdate(update)...),
+		validation.Field("alias", input.Alias, alias...),
+		validation.Field("name", input.Name, validation.Required),
+		validation.Field("maxSize", input.MaxSize, validation.NonNegative),
+		validation.Field("maxObjects", input.MaxObjects, validation.NonNegative),
+		validation.Field("currentSize", input.CurrentSize, validation.NonNegative),
+		validation.Field("currentObjects", input.CurrentObjects, validation.NonNegative),
+		validation.Field("storageLocationId", input.StorageLocationID, validation.Required),
+	)
+}
+
+func requiredOnUpdate(update bool) []validation.Rule {
+	if update {
+		return []validation.Rule{validation.Required}
+	}
+	return nil
+}
+
+func tenantInputToGrpcTenant(input *model.TenantInput) *pb.Tenant {
+	return &pb.Tenant{
+		Id:     input.ID,
+		Name:   strings.TrimSpace(input.Name),
+		Alias:  input.Alias,
+		Person: strings.TrimSpace(input.Person),
+		Email:  strings.TrimSpace(input.Email),
+	}
+}
+
+// tenantAliases serializes the alias check and the write of tenants, so two creates in this clerk cannot both
+// take the same alias. Clerks running side by side still rely on the unique alias column of the handler.
+var tenantAliases sync.Mutex
+
+func CreateTenant(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.TenantInput) (*model.Tenant, error) {
+	if input == nil {
+		return nil, errors.New("Invalid tenant input: input must not be empty")
+	}
+	// the id is assigned by the handler
+	tenantInput := *input
+	tenantInput.ID = ""
+	tenantAliases.Lock()
+	defer tenantAliases.Unlock()
+	if err := validateTenantInput(ctx, clientClerkHandler, &tenantInput, nil); err != nil {
+		return nil, err
+	}
+	tenantPb := tenantInputToGrpcTenant(&tenantInput)
+	if _, err := clientClerkHandler.SaveTenant(ctx, tenantPb); err != nil {
+		return nil, errors.Wrapf(err, "Could not SaveTenant: %v", err)
+	}
+	// SaveTenant does not return the new id, the alias is unique
+	tenantsPb, err := clientClerkHandler.FindAllTenants(ctx, &pb.NoParam{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not FindAllTenants: %v", err)
+	}
+	for _, created := range tenantsPb.Tenants {
+		if created.Alias == tenantPb.Alias && created.Id != "" {
+			return tenantToGraphQlTenant(created), nil
+		}
+	}
+	return nil, errors.Errorf("Could not SaveTenant: tenant %s was saved but is not listed by the handler", tenantPb.Alias)
+}
+
+func UpdateTenant(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.TenantInput) (*model.Tenant, error) {
+	if input == nil || input.ID == "" {
+		return nil, errors.New("Invalid tenant input: id must not be empty")
+	}
+	currentPb, err := clientClerkHandler.FindTenantById(ctx, &pb.Id{Id: input.ID})
 	if err != nil {
+		return nil, lookupError(err, policy.KindTenant, input.ID, "FindTenantById")
+	}
+	tenantAliases.Lock()
+	defer tenantAliases.Unlock()
+	if err := validateTenantInput(ctx, clientClerkHandler, input, currentPb); err != nil {
 		return nil, err
 	}
-	if len(tenantList) > 0 {
-		for count, tenant := range tenantList {
-			if tenant.Id == input.TenantID {
-				if tenant.Create && tenant.Read && tenant.Delete {
-					break
-				} else {
-					return nil, errors.New("You are not allowed to proceed with creating collection")
+	tenantPb := tenantInputToGrpcTenant(input)
+	if _, err := clientClerkHandler.UpdateTenant(ctx, tenantPb); err != nil {
+		return nil, errors.Wrapf(err, "Could not UpdateTenant: %v", err)
+	}
+	return tenantToGraphQlTenant(tenantPb), nil
+}
+
+// DeleteTenant deletes a tenant without collections and storage locations. In cascade mode, which has to be
+// confirmed with the alias of the tenant, its collections, storage locations and partitions are deleted first.
+// The handler has no transaction across these deletes, so every storage location is checked like in
+// DeleteStorageLocation before the first one is removed. A handler failure in between leaves a partly deleted
+// tenant, running the cascade again finishes it.
+// refuseTenantCascade checks the whole cascade before anything is deleted. Deleting the collections empties the
+// partitions of the storage locations of the tenant, unless they hold object instances of collections of other
+// tenants, which would lose them. Empty partitions can be deleted in any state, so nothing else can refuse the
+// deletes of the cascade.
+func refuseTenantCascade(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, collectionsPb []*pb.Collection, storageLocationsPb []*pb.StorageLocation) error {
+	deleted := make(map[string]bool, len(collectionsPb))
+	for _, collectionPb := range collectionsPb {
+		deleted[collectionPb.Id] = true
+	}
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	for _, storageLocationPb := range storageLocationsPb {
+		storagePartitionsPb, err := getAllStoragePartitionsForLocation(ctx, clientClerkHandler, storageLocationPb.Id)
+		if err != nil {
+			return err
+		}
+		objectInstancesPb, err := loaders.StoragePartitionInstances.LoadAll(ctx, partitionIds(storagePartitionsPb))
+		if err != nil {
+			return errors.Wrapf(err, "Could not GetObjectInstancesByStoragePartitionIdPaginated: %v", err)
+		}
+		for i, storagePartitionPb := range storagePartitionsPb {
+			objectIds := make([]string, 0, len(objectInstancesPb[i]))
+			for _, objectInstancePb := range objectInstancesPb[i] {
+				objectIds = append(objectIds, objectInstancePb.ObjectId)
+			}
+			objectsPb, err := loaders.Object.LoadAll(ctx, objectIds)
+			if err != nil {
+				return errors.Wrapf(err, "Could not GetObjectById: %v", err)
+			}
+			foreign := 0
+			for _, objectPb := range objectsPb {
+				if !deleted[objectPb.CollectionId] {
+					foreign++
 				}
 			}
-			if count == len(tenantList)-1 {
-				return nil, errors.New("You are not allowed to proceed with creating collection")
+			if foreign > 0 {
+				return errors.Errorf("Storage partition %s of storage location %s still holds %d object instances of collections of other tenants",
+					storagePartitionPb.Alias, storageLocationPb.Alias, foreign)
 			}
 		}
-	} else {
-		return nil, errors.New("You are not allowed to proceed with creating collection")
+	}
+	return nil
+}
+
+func DeleteTenant(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, store *lifecycle.Store, id string, mode model.TenantDeleteMode, confirm string) (*model.Tenant, error) {
+	tenantPb, err := clientClerkHandler.FindTenantById(ctx, &pb.Id{Id: id})
+	if err != nil {
+		return nil, lookupError(err, policy.KindTenant, id, "FindTenantById")
+	}
+	if tenantPb.Id == "" {
+		return nil, notFound(policy.KindTenant, id)
+	}
+	collectionsPb, err := clientClerkHandler.GetCollectionsByTenantId(ctx, &pb.Id{Id: id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetCollectionsByTenantId: %v", err)
+	}
+	storageLocationsPb, err := clientClerkHandler.GetStorageLocationsByTenantId(ctx, &pb.Id{Id: id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetStorageLocationsByTenantId: %v", err)
+	}
+	owned := len(collectionsPb.Collections) + len(storageLocationsPb.StorageLocations)
+	if owned > 0 && mode != model.TenantDeleteModeCascade {
+		return nil, errors.Errorf("Tenant %s still owns %d collections and %d storage locations, delete them first or use mode CASCADE",
+			tenantPb.Alias, len(collectionsPb.Collections), len(storageLocationsPb.StorageLocations))
+	}
+	if owned > 0 && confirm != tenantPb.Alias {
+		return nil, errors.Errorf("Tenant %s still owns %d collections and %d storage locations, confirm the cascade with the tenant alias",
+			tenantPb.Alias, len(collectionsPb.Collections), len(storageLocationsPb.StorageLocations))
+	}
+	if err := refuseTenantCascade(ctx, clientClerkHandler, collectionsPb.Collections, storageLocationsPb.StorageLocations); err != nil {
+		return nil, err
+	}
+	for _, collectionPb := range collectionsPb.Collections {
+		if _, err := DeleteCollection(ctx, clientClerkHandler, collectionPb.Id, false); err != nil {
+			return nil, errors.Wrapf(err, "Could not DeleteCollection %s", collectionPb.Alias)
+		}
+	}
+	for _, storageLocationPb := range storageLocationsPb.StorageLocations {
+		storagePartitionsPb, err := getAllStoragePartitionsForLocation(ctx, clientClerkHandler, storageLocationPb.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, storagePartitionPb := range storagePartitionsPb {
+			if _, err := DeleteStoragePartition(ctx, clientClerkHandler, store, storagePartitionPb.Id, false); err != nil {
+				return nil, errors.Wrapf(err, "Could not DeleteStoragePartition %s", storagePartitionPb.Alias)
+			}
+		}
+		if _, err := DeleteStorageLocation(ctx, clientClerkHandler, store, storageLocationPb.Id, false); err != nil {
+			return nil, errors.Wrapf(err, "Could not DeleteStorageLocation %s", storageLocationPb.Alias)
+		}
+	}
+	if _, err := clientClerkHandler.DeleteTenant(ctx, &pb.Id{Id: id}); err != nil {
+		return nil, errors.Wrapf(err, "Could not DeleteTenant: %v", err)
+	}
+	return tenantToGraphQlTenant(tenantPb), nil
+}
+
+// getAllStoragePartitionsForLocation pages through all partitions of the storage location
+func getAllStoragePartitionsForLocation(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, storageLocationId string) ([]*pb.StoragePartition, error) {
+	storagePartitions := make([]*pb.StoragePartition, 0)
+	for skip := int32(0); ; skip += 1000 {
+		storagePartitionsPb, err := clientClerkHandler.GetStoragePartitionsByLocationIdPaginated(ctx, &pb.Pagination{Id: storageLocationId, Skip: skip, Take: 1000, SortKey: "ID", SortDirection: sortDirectionAscending})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not GetStoragePartitionsByLocationIdPaginated: %v", err)
+		}
+		storagePartitions = append(storagePartitions, storagePartitionsPb.StoragePartitions...)
+		if len(storagePartitionsPb.StoragePartitions) == 0 || int64(skip)+1000 >= int64(storagePartitionsPb.TotalItems) {
+			return storagePartitions, nil
+		}
+	}
+}
+
+func CreateCollection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.CollectionInput) (*model.Collection, error) {
+	if err := validateCollectionInput(ctx, clientClerkHandler, input, false); err != nil {
+		return nil, err
 	}
 	collectionPb := collectionInputToGrpcCollection(*input)
 	idPb, err := clientClerkHandler.CreateCollection(ctx, collectionPb)
@@ -1391,28 +1742,11 @@ func CreateCollection(ctx context.Context, clientClerkHandler pbHandler.ClerkHan
 }
 
 func UpdateCollection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.CollectionInput) (*model.Collection, error) {
-	_, tenantList, err := middleware.TenantGroups(ctx)
-	if err != nil {
+	if err := validateCollectionInput(ctx, clientClerkHandler, input, true); err != nil {
 		return nil, err
 	}
-	if len(tenantList) > 0 {
-		for count, tenant := range tenantList {
-			if tenant.Id == input.TenantID {
-				if tenant.Create && tenant.Read && tenant.Delete {
-					break
-				} else {
-					return nil, errors.New("You are not allowed to proceed with updating collection")
-				}
-			}
-			if count == len(tenantList)-1 {
-				return nil, errors.New("You are not allowed to proceed with updating collection")
-			}
-		}
-	} else {
-		return nil, errors.New("You are not allowed to proceed with updating collection")
-	}
 	collectionPb := collectionInputToGrpcCollection(*input)
-	_, err = clientClerkHandler.UpdateCollection(ctx, collectionPb)
+	_, err := clientClerkHandler.UpdateCollection(ctx, collectionPb)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not UpdateCollection: %v", err)
 	}
@@ -1420,32 +1754,21 @@ func UpdateCollection(ctx context.Context, clientClerkHandler pbHandler.ClerkHan
 	return collectionG, nil
 }
 
-func DeleteCollection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, id string) (*model.Collection, error) {
+// DeleteCollection deletes the collection with its objects, with dryRun only the impact is reported
+func DeleteCollection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, id string, dryRun bool) (*model.Collection, error) {
 	collectionPb, err := clientClerkHandler.GetCollectionById(ctx, &pb.Id{Id: id})
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetCollectionById: %v", err)
 	}
-	_, tenantList, err := middleware.TenantGroups(ctx)
+	collection := collectionToGraphQlCollection(collectionPb)
+	collection.DeleteImpact, err = collectionDeleteImpact(ctx, clientClerkHandler, collectionPb)
 	if err != nil {
 		return nil, err
 	}
-	if len(tenantList) > 0 {
-		for count, tenant := range tenantList {
-			if tenant.Id == collectionPb.TenantId {
-				if tenant.Create && tenant.Read && tenant.Delete {
-					break
-				} else {
-					return nil, errors.New("You are not allowed to proceed with deleting the collection")
-				}
-			}
-			if count == len(tenantList)-1 {
-				return nil, errors.New("You are not allowed to proceed with deleting the collection")
-			}
-		}
-	} else {
-		return nil, errors.New("You are not allowed to proceed with deleting the collection")
+	if dryRun {
+		collection.DeleteImpact.DryRun = true
+		return collection, nil
 	}
-	collection := collectionToGraphQlCollection(collectionPb)
 	_, err = clientClerkHandler.DeleteCollectionById(ctx, &pb.Id{Id: id})
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not DeleteCollection: %v", err)
@@ -1453,28 +1776,18 @@ func DeleteCollection(ctx context.Context, clientClerkHandler pbHandler.ClerkHan
 	return collection, nil
 }
 
-func CreateStorageLocation(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.StorageLocationInput) (*model.StorageLocation, error) {
-	_, tenantList, err := middleware.TenantGroups(ctx)
+func CreateStorageLocation(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, clientClerkStorageHandler pbStorageHandler.ClerkStorageHandlerServiceClient, input *model.StorageLocationInput, conf models.ValidationConfig, verify bool) (*model.StorageLocation, error) {
+	connection, err := validateStorageLocationInput(ctx, clientClerkHandler, input, conf, false)
 	if err != nil {
 		return nil, err
 	}
-	if len(tenantList) > 0 {
-		for count, tenant := range tenantList {
-			if tenant.Id == input.TenantID {
-				if tenant.Create && tenant.Read && tenant.Delete {
-					break
-				} else {
-					return nil, errors.New("You are not allowed to proceed with creating storage location")
-				}
-			}
-			if count == len(tenantList)-1 {
-				return nil, errors.New("You are not allowed to proceed with creating storage location")
-			}
+	if verify {
+		if err := verifyStorageConnection(ctx, clientClerkStorageHandler, input.Alias, connection); err != nil {
+			return nil, err
 		}
-	} else {
-		return nil, errors.New("You are not allowed to proceed with creating storage location")
 	}
 	storageLocationPb := storageLocationInputToGrpcStorageLocation(input)
+	storageLocationPb.Connection = connection
 	idPb, err := clientClerkHandler.SaveStorageLocation(ctx, storageLocationPb)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not CreateStorageLocation: %v", err)
@@ -1484,28 +1797,18 @@ func CreateStorageLocation(ctx context.Context, clientClerkHandler pbHandler.Cle
 	return storageLocationG, nil
 }
 
-func UpdateStorageLocation(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.StorageLocationInput) (*model.StorageLocation, error) {
-	_, tenantList, err := middleware.TenantGroups(ctx)
+func UpdateStorageLocation(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, clientClerkStorageHandler pbStorageHandler.ClerkStorageHandlerServiceClient, input *model.StorageLocationInput, conf models.ValidationConfig, verify bool) (*model.StorageLocation, error) {
+	connection, err := validateStorageLocationInput(ctx, clientClerkHandler, input, conf, true)
 	if err != nil {
 		return nil, err
 	}
-	if len(tenantList) > 0 {
-		for count, tenant := range tenantList {
-			if tenant.Id == input.TenantID {
-				if tenant.Create && tenant.Read && tenant.Delete {
-					break
-				} else {
-					return nil, errors.New("You are not allowed to proceed with updating storage location")
-				}
-			}
-			if count == len(tenantList)-1 {
-				return nil, errors.New("You are not allowed to proceed with updating storage location")
-			}
+	if verify {
+		if err := verifyStorageConnection(ctx, clientClerkStorageHandler, input.Alias, connection); err != nil {
+			return nil, err
 		}
-	} else {
-		return nil, errors.New("You are not allowed to proceed with updating storage location")
 	}
 	storageLocationPb := storageLocationInputToGrpcStorageLocation(input)
+	storageLocationPb.Connection = connection
 	_, err = clientClerkHandler.UpdateStorageLocation(ctx, storageLocationPb)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not UpdateStorageLocation: %v", err)
@@ -1514,76 +1817,86 @@ func UpdateStorageLocation(ctx context.Context, clientClerkHandler pbHandler.Cle
 	return storageLocationG, nil
 }
 
-func DeleteStorageLocation(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, id string) (*model.StorageLocation, error) {
-	StorageLocationPb, err := clientClerkHandler.GetStorageLocationById(ctx, &pb.Id{Id: id})
+// DeleteStorageLocation deletes the storage location with its partitions, with dryRun only the impact is reported.
+// The delete is refused if objects would be left below their needed quality or a partition is not empty.
+func DeleteStorageLocation(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, store *lifecycle.Store, id string, dryRun bool) (*model.StorageLocation, error) {
+	storageLocationPb, err := clientClerkHandler.GetStorageLocationById(ctx, &pb.Id{Id: id})
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
 	}
-	_, tenantList, err := middleware.TenantGroups(ctx)
-	if err != nil {
-		return nil, err
-	}
-	if len(tenantList) > 0 {
-		for count, tenant := range tenantList {
-			if tenant.Id == StorageLocationPb.TenantId {
-				if tenant.Create && tenant.Read && tenant.Delete {
-					break
-				} else {
-					return nil, errors.New("You are not allowed to proceed with deleting the storage location")
-				}
-			}
-			if count == len(tenantList)-1 {
-				return nil, errors.New("You are not allowed to proceed with deleting the storage location")
-			}
+	storageLocationG := storageLocationToGraphQlStorageLocation(storageLocationPb)
+	if dryRun {
+		storagePartitionsPb, err := getAllStoragePartitionsForLocation(ctx, clientClerkHandler, id)
+		if err != nil {
+			return nil, err
 		}
-	} else {
-		return nil, errors.New("You are not allowed to proceed with deleting the storage location")
+		storageLocationG.DeleteImpact, err = instancesDeleteImpact(ctx, clientClerkHandler, partitionIds(storagePartitionsPb))
+		if err != nil {
+			return nil, err
+		}
+		storageLocationG.DeleteImpact.DryRun = true
+		return storageLocationG, nil
 	}
-	storageLocationPb, err := clientClerkHandler.GetStorageLocationById(ctx, &pb.Id{Id: id})
+	impact, storagePartitionsPb, err := storageLocationDeleteImpact(ctx, clientClerkHandler, store, storageLocationPb)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
+		return nil, err
 	}
+	storageLocationG.DeleteImpact = impact
 	_, err = clientClerkHandler.DeleteStorageLocationById(ctx, &pb.Id{Id: id})
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not DeleteStorageLocationById: %v", err)
 	}
-	storageLocationG := storageLocationToGraphQlStorageLocation(storageLocationPb)
+	for _, storagePartitionId := range partitionIds(storagePartitionsPb) {
+		if err := store.Delete(ctx, storagePartitionId); err != nil {
+			return nil, errors.Wrapf(err, "Could not remove state of storage partition %s", storagePartitionId)
+		}
+	}
 	return storageLocationG, nil
 }
 
-func CreateStoragePartition(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, clientClerkStorageHandler pbStorageHandler.ClerkStorageHandlerServiceClient, input *model.StoragePartitionInput) (*model.StoragePartition, error) {
-	storageLocationPb, err := clientClerkHandler.GetStorageLocationById(ctx, &pb.Id{Id: input.StorageLocationID})
+// storageLocationDeleteImpact reports the impact of deleting the storage location and refuses the delete if objects
+// would be left below their needed quality or one of its partitions is not empty
+func storageLocationDeleteImpact(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, store *lifecycle.Store, storageLocationPb *pb.StorageLocation) (*model.DeleteImpact, []*pb.StoragePartition, error) {
+	storagePartitionsPb, err := getAllStoragePartitionsForLocation(ctx, clientClerkHandler, storageLocationPb.Id)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
+		return nil, nil, err
 	}
-	_, tenantList, err := middleware.TenantGroups(ctx)
+	impact, err := instancesDeleteImpact(ctx, clientClerkHandler, partitionIds(storagePartitionsPb))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	if len(tenantList) > 0 {
-		for count, tenant := range tenantList {
-			if tenant.Id == storageLocationPb.TenantId {
-				if tenant.Create && tenant.Read && tenant.Delete {
-					break
-				} else {
-					return nil, errors.New("You are not allowed to proceed with creating storage partition")
-				}
-			}
-			if count == len(tenantList)-1 {
-				return nil, errors.New("You are not allowed to proceed with updating storage partition")
-			}
+	if err := refuseQualityLoss("storage location", storageLocationPb.Alias, impact); err != nil {
+		return nil, nil, err
+	}
+	for _, storagePartitionPb := range storagePartitionsPb {
+		if err := refusePartitionDelete(ctx, clientClerkHandler, store, storagePartitionPb); err != nil {
+			return nil, nil, err
 		}
-	} else {
-		return nil, errors.New("You are not allowed to proceed with creating storage partition")
+	}
+	return impact, storagePartitionsPb, nil
+}
+
+func partitionIds(storagePartitionsPb []*pb.StoragePartition) []string {
+	storagePartitionIds := make([]string, 0, len(storagePartitionsPb))
+	for _, storagePartitionPb := range storagePartitionsPb {
+		storagePartitionIds = append(storagePartitionIds, storagePartitionPb.Id)
+	}
+	return storagePartitionIds
+}
+
+func CreateStoragePartition(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, clientClerkStorageHandler pbStorageHandler.ClerkStorageHandlerServiceClient, input *model.StoragePartitionInput) (*model.StoragePartition, error) {
+	if err := validateStoragePartitionInput(ctx, clientClerkHandler, input, false); err != nil {
+		return nil, err
+	}
+	storageLocationPb, err := clientClerkHandler.GetStorageLocationById(ctx, &pb.Id{Id: input.StorageLocationID})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
 	}
 	connection := dlzamodels.Connection{}
 	if err = json.Unmarshal([]byte(storageLocationPb.Connection), &connection); err != nil {
 		return nil, errors.Wrapf(err, "error mapping storageLocation json for storageLocation ID: %s", storageLocationPb.Id)
 	}
 	aliasParts := strings.Split(input.Alias, "/")
-	if len(aliasParts) != 2 {
-		return nil, errors.New("alias should have right format 'part1/part2'")
-	}
 	pathString := path.Join(connection.Folder, aliasParts[0])
 	if _, err := clientClerkStorageHandler.CreateFolder(ctx, &pb.Id{Id: pathString}); err != nil {
 		return nil, errors.Wrapf(err, "Could not CreateFolder with path: %s", pathString)
@@ -1598,33 +1911,15 @@ func CreateStoragePartition(ctx context.Context, clientClerkHandler pbHandler.Cl
 	return storagePartitionG, nil
 }
 
-func UpdateStoragePartition(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.StoragePartitionInput) (*model.StoragePartition, error) {
-	storageLocationPb, err := clientClerkHandler.GetStorageLocationById(ctx, &pb.Id{Id: input.StorageLocationID})
-	if err != nil {
-		return nil, errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
-	}
-	_, tenantList, err := middleware.TenantGroups(ctx)
-	if err != nil {
+func UpdateStoragePartition(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, store *lifecycle.Store, input *model.StoragePartitionInput) (*model.StoragePartition, error) {
+	if err := validateStoragePartitionInput(ctx, clientClerkHandler, input, true); err != nil {
 		return nil, err
 	}
-	if len(tenantList) > 0 {
-		for count, tenant := range tenantList {
-			if tenant.Id == storageLocationPb.TenantId {
-				if tenant.Create && tenant.Read && tenant.Delete {
-					break
-				} else {
-					return nil, errors.New("You are not allowed to proceed with updating storage partition")
-				}
-			}
-			if count == len(tenantList)-1 {
-				return nil, errors.New("You are not allowed to proceed with updating storage partition")
-			}
-		}
-	} else {
-		return nil, errors.New("You are not allowed to proceed with updating storage partition")
+	if err := refuseLimitChange(ctx, clientClerkHandler, store, input); err != nil {
+		return nil, err
 	}
 	storagePartitionPb := storagePartitionInputToGrpcStoragePartition(input)
-	_, err = clientClerkHandler.UpdateStoragePartition(ctx, storagePartitionPb)
+	_, err := clientClerkHandler.UpdateStoragePartition(ctx, storagePartitionPb)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not UpdateStoragePartition: %v", err)
 	}
@@ -1632,44 +1927,128 @@ func UpdateStoragePartition(ctx context.Context, clientClerkHandler pbHandler.Cl
 	return storagePartitionG, nil
 }
 
-func DeleteStoragePartition(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, id string) (*model.StoragePartition, error) {
-	StoragePartitionPb, err := clientClerkHandler.GetStoragePartitionById(ctx, &pb.Id{Id: id})
+// DeleteStoragePartition deletes the storage partition, with dryRun only the impact is reported.
+// The delete is refused if objects would be left below their needed quality or the partition is not empty.
+func DeleteStoragePartition(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, store *lifecycle.Store, id string, dryRun bool) (*model.StoragePartition, error) {
+	storagePartition, err := GetStoragePartitionById(ctx, clientClerkHandler, id)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
 	}
-	StorageLocationPb, err := clientClerkHandler.GetStorageLocationById(ctx, &pb.Id{Id: StoragePartitionPb.StorageLocationId})
+	storagePartition.DeleteImpact, err = instancesDeleteImpact(ctx, clientClerkHandler, []string{id})
 	if err != nil {
-		return nil, errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
+		return nil, err
 	}
-	_, tenantList, err := middleware.TenantGroups(ctx)
-	if err != nil {
+	if dryRun {
+		storagePartition.DeleteImpact.DryRun = true
+		return storagePartition, nil
+	}
+	if err := refuseQualityLoss("storage partition", storagePartition.Alias, storagePartition.DeleteImpact); err != nil {
 		return nil, err
 	}
-	if len(tenantList) > 0 {
-		for count, tenant := range tenantList {
-			if tenant.Id == StorageLocationPb.TenantId {
-				if tenant.Create && tenant.Read && tenant.Delete {
-					break
-				} else {
-					return nil, errors.New("You are not allowed to proceed with deleting the storage partition")
-				}
+	if err := refusePartitionDelete(ctx, clientClerkHandler, store, &pb.StoragePartition{Id: id, Alias: storagePartition.Alias}); err != nil {
+		return nil, err
+	}
+	_, err = clientClerkHandler.DeleteStoragePartitionById(ctx, &pb.Id{Id: id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not DeleteStoragePartitionById: %v", err)
+	}
+	if err := store.Delete(ctx, id); err != nil {
+		return nil, errors.Wrapf(err, "Could not remove state of storage partition %s", id)
+	}
+	return storagePartition, nil
+}
+
+// Snapshot loads the entity before a mutation for the audit trail
+func Snapshot(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, kind string, id string) (any, error) {
+	switch kind {
+	case policy.KindTenant:
+		tenantPb, err := clientClerkHandler.FindTenantById(ctx, &pb.Id{Id: id})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not FindTenantById: %v", err)
+		}
+		return tenantToGraphQlTenant(tenantPb), nil
+	case policy.KindCollection:
+		return GetCollectionById(ctx, clientClerkHandler, id)
+	case policy.KindStorageLocation:
+		return GetStorageLocationById(ctx, clientClerkHandler, id)
+	case policy.KindStoragePartition:
+		return GetStoragePartitionById(ctx, clientClerkHandler, id)
+	}
+	return nil, errors.Errorf("no snapshot for %s", kind)
+}
+
+func GetAuditEvents(ctx context.Context, auditLog *audit.Log, options *model.AuditEventListOptions) (*model.AuditEventList, error) {
+	filter := audit.Filter{}
+	skip, take := 0, 100
+	if options != nil {
+		if options.Actor != nil {
+			filter.Actor = *options.Actor
+		}
+		if options.Action != nil {
+			filter.Action = options.Action.String()
+		}
+		if options.Kind != nil {
+			filter.Kind = *options.Kind
+		}
+		if options.TargetID != nil {
+			filter.TargetID = *options.TargetID
+		}
+		if options.Source != nil {
+			filter.Source = strings.ToUpper(*options.Source)
+		}
+		var err error
+		if options.From != nil {
+			if filter.From, err = time.Parse(time.RFC3339, *options.From); err != nil {
+				return nil, errors.Errorf("Invalid pagination arguments: from is no RFC 3339 time: %v", err)
 			}
-			if count == len(tenantList)-1 {
-				return nil, errors.New("You are not allowed to proceed with deleting the storage partition")
+		}
+		if options.To != nil {
+			if filter.To, err = time.Parse(time.RFC3339, *options.To); err != nil {
+				return nil, errors.Errorf("Invalid pagination arguments: to is no RFC 3339 time: %v", err)
 			}
 		}
-	} else {
-		return nil, errors.New("You are not allowed to proceed with deleting the storage partition")
+		if options.Skip != nil {
+			skip = max(*options.Skip, 0)
+		}
+		if options.Take != nil {
+			if *options.Take > 1000 {
+				return nil, errors.New("You could not retrieve more than 1000 audit events")
+			}
+			take = max(*options.Take, 0)
+		}
 	}
-	storagePartition, err := GetStoragePartitionById(ctx, clientClerkHandler, id)
+	events, total, err := auditLog.Query(ctx, filter, skip, take)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
+		return nil, errors.Wrapf(err, "Could not query audit events: %v", err)
 	}
-	_, err = clientClerkHandler.DeleteStoragePartitionById(ctx, &pb.Id{Id: id})
-	if err != nil {
-		return nil, errors.Wrapf(err, "Could not DeleteStoragePartitionById: %v", err)
+	items := make([]*model.AuditEvent, 0, len(events))
+	for _, event := range events {
+		items = append(items, auditEventToGraphQlAuditEvent(event))
 	}
-	return storagePartition, nil
+	return &model.AuditEventList{Items: items, TotalItems: total}, nil
+}
+
+func auditEventToGraphQlAuditEvent(event audit.Event) *model.AuditEvent {
+	auditEvent := model.AuditEvent{
+		ID:       event.ID,
+		Time:     event.Time.Format(time.RFC3339),
+		Actor:    event.Actor,
+		Subject:  event.Subject,
+		Source:   event.Source,
+		IP:       event.IP,
+		Action:   model.TenantAction(event.Action),
+		Kind:     event.Kind,
+		TargetID: event.TargetID,
+	}
+	if len(event.Before) > 0 {
+		before := string(event.Before)
+		auditEvent.Before = &before
+	}
+	if len(event.After) > 0 {
+		after := string(event.After)
+		auditEvent.After = &after
+	}
+	return &auditEvent
 }
 
 func tenantToGraphQlTenant(tenantPb *pb.Tenant) *model.Tenant {
@@ -1801,7 +2180,8 @@ func storageLocationToGraphQlStorageLocation(storageLocationPb *pb.StorageLocati
 	storageLocation.Alias = storageLocationPb.Alias
 	storageLocation.Type = storageLocationPb.Type
 	storageLocation.Vault = storageLocationPb.Vault
-	storageLocation.Connection = "xxxxxxxxxxxxxx"
+	storageLocation.Connection = redactedConnection
+	storageLocation.ConnectionConfig = storageConnectionToGraphQlStorageConnection(storageLocationPb.Connection)
 	storageLocation.Quality = int(storageLocationPb.Quality)
 	storageLocation.Price = int(storageLocationPb.Price)
 	storageLocation.SecurityCompliency = storageLocationPb.SecurityCompliency
@@ -1820,7 +2200,6 @@ func storageLocationInputToGrpcStorageLocation(storageLocationInput *model.Stora
 	storageLocationPb.Alias = storageLocationInput.Alias
 	storageLocationPb.Type = storageLocationInput.Type
 	storageLocationPb.Vault = storageLocationInput.Vault
-	storageLocationPb.Connection = storageLocationInput.Connection
 	storageLocationPb.Quality = int32(storageLocationInput.Quality)
 	storageLocationPb.Price = int32(storageLocationInput.Price)
 	storageLocationPb.SecurityCompliency = storageLocationInput.SecurityCompliency
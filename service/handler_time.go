@@ -0,0 +1,20 @@
+package service
+
+import (
+	"strings"
+	"time"
+)
+
+// handlerTimeLayouts are the formats the handler returns times in
+var handlerTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999-07", "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"}
+
+// parseHandlerTime reads a time as the handler returns it, e.g. the creation time of object instances
+func parseHandlerTime(value string) (time.Time, bool) {
+	for _, layout := range handlerTimeLayouts {
+		t, err := time.Parse(layout, strings.TrimSpace(value))
+		if err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/dataloader"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+// collectionDeleteImpact reports the objects of the collection, which lose all their instances with it
+func collectionDeleteImpact(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, collectionPb *pb.Collection) (*model.DeleteImpact, error) {
+	impact := &model.DeleteImpact{}
+	err := scanObjectInstances(ctx, clientClerkHandler, []*pb.Collection{collectionPb}, func(collectionPb *pb.Collection, objectPb *pb.Object, objectInstancesPb []*pb.ObjectInstance) error {
+		impact.Objects++
+		impact.Files += int(objectPb.TotalFileCount)
+		impact.ObjectInstances += len(objectInstancesPb)
+		for _, objectInstancePb := range objectInstancesPb {
+			impact.TotalSize += float64(objectInstancePb.Size)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	impact.ObjectsLosingLastCopy = impact.Objects
+	impact.LastCopyLost = impact.Objects > 0
+	return impact, nil
+}
+
+// instancesDeleteImpact reports the object instances on the removed partitions. The quality an object has is
+// the sum of the qualities of the storage locations holding an instance of it. An object is counted as below its
+// needed quality if it ends up below it with less quality than it has now, whether it reaches it now or not.
+func instancesDeleteImpact(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, removedPartitions []string) (*model.DeleteImpact, error) {
+	removed := make(map[string]bool, len(removedPartitions))
+	for _, storagePartitionId := range removedPartitions {
+		removed[storagePartitionId] = true
+	}
+	impact := &model.DeleteImpact{}
+	objectIds := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, storagePartitionId := range removedPartitions {
+		for skip := int32(0); ; skip += 1000 {
+			objectInstancesPb, err := clientClerkHandler.GetObjectInstancesByStoragePartitionIdPaginated(ctx, &pb.Pagination{Id: storagePartitionId, Skip: skip, Take: 1000, SortKey: "ID", SortDirection: sortDirectionAscending})
+			if err != nil {
+				return nil, errors.Wrapf(err, "Could not GetObjectInstancesByStoragePartitionIdPaginated: %v", err)
+			}
+			for _, objectInstancePb := range objectInstancesPb.ObjectInstances {
+				impact.ObjectInstances++
+				impact.TotalSize += float64(objectInstancePb.Size)
+				if !seen[objectInstancePb.ObjectId] {
+					seen[objectInstancePb.ObjectId] = true
+					objectIds = append(objectIds, objectInstancePb.ObjectId)
+				}
+			}
+			if len(objectInstancesPb.ObjectInstances) == 0 || int64(skip)+1000 >= int64(objectInstancesPb.TotalItems) {
+				break
+			}
+		}
+	}
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	objectsPb, err := loaders.Object.LoadAll(ctx, objectIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetObjectById: %v", err)
+	}
+	neededQualities, err := loaders.ObjectNeededQuality.LoadAll(ctx, objectIds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetNeededQualityForObject: %v", err)
+	}
+	for i, objectPb := range objectsPb {
+		impact.Objects++
+		impact.Files += int(objectPb.TotalFileCount)
+		objectInstancesPb, err := getAllObjectInstancesForObject(ctx, clientClerkHandler, objectIds[i])
+		if err != nil {
+			return nil, err
+		}
+		// storage location id -> whether an instance on it is kept
+		locations := make(map[string]bool)
+		for _, objectInstancePb := range objectInstancesPb {
+			storagePartitionPb, err := loaders.StoragePartition.Load(ctx, objectInstancePb.StoragePartitionId)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
+			}
+			locations[storagePartitionPb.StorageLocationId] = locations[storagePartitionPb.StorageLocationId] || !removed[objectInstancePb.StoragePartitionId]
+		}
+		quality, remainingQuality, kept := 0, 0, 0
+		for storageLocationId, keeps := range locations {
+			storageLocationPb, err := loaders.StorageLocation.Load(ctx, storageLocationId)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
+			}
+			quality += int(storageLocationPb.Quality)
+			if keeps {
+				remainingQuality += int(storageLocationPb.Quality)
+				kept++
+			}
+		}
+		if kept == 0 {
+			impact.ObjectsLosingLastCopy++
+			impact.LastCopyLost = true
+		}
+		if int64(remainingQuality) < neededQualities[i] && remainingQuality < quality {
+			impact.ObjectsBelowNeededQuality++
+		}
+	}
+	return impact, nil
+}
+
+// getAllObjectInstancesForObject returns all instances of the object, read once per request
+func getAllObjectInstancesForObject(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, objectId string) ([]*pb.ObjectInstance, error) {
+	objectInstancesPb, err := dataloader.For(ctx, clientClerkHandler).ObjectObjectInstances.Load(ctx, objectId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetObjectInstancesByObjectIdPaginated: %v", err)
+	}
+	return objectInstancesPb, nil
+}
+
+// scanObjectInstances visits the objects of the collections with their instances. Objects and instances are
+// read through the loaders of the request, so the reports and counts of one query share them.
+func scanObjectInstances(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, collectionsPb []*pb.Collection, visit func(collectionPb *pb.Collection, objectPb *pb.Object, objectInstancesPb []*pb.ObjectInstance) error) error {
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	for _, collectionPb := range collectionsPb {
+		objectsPb, err := loaders.CollectionObjects.Load(ctx, collectionPb.Id)
+		if err != nil {
+			return errors.Wrapf(err, "Could not GetObjectsByCollectionIdPaginated: %v", err)
+		}
+		objectIds := make([]string, 0, len(objectsPb))
+		for _, objectPb := range objectsPb {
+			objectIds = append(objectIds, objectPb.Id)
+		}
+		objectInstancesPb, err := loaders.ObjectObjectInstances.LoadAll(ctx, objectIds)
+		if err != nil {
+			return errors.Wrapf(err, "Could not GetObjectInstancesByObjectIdPaginated: %v", err)
+		}
+		for i, objectPb := range objectsPb {
+			if err := visit(collectionPb, objectPb, objectInstancesPb[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scanStorageLocationObjectInstances visits the object instances on the partitions of the storage location, read
+// once per request
+func scanStorageLocationObjectInstances(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, storageLocationId string, visit func(objectInstancePb *pb.ObjectInstance) error) error {
+	storagePartitionsPb, err := getAllStoragePartitionsForLocation(ctx, clientClerkHandler, storageLocationId)
+	if err != nil {
+		return err
+	}
+	objectInstancesPb, err := dataloader.For(ctx, clientClerkHandler).StoragePartitionInstances.LoadAll(ctx, partitionIds(storagePartitionsPb))
+	if err != nil {
+		return errors.Wrapf(err, "Could not GetObjectInstancesByStoragePartitionIdPaginated: %v", err)
+	}
+	for _, partitionInstancesPb := range objectInstancesPb {
+		for _, objectInstancePb := range partitionInstancesPb {
+			if err := visit(objectInstancePb); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RefuseStorageLocationQualityLoss blocks the delete of the storage location if objects would lose their last copy
+// or drop below their needed quality with it. Outside of a GraphQL request it reads through loaders of its own.
+func RefuseStorageLocationQualityLoss(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, storageLocationPb *pb.StorageLocation) error {
+	ctx = dataloader.WithLoaders(ctx, dataloader.For(ctx, clientClerkHandler))
+	storagePartitionsPb, err := getAllStoragePartitionsForLocation(ctx, clientClerkHandler, storageLocationPb.Id)
+	if err != nil {
+		return err
+	}
+	impact, err := instancesDeleteImpact(ctx, clientClerkHandler, partitionIds(storagePartitionsPb))
+	if err != nil {
+		return err
+	}
+	return refuseQualityLoss("storage location", storageLocationPb.Alias, impact)
+}
+
+// refuseQualityLoss blocks real deletes removing the last copy of objects or lowering the quality of objects below
+// their needed quality
+func refuseQualityLoss(kind string, alias string, impact *model.DeleteImpact) error {
+	if impact.ObjectsLosingLastCopy > 0 {
+		return errors.Errorf("Deleting %s %s would remove the last copy of %d objects", kind, alias, impact.ObjectsLosingLastCopy)
+	}
+	if impact.ObjectsBelowNeededQuality > 0 {
+		return errors.Errorf("Deleting %s %s would drop %d objects below their needed quality", kind, alias, impact.ObjectsBelowNeededQuality)
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/dataloader"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	"github.com/ocfl-archive/dlza-manager-clerk/validation"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+const (
+	integrityReportTopErrors  = 10
+	integrityReportMaxBuckets = 1000
+)
+
+// IntegrityReportArguments select the object instances of the report, by collection, storage location or both,
+// or all of the tenant, and the time range [From, To)
+type IntegrityReportArguments struct {
+	TenantID          *string
+	CollectionID      *string
+	StorageLocationID *string
+	From              string
+	To                string
+	Bucket            model.IntegrityReportBucket
+}
+
+// IntegrityReport aggregates the checks of the object instances run within the time range, in total and per bucket
+func IntegrityReport(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, args IntegrityReportArguments) (*model.IntegrityReport, error) {
+	if args.Bucket == "" {
+		args.Bucket = model.IntegrityReportBucketMonth
+	}
+	from, fromOk := parseReportTime(args.From)
+	to, toOk := parseReportTime(args.To)
+	if err := validation.Validate("integrity report arguments",
+		validation.Field("tenantId", firstString(args.TenantID, args.CollectionID, args.StorageLocationID), validation.Func("scope", func(value any) string {
+			if value == "" {
+				return "or collectionId or storageLocationId is needed"
+			}
+			return ""
+		})),
+		validation.Field("from", args.From, validation.Func("time", func(value any) string {
+			if !fromOk {
+				return "should be a date as YYYY-MM-DD or a time as RFC 3339"
+			}
+			return ""
+		})),
+		validation.Field("to", args.To, validation.Func("time", func(value any) string {
+			switch {
+			case !toOk:
+				return "should be a date as YYYY-MM-DD or a time as RFC 3339"
+			case fromOk && !to.After(from):
+				return "should be after from"
+			case fromOk && len(reportBuckets(from, to, args.Bucket)) > integrityReportMaxBuckets:
+				return "should be at most " + strconv.Itoa(integrityReportMaxBuckets) + " buckets after from"
+			}
+			return ""
+		})),
+	); err != nil {
+		return nil, err
+	}
+	objectInstanceIds, err := integrityReportObjectInstances(ctx, clientClerkHandler, args)
+	if err != nil {
+		return nil, err
+	}
+	report := &model.IntegrityReport{
+		TenantID:          args.TenantID,
+		CollectionID:      args.CollectionID,
+		StorageLocationID: args.StorageLocationID,
+		From:              from.Format(time.RFC3339),
+		To:                to.Format(time.RFC3339),
+		Bucket:            args.Bucket,
+		ObjectInstances:   len(objectInstanceIds),
+		TopErrors:         make([]*model.IntegrityErrorCount, 0),
+		Buckets:           make([]*model.IntegrityReportBucketStats, 0),
+	}
+	starts := reportBuckets(from, to, args.Bucket)
+	for i, start := range starts {
+		end := to
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		report.Buckets = append(report.Buckets, &model.IntegrityReportBucketStats{Start: start.Format(time.RFC3339), End: end.Format(time.RFC3339)})
+	}
+	// the time since the last successful check is taken at the end of the range, or now if it is not over yet
+	at := to
+	if now := time.Now(); now.Before(at) {
+		at = now
+	}
+	messages := make(map[string]int)
+	var sinceSuccess time.Duration
+	for _, objectInstanceId := range objectInstanceIds {
+		failing := false
+		failingIn := make(map[int]bool)
+		var lastSuccess time.Time
+		err := visitObjectInstanceChecks(ctx, clientClerkHandler, objectInstanceId, func(checkPb *pb.ObjectInstanceCheck) bool {
+			checked, ok := parseHandlerTime(checkPb.CheckTime)
+			if !ok || !checked.Before(at) {
+				return true
+			}
+			// the checks come newest first, the first without error is the last success
+			if !checkPb.Error && lastSuccess.IsZero() {
+				lastSuccess = checked
+			}
+			if checked.Before(from) {
+				// older checks only matter until the last success is found
+				return lastSuccess.IsZero()
+			}
+			i := bucketIndex(starts, checked)
+			report.Checks++
+			report.Buckets[i].Checks++
+			if !checkPb.Error {
+				return true
+			}
+			report.Errors++
+			report.Buckets[i].Errors++
+			messages[checkPb.Message]++
+			failing = true
+			failingIn[i] = true
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if failing {
+			report.FailingObjectInstances++
+		}
+		for i := range failingIn {
+			report.Buckets[i].FailingObjectInstances++
+		}
+		if lastSuccess.IsZero() {
+			report.NeverSucceeded++
+			continue
+		}
+		sinceSuccess += at.Sub(lastSuccess)
+	}
+	if succeeded := report.ObjectInstances - report.NeverSucceeded; succeeded > 0 {
+		hours := sinceSuccess.Hours() / float64(succeeded)
+		report.MeanHoursSinceLastSuccess = &hours
+	}
+	for message, count := range messages {
+		report.TopErrors = append(report.TopErrors, &model.IntegrityErrorCount{Message: message, Count: count})
+	}
+	slices.SortFunc(report.TopErrors, func(a, b *model.IntegrityErrorCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Message, b.Message)
+	})
+	report.TopErrors = report.TopErrors[:min(len(report.TopErrors), integrityReportTopErrors)]
+	return report, nil
+}
+
+// integrityReportObjectInstances lists the object instances of the collection on the storage location, of the
+// collection, of the storage location or of all collections of the tenant
+func integrityReportObjectInstances(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, args IntegrityReportArguments) ([]string, error) {
+	objectInstanceIds := make([]string, 0)
+	storageLocationId := firstString(args.StorageLocationID)
+	if collectionId := firstString(args.CollectionID); collectionId != "" || storageLocationId == "" {
+		collectionsPb, err := replicationCollections(ctx, clientClerkHandler, args.TenantID, args.CollectionID)
+		if err != nil {
+			return nil, err
+		}
+		loaders := dataloader.For(ctx, clientClerkHandler)
+		err = scanObjectInstances(ctx, clientClerkHandler, collectionsPb, func(collectionPb *pb.Collection, objectPb *pb.Object, objectInstancesPb []*pb.ObjectInstance) error {
+			for _, objectInstancePb := range objectInstancesPb {
+				if storageLocationId != "" {
+					storagePartitionPb, err := loaders.StoragePartition.Load(ctx, objectInstancePb.StoragePartitionId)
+					if err != nil {
+						return errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
+					}
+					if storagePartitionPb.StorageLocationId != storageLocationId {
+						continue
+					}
+				}
+				objectInstanceIds = append(objectInstanceIds, objectInstancePb.Id)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return objectInstanceIds, nil
+	}
+	err := scanStorageLocationObjectInstances(ctx, clientClerkHandler, storageLocationId, func(objectInstancePb *pb.ObjectInstance) error {
+		objectInstanceIds = append(objectInstanceIds, objectInstancePb.Id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objectInstanceIds, nil
+}
+
+// visitObjectInstanceChecks pages through the checks of the object instance, the newest first, until visit
+// returns false
+func visitObjectInstanceChecks(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, objectInstanceId string, visit func(checkPb *pb.ObjectInstanceCheck) bool) error {
+	for skip := int32(0); ; skip += 1000 {
+		checksPb, err := clientClerkHandler.GetObjectInstanceChecksByObjectInstanceIdPaginated(ctx, &pb.Pagination{Id: objectInstanceId, Skip: skip, Take: 1000, SortKey: "checktime", SortDirection: sortDirectionDescending})
+		if err != nil {
+			return errors.Wrapf(err, "Could not GetObjectInstanceChecksByObjectInstanceIdPaginated: %v", err)
+		}
+		for _, checkPb := range checksPb.ObjectInstanceChecks {
+			if !visit(checkPb) {
+				return nil
+			}
+		}
+		if len(checksPb.ObjectInstanceChecks) == 0 || int64(skip)+1000 >= checksPb.TotalItems {
+			return nil
+		}
+	}
+}
+
+// parseReportTime reads a date as YYYY-MM-DD or a time as RFC 3339
+func parseReportTime(value string) (time.Time, bool) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// reportBuckets returns the starts of the buckets covering [from, to). The first starts at from, the others at
+// the beginning of a day, a week from monday or a month in UTC.
+func reportBuckets(from time.Time, to time.Time, bucket model.IntegrityReportBucket) []time.Time {
+	starts := []time.Time{from}
+	year, month, day := from.Date()
+	next := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case model.IntegrityReportBucketWeek:
+		next = next.AddDate(0, 0, -(int(next.Weekday())+6)%7)
+	case model.IntegrityReportBucketMonth:
+		next = time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+	for {
+		switch bucket {
+		case model.IntegrityReportBucketDay:
+			next = next.AddDate(0, 0, 1)
+		case model.IntegrityReportBucketWeek:
+			next = next.AddDate(0, 0, 7)
+		default:
+			next = next.AddDate(0, 1, 0)
+		}
+		if !next.Before(to) || len(starts) > integrityReportMaxBuckets {
+			return starts
+		}
+		starts = append(starts, next)
+	}
+}
+
+// bucketIndex returns the bucket of a time within the range
+func bucketIndex(starts []time.Time, t time.Time) int {
+	i, found := slices.BinarySearchFunc(starts, t, func(start time.Time, t time.Time) int {
+		return start.Compare(t)
+	})
+	if found {
+		return i
+	}
+	return i - 1
+}
+
+// firstString returns the first of the optional arguments which is set
+func firstString(values ...*string) string {
+	for _, value := range values {
+		if value != nil && *value != "" {
+			return *value
+		}
+	}
+	return ""
+}
+
+// WriteIntegrityReportCSV writes one line per bucket, one with the totals of the range and one per top error
+func WriteIntegrityReportCSV(w io.Writer, report *model.IntegrityReport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"row", "start", "end", "checks", "errors", "failing_object_instances", "object_instances", "never_succeeded", "mean_hours_since_last_success", "message"}); err != nil {
+		return errors.Wrap(err, "cannot write integrity report")
+	}
+	records := make([][]string, 0, len(report.Buckets)+len(report.TopErrors)+1)
+	for _, bucket := range report.Buckets {
+		records = append(records, []string{"bucket", bucket.Start, bucket.End, strconv.Itoa(bucket.Checks), strconv.Itoa(bucket.Errors), strconv.Itoa(bucket.FailingObjectInstances), "", "", "", ""})
+	}
+	meanHours := ""
+	if report.MeanHoursSinceLastSuccess != nil {
+		meanHours = strconv.FormatFloat(*report.MeanHoursSinceLastSuccess, 'f', 1, 64)
+	}
+	records = append(records, []string{"total", report.From, report.To, strconv.Itoa(report.Checks), strconv.Itoa(report.Errors), strconv.Itoa(report.FailingObjectInstances), strconv.Itoa(report.ObjectInstances), strconv.Itoa(report.NeverSucceeded), meanHours, ""})
+	for _, topError := range report.TopErrors {
+		records = append(records, []string{"error", report.From, report.To, "", strconv.Itoa(topError.Count), "", "", "", "", topError.Message})
+	}
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			return errors.Wrap(err, "cannot write integrity report")
+		}
+	}
+	writer.Flush()
+	return errors.Wrap(writer.Error(), "cannot write integrity report")
+}
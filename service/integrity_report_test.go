@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestReportBuckets(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   string
+		to     string
+		bucket model.IntegrityReportBucket
+		starts []string
+	}{
+		{"days", "2026-10-18T12:00:00Z", "2026-10-20T00:00:00Z", model.IntegrityReportBucketDay, []string{"2026-10-18T12:00:00Z", "2026-10-19T00:00:00Z"}},
+		{"weeks from monday", "2026-10-14T00:00:00Z", "2026-10-28T00:00:00Z", model.IntegrityReportBucketWeek, []string{"2026-10-14T00:00:00Z", "2026-10-19T00:00:00Z", "2026-10-26T00:00:00Z"}},
+		{"months", "2025-01-15T00:00:00Z", "2025-03-10T00:00:00Z", model.IntegrityReportBucketMonth, []string{"2025-01-15T00:00:00Z", "2025-02-01T00:00:00Z", "2025-03-01T00:00:00Z"}},
+		{"within one month", "2025-01-15T00:00:00Z", "2025-01-20T00:00:00Z", model.IntegrityReportBucketMonth, []string{"2025-01-15T00:00:00Z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			starts := reportBuckets(date(tt.from), date(tt.to), tt.bucket)
+			if len(starts) != len(tt.starts) {
+				t.Fatalf("got %d buckets %v, want %v", len(starts), starts, tt.starts)
+			}
+			for i, start := range starts {
+				if !start.Equal(date(tt.starts[i])) {
+					t.Errorf("bucket %d starts at %s, want %s", i, start.Format(time.RFC3339), tt.starts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReportBucketsLimit(t *testing.T) {
+	starts := reportBuckets(date("2000-01-01T00:00:00Z"), date("2026-01-01T00:00:00Z"), model.IntegrityReportBucketDay)
+	if len(starts) <= integrityReportMaxBuckets {
+		t.Fatalf("got %d buckets, want more than %d to refuse the range", len(starts), integrityReportMaxBuckets)
+	}
+	if len(starts) > integrityReportMaxBuckets+1 {
+		t.Errorf("got %d buckets, the bucketing should stop right after the limit", len(starts))
+	}
+}
+
+func TestBucketIndex(t *testing.T) {
+	starts := []time.Time{date("2026-10-14T00:00:00Z"), date("2026-10-19T00:00:00Z"), date("2026-10-26T00:00:00Z")}
+	tests := []struct {
+		at    string
+		index int
+	}{
+		{"2026-10-14T00:00:00Z", 0},
+		{"2026-10-18T23:59:59Z", 0},
+		{"2026-10-19T00:00:00Z", 1},
+		{"2026-10-25T12:00:00Z", 1},
+		{"2026-10-26T00:00:00Z", 2},
+		{"2026-10-27T00:00:00Z", 2},
+	}
+	for _, tt := range tests {
+		if index := bucketIndex(starts, date(tt.at)); index != tt.index {
+			t.Errorf("bucketIndex(%s) = %d, want %d", tt.at, index, tt.index)
+		}
+	}
+}
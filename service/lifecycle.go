@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	"github.com/ocfl-archive/dlza-manager-clerk/lifecycle"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+// SetStoragePartitionState moves the partition along its lifecycle. A partition leaving the active state gets its
+// limits pinned to the current fill, so the handler places no new object instances on it, activating it again
+// restores the limits it had.
+func SetStoragePartitionState(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, store *lifecycle.Store, id string, state model.StoragePartitionState, reason string, actor string) (*model.StoragePartition, error) {
+	if !store.Persistent() {
+		// a state kept in memory only would come back active with pinned limits after a restart
+		return nil, errors.New("No partition state file configured, storage partition states cannot be changed")
+	}
+	storagePartitionPb, err := clientClerkHandler.GetStoragePartitionById(ctx, &pb.Id{Id: id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
+	}
+	record := store.Get(ctx, id)
+	to := lifecycle.State(state)
+	if err := lifecycle.CheckTransition(record.State, to); err != nil {
+		return nil, errors.Wrapf(err, "Storage partition %s", storagePartitionPb.Alias)
+	}
+	if to == lifecycle.Retired {
+		if err := refuseNonEmptyPartition(ctx, clientClerkHandler, storagePartitionPb); err != nil {
+			return nil, err
+		}
+	}
+	previousMaxSize, previousMaxObjects := storagePartitionPb.MaxSize, storagePartitionPb.MaxObjects
+	switch {
+	case record.State == lifecycle.Active:
+		record.MaxSize = storagePartitionPb.MaxSize
+		record.MaxObjects = storagePartitionPb.MaxObjects
+		storagePartitionPb.MaxSize = storagePartitionPb.CurrentSize
+		storagePartitionPb.MaxObjects = storagePartitionPb.CurrentObjects
+	case to == lifecycle.Active:
+		storagePartitionPb.MaxSize = record.MaxSize
+		storagePartitionPb.MaxObjects = record.MaxObjects
+	}
+	if to == lifecycle.Draining {
+		record.DrainStartSize = storagePartitionPb.CurrentSize
+		record.DrainStartObjects = storagePartitionPb.CurrentObjects
+	} else {
+		record.DrainStartSize = 0
+		record.DrainStartObjects = 0
+	}
+	if _, err := clientClerkHandler.UpdateStoragePartition(ctx, storagePartitionPb); err != nil {
+		return nil, errors.Wrapf(err, "Could not UpdateStoragePartition: %v", err)
+	}
+	record.State = to
+	record.Changed = time.Now()
+	record.ChangedBy = actor
+	record.Reason = reason
+	if err := store.Put(ctx, record); err != nil {
+		// without its state the partition has to keep the limits it had, or it would stay pinned while active
+		storagePartitionPb.MaxSize, storagePartitionPb.MaxObjects = previousMaxSize, previousMaxObjects
+		if _, rollbackErr := clientClerkHandler.UpdateStoragePartition(ctx, storagePartitionPb); rollbackErr != nil {
+			err = errors.Append(err, errors.Wrapf(rollbackErr, "Could not restore the limits of storage partition %s", storagePartitionPb.Alias))
+		}
+		return nil, errors.Wrapf(err, "Could not save state of storage partition %s", storagePartitionPb.Alias)
+	}
+	return storagePartitionToGraphQlStoragePartition(storagePartitionPb), nil
+}
+
+// GetStoragePartitionLifecycle returns the lifecycle of the partition, nil if its state was never changed
+func GetStoragePartitionLifecycle(ctx context.Context, store *lifecycle.Store, storagePartition *model.StoragePartition) *model.StoragePartitionLifecycle {
+	if !store.Known(ctx, storagePartition.ID) {
+		return nil
+	}
+	record := store.Get(ctx, storagePartition.ID)
+	result := &model.StoragePartitionLifecycle{
+		State:      model.StoragePartitionState(record.State),
+		Changed:    record.Changed.Format(time.RFC3339),
+		ChangedBy:  record.ChangedBy,
+		Reason:     record.Reason,
+		MaxSize:    int(record.MaxSize),
+		MaxObjects: int(record.MaxObjects),
+	}
+	if record.State == lifecycle.Draining {
+		progress := &model.DrainProgress{
+			StartObjects:     int(record.DrainStartObjects),
+			StartSize:        int(record.DrainStartSize),
+			RemainingObjects: storagePartition.CurrentObjects,
+			RemainingSize:    storagePartition.CurrentSize,
+			Progress:         1,
+		}
+		if record.DrainStartObjects > 0 {
+			progress.Progress = max(0, 1-float64(storagePartition.CurrentObjects)/float64(record.DrainStartObjects))
+		}
+		result.DrainProgress = progress
+	}
+	return result
+}
+
+// refuseNonEmptyPartition blocks retiring or deleting a partition still holding object instances
+func refuseNonEmptyPartition(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, storagePartitionPb *pb.StoragePartition) error {
+	objectInstancesPb, err := clientClerkHandler.GetObjectInstancesByStoragePartitionIdPaginated(ctx, &pb.Pagination{Id: storagePartitionPb.Id, Skip: 0, Take: 1, SortKey: "ID", SortDirection: sortDirectionAscending})
+	if err != nil {
+		return errors.Wrapf(err, "Could not GetObjectInstancesByStoragePartitionIdPaginated: %v", err)
+	}
+	if objectInstancesPb.TotalItems > 0 {
+		return errors.Errorf("Storage partition %s still holds %d object instances", storagePartitionPb.Alias, objectInstancesPb.TotalItems)
+	}
+	return nil
+}
+
+// refusePartitionDelete allows deleting empty partitions in any state, partitions without a recorded state
+// included. Partitions still holding object instances have to be drained and retired first.
+func refusePartitionDelete(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, store *lifecycle.Store, storagePartitionPb *pb.StoragePartition) error {
+	if err := refuseNonEmptyPartition(ctx, clientClerkHandler, storagePartitionPb); err != nil {
+		return errors.Wrapf(err, "Storage partition %s is %s, drain and retire it before deleting it", storagePartitionPb.Alias, store.Get(ctx, storagePartitionPb.Id).State)
+	}
+	return nil
+}
+
+// refuseLimitChange keeps the pinned limits of partitions which are not active
+func refuseLimitChange(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, store *lifecycle.Store, input *model.StoragePartitionInput) error {
+	if input == nil {
+		return nil
+	}
+	state := store.Get(ctx, input.ID).State
+	if state.Writable() {
+		return nil
+	}
+	storagePartitionPb, err := clientClerkHandler.GetStoragePartitionById(ctx, &pb.Id{Id: input.ID})
+	if err != nil {
+		return errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
+	}
+	if int64(input.MaxSize) != storagePartitionPb.MaxSize || int64(input.MaxObjects) != storagePartitionPb.MaxObjects {
+		return errors.Errorf("Storage partition %s is %s, its limits are kept until it is active again", storagePartitionPb.Alias, state)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/dataloader"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TenantOwners walks up the entity hierarchy to the owning tenant, using the request loaders:
+// check → object instance → object → collection → tenant, file → object and partition → location → tenant
+type TenantOwners struct {
+	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+}
+
+func NewTenantOwners(clientClerkHandler pbHandler.ClerkHandlerServiceClient) *TenantOwners {
+	return &TenantOwners{ClientClerkHandler: clientClerkHandler}
+}
+
+func (o *TenantOwners) TenantOf(ctx context.Context, kind string, id string) (string, error) {
+	loaders := dataloader.For(ctx, o.ClientClerkHandler)
+	switch kind {
+	case policy.KindTenant:
+		return id, nil
+	case policy.KindCollection:
+		collectionPb, err := loaders.Collection.Load(ctx, id)
+		if err != nil {
+			return "", lookupError(err, kind, id, "GetCollectionByIdFromMv")
+		}
+		if collectionPb.Id == "" {
+			return "", notFound(kind, id)
+		}
+		return collectionPb.TenantId, nil
+	case policy.KindStorageLocation:
+		storageLocationPb, err := loaders.StorageLocation.Load(ctx, id)
+		if err != nil {
+			return "", lookupError(err, kind, id, "GetStorageLocationById")
+		}
+		if storageLocationPb.Id == "" {
+			return "", notFound(kind, id)
+		}
+		return storageLocationPb.TenantId, nil
+	case policy.KindStoragePartition:
+		storagePartitionPb, err := loaders.StoragePartition.Load(ctx, id)
+		if err != nil {
+			return "", lookupError(err, kind, id, "GetStoragePartitionById")
+		}
+		if storagePartitionPb.Id == "" {
+			return "", notFound(kind, id)
+		}
+		return o.TenantOf(ctx, policy.KindStorageLocation, storagePartitionPb.StorageLocationId)
+	case policy.KindObject:
+		objectPb, err := loaders.Object.Load(ctx, id)
+		if err != nil {
+			return "", lookupError(err, kind, id, "GetObjectById")
+		}
+		if objectPb.Id == "" {
+			return "", notFound(kind, id)
+		}
+		return o.TenantOf(ctx, policy.KindCollection, objectPb.CollectionId)
+	case policy.KindObjectInstance:
+		objectInstancePb, err := loaders.ObjectInstance.Load(ctx, id)
+		if err != nil {
+			return "", lookupError(err, kind, id, "GetObjectInstanceById")
+		}
+		if objectInstancePb.Id == "" {
+			return "", notFound(kind, id)
+		}
+		return o.TenantOf(ctx, policy.KindObject, objectInstancePb.ObjectId)
+	case policy.KindObjectInstanceCheck:
+		objectInstanceCheckPb, err := loaders.ObjectInstanceCheck.Load(ctx, id)
+		if err != nil {
+			return "", lookupError(err, kind, id, "GetObjectInstanceCheckById")
+		}
+		if objectInstanceCheckPb.Id == "" {
+			return "", notFound(kind, id)
+		}
+		return o.TenantOf(ctx, policy.KindObjectInstance, objectInstanceCheckPb.ObjectInstanceId)
+	case policy.KindFile:
+		filePb, err := loaders.File.Load(ctx, id)
+		if err != nil {
+			return "", lookupError(err, kind, id, "GetFileById")
+		}
+		if filePb.Id == "" {
+			return "", notFound(kind, id)
+		}
+		return o.TenantOf(ctx, policy.KindObject, filePb.ObjectId)
+	}
+	return "", errors.Errorf("no owning tenant known for %s", kind)
+}
+
+func lookupError(err error, kind string, id string, method string) error {
+	if status.Code(err) == codes.NotFound {
+		return notFound(kind, id)
+	}
+	return errors.Wrapf(err, "Could not %s: %v", method, err)
+}
+
+func notFound(kind string, id string) error {
+	return errors.Errorf("%s %s could not be found", kind, id)
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/dataloader"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+)
+
+const DefaultReplicationInterval = time.Hour
+
+// underReplicatedObjectPrefix keeps the node id apart from the one of the object
+const underReplicatedObjectPrefix = "under-replicated:"
+
+// objectQuality is the needed against the resulting quality of an object
+type objectQuality struct {
+	objectPb  *pb.Object
+	tenantId  string
+	needed    int
+	resulting int
+}
+
+// scanObjectQualities visits the objects of the collections with their needed and resulting quality. The objects
+// and qualities are read through the loaders of the request, so the gaps and the list of one query share them.
+func scanObjectQualities(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, collectionsPb []*pb.Collection, visit func(quality objectQuality) error) error {
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	for _, collectionPb := range collectionsPb {
+		objectsPb, err := loaders.CollectionObjects.Load(ctx, collectionPb.Id)
+		if err != nil {
+			return errors.Wrapf(err, "Could not GetObjectsByCollectionIdPaginated: %v", err)
+		}
+		objectIds := make([]string, 0, len(objectsPb))
+		for _, objectPb := range objectsPb {
+			objectIds = append(objectIds, objectPb.Id)
+		}
+		neededQualities, err := loaders.ObjectNeededQuality.LoadAll(ctx, objectIds)
+		if err != nil {
+			return errors.Wrapf(err, "Could not GetNeededQualityForObject: %v", err)
+		}
+		resultingQualities, err := loaders.ObjectResultingQuality.LoadAll(ctx, objectIds)
+		if err != nil {
+			return errors.Wrapf(err, "Could not GetResultingQualityForObject: %v", err)
+		}
+		for i, objectPb := range objectsPb {
+			if err := visit(objectQuality{objectPb: objectPb, tenantId: collectionPb.TenantId, needed: int(neededQualities[i]), resulting: int(resultingQualities[i])}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replicationCollections returns the collection or, without one, all collections of the tenant
+func replicationCollections(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, tenantId *string, collectionId *string) ([]*pb.Collection, error) {
+	if collectionId != nil && *collectionId != "" {
+		collectionPb, err := dataloader.For(ctx, clientClerkHandler).Collection.Load(ctx, *collectionId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not GetCollectionById: %v", err)
+		}
+		return []*pb.Collection{collectionPb}, nil
+	}
+	if tenantId == nil || *tenantId == "" {
+		return nil, errors.New("Invalid pagination arguments: tenantId or collectionId is needed")
+	}
+	collectionsPb, err := clientClerkHandler.GetCollectionsByTenantId(ctx, &pb.Id{Id: *tenantId})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetCollectionsByTenantId: %v", err)
+	}
+	return collectionsPb.Collections, nil
+}
+
+// collectionGaps are the objects of a collection below their needed quality, the largest gap first
+type collectionGaps struct {
+	tenantId string
+	objects  int
+	gaps     []objectQuality
+}
+
+// ReplicationScanner computes the replication gaps of all collections every interval. The handler has no query
+// for the gaps, so only the scan walks all objects, the gaps and the under-replicated objects of the queries are
+// read from its last result.
+type ReplicationScanner struct {
+	ClientClerkHandler pbHandler.ClerkHandlerServiceClient
+	Interval           time.Duration
+	Logger             zLogger.ZLogger
+	lock               sync.RWMutex
+	scanned            time.Time
+	// collection id -> gaps, nil until the first scan is done
+	collections map[string]*collectionGaps
+}
+
+func NewReplicationScanner(clientClerkHandler pbHandler.ClerkHandlerServiceClient, interval time.Duration, logger zLogger.ZLogger) *ReplicationScanner {
+	if interval <= 0 {
+		interval = DefaultReplicationInterval
+	}
+	return &ReplicationScanner{
+		ClientClerkHandler: clientClerkHandler,
+		Interval:           interval,
+		Logger:             logger,
+	}
+}
+
+// Start scans every interval until ctx is done
+func (s *ReplicationScanner) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Scan(ctx); err != nil && ctx.Err() == nil {
+				s.Logger.Warn().Msgf("cannot compute replication gaps: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Scan computes the gaps of the collections of all tenants. A failing tenant keeps the gaps of the scan before,
+// the errors of the tenants are returned together.
+func (s *ReplicationScanner) Scan(ctx context.Context) error {
+	tenantsPb, err := s.ClientClerkHandler.FindAllTenants(ctx, &pb.NoParam{})
+	if err != nil {
+		return errors.Wrapf(err, "Could not FindAllTenants: %v", err)
+	}
+	collections := make(map[string]*collectionGaps)
+	var result error
+	for _, tenantPb := range tenantsPb.Tenants {
+		// the loaders cache the objects of one tenant only
+		tenantCtx := dataloader.WithLoaders(ctx, dataloader.NewLoaders(s.ClientClerkHandler))
+		tenantCollections, err := s.scanTenant(tenantCtx, tenantPb.Id)
+		if err != nil {
+			result = errors.Append(result, errors.Wrapf(err, "cannot compute replication gaps of tenant %s", tenantPb.Alias))
+			s.lock.RLock()
+			for collectionId, gaps := range s.collections {
+				if gaps.tenantId == tenantPb.Id {
+					collections[collectionId] = gaps
+				}
+			}
+			s.lock.RUnlock()
+			continue
+		}
+		maps.Copy(collections, tenantCollections)
+	}
+	s.lock.Lock()
+	s.collections = collections
+	s.scanned = time.Now()
+	s.lock.Unlock()
+	return result
+}
+
+func (s *ReplicationScanner) scanTenant(ctx context.Context, tenantId string) (map[string]*collectionGaps, error) {
+	collectionsPb, err := s.ClientClerkHandler.GetCollectionsByTenantId(ctx, &pb.Id{Id: tenantId})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not GetCollectionsByTenantId: %v", err)
+	}
+	collections := make(map[string]*collectionGaps, len(collectionsPb.Collections))
+	for _, collectionPb := range collectionsPb.Collections {
+		collections[collectionPb.Id] = &collectionGaps{tenantId: tenantId, gaps: make([]objectQuality, 0)}
+	}
+	err = scanObjectQualities(ctx, s.ClientClerkHandler, collectionsPb.Collections, func(quality objectQuality) error {
+		gaps, ok := collections[quality.objectPb.CollectionId]
+		if !ok {
+			return nil
+		}
+		gaps.objects++
+		if quality.resulting < quality.needed {
+			gaps.gaps = append(gaps.gaps, quality)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, gaps := range collections {
+		sortQualityGaps(gaps.gaps)
+	}
+	return collections, nil
+}
+
+// gaps returns the gaps of the collection or of all collections of the tenant with the time of the scan. A
+// collection created after the scan has none yet.
+func (s *ReplicationScanner) gaps(tenantId *string, collectionId *string) ([]*collectionGaps, time.Time, error) {
+	if firstString(tenantId, collectionId) == "" {
+		return nil, time.Time{}, errors.New("Invalid pagination arguments: tenantId or collectionId is needed")
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.collections == nil {
+		return nil, time.Time{}, errors.New("The replication gaps are not computed yet, retry later")
+	}
+	result := make([]*collectionGaps, 0)
+	if id := firstString(collectionId); id != "" {
+		if gaps, ok := s.collections[id]; ok {
+			result = append(result, gaps)
+		}
+		return result, s.scanned, nil
+	}
+	for _, gaps := range s.collections {
+		if gaps.tenantId == *tenantId {
+			result = append(result, gaps)
+		}
+	}
+	return result, s.scanned, nil
+}
+
+// sortQualityGaps orders the objects by their gap, the largest first
+func sortQualityGaps(gaps []objectQuality) {
+	slices.SortFunc(gaps, func(a, b objectQuality) int {
+		if c := cmp.Compare(b.needed-b.resulting, a.needed-a.resulting); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.objectPb.Id, b.objectPb.Id)
+	})
+}
+
+// GetUnderReplicatedObjects lists the objects whose resulting quality is below the needed one, the largest gap
+// first, as of the last scan. Only the instances of the objects of the page are read.
+func GetUnderReplicatedObjects(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, scanner *ReplicationScanner, tenantId *string, collectionId *string, skip *int, take *int) (*model.UnderReplicatedObjectList, error) {
+	offset, limit := 0, 100
+	if skip != nil {
+		offset = *skip
+	}
+	if take != nil {
+		if *take > 1000 {
+			return nil, errors.New("You could not retrieve more than 1000 objects")
+		}
+		limit = *take
+	}
+	if offset < 0 || limit < 0 {
+		return nil, errors.New("Invalid pagination arguments: skip and take must not be negative")
+	}
+	collections, _, err := scanner.gaps(tenantId, collectionId)
+	if err != nil {
+		return nil, err
+	}
+	gaps := make([]objectQuality, 0)
+	for _, collection := range collections {
+		gaps = append(gaps, collection.gaps...)
+	}
+	if len(collections) > 1 {
+		sortQualityGaps(gaps)
+	}
+	list := &model.UnderReplicatedObjectList{Items: make([]*model.UnderReplicatedObject, 0), TotalItems: len(gaps)}
+	if offset >= len(gaps) {
+		return list, nil
+	}
+	storageLocations := make(map[string][]*pb.StorageLocation)
+	for _, quality := range gaps[offset:min(len(gaps), offset+limit)] {
+		if _, ok := storageLocations[quality.tenantId]; !ok {
+			storageLocationsPb, err := clientClerkHandler.GetStorageLocationsByTenantId(ctx, &pb.Id{Id: quality.tenantId})
+			if err != nil {
+				return nil, errors.Wrapf(err, "Could not GetStorageLocationsByTenantId: %v", err)
+			}
+			storageLocations[quality.tenantId] = storageLocationsPb.StorageLocations
+		}
+		item, err := underReplicatedObject(ctx, clientClerkHandler, quality, storageLocations[quality.tenantId])
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, item)
+	}
+	return list, nil
+}
+
+// underReplicatedObject adds the instances of the object and the storage locations of the tenant holding none
+func underReplicatedObject(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, quality objectQuality, storageLocationsPb []*pb.StorageLocation) (*model.UnderReplicatedObject, error) {
+	objectInstancesPb, err := getAllObjectInstancesForObject(ctx, clientClerkHandler, quality.objectPb.Id)
+	if err != nil {
+		return nil, err
+	}
+	loaders := dataloader.For(ctx, clientClerkHandler)
+	item := &model.UnderReplicatedObject{
+		ID:                      underReplicatedObjectPrefix + quality.objectPb.Id,
+		Object:                  objectToGraphQlObject(quality.objectPb),
+		CollectionID:            quality.objectPb.CollectionId,
+		NeededQuality:           quality.needed,
+		ResultingQuality:        quality.resulting,
+		QualityGap:              quality.needed - quality.resulting,
+		Instances:               make([]*model.ReplicaStatus, 0, len(objectInstancesPb)),
+		MissingStorageLocations: make([]*model.StorageLocation, 0),
+	}
+	held := make(map[string]bool)
+	for _, objectInstancePb := range objectInstancesPb {
+		storagePartitionPb, err := loaders.StoragePartition.Load(ctx, objectInstancePb.StoragePartitionId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not GetStoragePartitionById: %v", err)
+		}
+		storageLocationPb, err := loaders.StorageLocation.Load(ctx, storagePartitionPb.StorageLocationId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not GetStorageLocationById: %v", err)
+		}
+		held[storageLocationPb.Id] = true
+		item.Instances = append(item.Instances, &model.ReplicaStatus{
+			ObjectInstanceID:     objectInstancePb.Id,
+			StorageLocationID:    storageLocationPb.Id,
+			StorageLocationAlias: storageLocationPb.Alias,
+			Quality:              int(storageLocationPb.Quality),
+			Status:               objectInstancePb.Status,
+		})
+	}
+	for _, storageLocationPb := range storageLocationsPb {
+		if !held[storageLocationPb.Id] {
+			item.MissingStorageLocations = append(item.MissingStorageLocations, storageLocationToGraphQlStorageLocation(storageLocationPb))
+		}
+	}
+	return item, nil
+}
+
+// GetReplicationGap summarises the objects of the tenant or collection below their needed quality as of the last scan
+func GetReplicationGap(scanner *ReplicationScanner, tenantId *string, collectionId *string) (*model.ReplicationGap, error) {
+	collections, scanned, err := scanner.gaps(tenantId, collectionId)
+	if err != nil {
+		return nil, err
+	}
+	gap := &model.ReplicationGap{ComputedAt: scanned.Format(time.RFC3339)}
+	for _, collection := range collections {
+		gap.Objects += collection.objects
+		gap.UnderReplicatedObjects += len(collection.gaps)
+		for _, quality := range collection.gaps {
+			gap.MissingQuality += quality.needed - quality.resulting
+			gap.LargestGap = max(gap.LargestGap, quality.needed-quality.resulting)
+		}
+	}
+	return gap, nil
+}
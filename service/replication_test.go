@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+	"google.golang.org/grpc"
+)
+
+type replicationHandler struct {
+	pbHandler.ClerkHandlerServiceClient
+	failTenant string
+	objects    map[string][]*pb.Object
+	needed     map[string]int64
+	resulting  map[string]int64
+}
+
+func (h *replicationHandler) FindAllTenants(_ context.Context, _ *pb.NoParam, _ ...grpc.CallOption) (*pb.Tenants, error) {
+	return &pb.Tenants{Tenants: []*pb.Tenant{{Id: "t1", Alias: "t1"}, {Id: "t2", Alias: "t2"}}}, nil
+}
+
+func (h *replicationHandler) GetCollectionsByTenantId(_ context.Context, in *pb.Id, _ ...grpc.CallOption) (*pb.Collections, error) {
+	if in.Id == h.failTenant {
+		return nil, context.DeadlineExceeded
+	}
+	return &pb.Collections{Collections: []*pb.Collection{{Id: "c-" + in.Id, TenantId: in.Id}}}, nil
+}
+
+func (h *replicationHandler) GetObjectsByCollectionIdPaginated(_ context.Context, in *pb.Pagination, _ ...grpc.CallOption) (*pb.Objects, error) {
+	return &pb.Objects{Objects: h.objects[in.Id]}, nil
+}
+
+func (h *replicationHandler) GetNeededQualityForObject(_ context.Context, in *pb.Id, _ ...grpc.CallOption) (*pb.SizeAndId, error) {
+	return &pb.SizeAndId{Size: h.needed[in.Id]}, nil
+}
+
+func (h *replicationHandler) GetResultingQualityForObject(_ context.Context, in *pb.Id, _ ...grpc.CallOption) (*pb.SizeAndId, error) {
+	return &pb.SizeAndId{Size: h.resulting[in.Id]}, nil
+}
+
+func TestReplicationScanner(t *testing.T) {
+	ctx := context.Background()
+	handler := &replicationHandler{
+		objects: map[string][]*pb.Object{
+			"c-t1": {{Id: "o1", CollectionId: "c-t1"}, {Id: "o2", CollectionId: "c-t1"}, {Id: "o3", CollectionId: "c-t1"}},
+			"c-t2": {{Id: "o4", CollectionId: "c-t2"}},
+		},
+		needed:    map[string]int64{"o1": 3, "o2": 3, "o3": 3, "o4": 2},
+		resulting: map[string]int64{"o1": 1, "o2": 2, "o3": 3, "o4": 0},
+	}
+	scanner := NewReplicationScanner(handler, 0, nil)
+	tenantId := "t1"
+	if _, err := GetReplicationGap(scanner, &tenantId, nil); err == nil || !strings.Contains(err.Error(), "not computed yet") {
+		t.Fatalf("err = %v before the first scan, want not computed yet", err)
+	}
+	if err := scanner.Scan(ctx); err != nil {
+		t.Fatal(err)
+	}
+	gap, err := GetReplicationGap(scanner, &tenantId, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gap.Objects != 3 || gap.UnderReplicatedObjects != 2 || gap.MissingQuality != 3 || gap.LargestGap != 2 {
+		t.Errorf("gap = %+v, want 3 objects, 2 under-replicated, 3 missing, largest 2", gap)
+	}
+	take := 0
+	list, err := GetUnderReplicatedObjects(ctx, handler, scanner, &tenantId, nil, nil, &take)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.TotalItems != 2 || len(list.Items) != 0 {
+		t.Errorf("list has %d of %d items, want 0 of 2", len(list.Items), list.TotalItems)
+	}
+
+	// a failing tenant keeps the gaps of the scan before
+	handler.failTenant = "t2"
+	handler.resulting["o1"] = 3
+	if err := scanner.Scan(ctx); err == nil {
+		t.Fatal("scan with a failing tenant returned no error")
+	}
+	collectionId := "c-t2"
+	gap, err = GetReplicationGap(scanner, nil, &collectionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gap.UnderReplicatedObjects != 1 || gap.MissingQuality != 2 {
+		t.Errorf("gap of the failing tenant = %+v, want the one of the scan before", gap)
+	}
+	gap, err = GetReplicationGap(scanner, &tenantId, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gap.UnderReplicatedObjects != 1 {
+		t.Errorf("gap of the scanned tenant = %+v, want 1 under-replicated object", gap)
+	}
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	"github.com/ocfl-archive/dlza-manager-clerk/policy"
+	"github.com/ocfl-archive/dlza-manager-clerk/validation"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+	dlzamodels "github.com/ocfl-archive/dlza-manager/models"
+)
+
+// redactedConnection is returned instead of the values of the connection json, which may hold secrets
+const redactedConnection = "xxxxxxxxxxxxxx"
+
+// storageConnection is the connection json of a storage location. The storage handler reads it as
+// dlzamodels.Connection, the settings of an S3 or SFTP backend are kept next to the folder. Other fields are kept
+// as they were saved.
+type storageConnection struct {
+	dlzamodels.Connection
+	S3    *s3Connection
+	SFTP  *sftpConnection
+	other map[string]json.RawMessage
+}
+
+// s3Connection are the settings of an S3 backend, the secret access key is never returned
+type s3Connection struct {
+	Endpoint        string `json:"endpoint"`
+	Bucket          string `json:"bucket"`
+	Region          string `json:"region,omitempty"`
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty"`
+}
+
+// sftpConnection are the settings of an SFTP backend, password and private key are never returned
+type sftpConnection struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	User       string `json:"user"`
+	Password   string `json:"password,omitempty"`
+	PrivateKey string `json:"privateKey,omitempty"`
+}
+
+// defaultSFTPPort is used for SFTP backends without a port
+const defaultSFTPPort = 22
+
+// parseStorageConnection reads the connection json of a storage location, which has to be a json object
+func parseStorageConnection(raw string) (*storageConnection, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return nil, err
+	}
+	connection := &storageConnection{other: fields}
+	if err := json.Unmarshal([]byte(raw), &connection.Connection); err != nil {
+		return nil, err
+	}
+	delete(connection.other, "folder")
+	if data, ok := fields["s3"]; ok {
+		connection.S3 = &s3Connection{}
+		if err := json.Unmarshal(data, connection.S3); err != nil {
+			return nil, errors.Wrap(err, "cannot read s3 settings")
+		}
+		delete(connection.other, "s3")
+	}
+	if data, ok := fields["sftp"]; ok {
+		connection.SFTP = &sftpConnection{}
+		if err := json.Unmarshal(data, connection.SFTP); err != nil {
+			return nil, errors.Wrap(err, "cannot read sftp settings")
+		}
+		delete(connection.other, "sftp")
+	}
+	return connection, nil
+}
+
+func (c *storageConnection) marshal() (string, error) {
+	fields := maps.Clone(c.other)
+	if fields == nil {
+		fields = map[string]json.RawMessage{}
+	}
+	values := map[string]any{"folder": c.Folder}
+	if c.S3 != nil {
+		values["s3"] = c.S3
+	}
+	if c.SFTP != nil {
+		values["sftp"] = c.SFTP
+	}
+	for field, value := range values {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		fields[field] = data
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// backend names the kind of storage the connection points to
+func (c *storageConnection) backend() model.StorageBackend {
+	switch {
+	case c.S3 != nil:
+		return model.StorageBackendS3
+	case c.SFTP != nil:
+		return model.StorageBackendSftp
+	}
+	return model.StorageBackendFilesystem
+}
+
+// applyInput sets the connection to the backend of the input. The secrets left out of the input are taken from
+// the stored settings of the same backend, the settings of another backend are dropped.
+func (c *storageConnection) applyInput(input *model.StorageConnectionInput) {
+	c.Folder = strings.TrimSpace(input.Folder)
+	stored := *c
+	c.S3, c.SFTP = nil, nil
+	if input.S3 != nil {
+		c.S3 = &s3Connection{
+			Endpoint:        strings.TrimSpace(input.S3.Endpoint),
+			Bucket:          strings.TrimSpace(input.S3.Bucket),
+			Region:          strings.TrimSpace(valueOf(input.S3.Region)),
+			AccessKeyID:     strings.TrimSpace(input.S3.AccessKeyID),
+			SecretAccessKey: valueOf(input.S3.SecretAccessKey),
+		}
+		if c.S3.SecretAccessKey == "" && stored.S3 != nil {
+			c.S3.SecretAccessKey = stored.S3.SecretAccessKey
+		}
+	}
+	if input.Sftp != nil {
+		c.SFTP = &sftpConnection{
+			Host:       strings.TrimSpace(input.Sftp.Host),
+			Port:       defaultSFTPPort,
+			User:       strings.TrimSpace(input.Sftp.User),
+			Password:   valueOf(input.Sftp.Password),
+			PrivateKey: valueOf(input.Sftp.PrivateKey),
+		}
+		if input.Sftp.Port != nil {
+			c.SFTP.Port = *input.Sftp.Port
+		}
+		if c.SFTP.Password == "" && c.SFTP.PrivateKey == "" && stored.SFTP != nil {
+			c.SFTP.Password = stored.SFTP.Password
+			c.SFTP.PrivateKey = stored.SFTP.PrivateKey
+		}
+	}
+}
+
+func valueOf(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// storageLocationConnection resolves the connection json to save for the input and the parsed connection to
+// validate. The parsed connection is nil if an update keeps the stored connection.
+// connectionConfig sets the folder and the backend, the secrets and other fields of the stored connection are kept.
+func storageLocationConnection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, input *model.StorageLocationInput, update bool) (string, *storageConnection, error) {
+	var storedRaw string
+	if update && input.ID != "" {
+		storageLocationPb, err := clientClerkHandler.GetStorageLocationById(ctx, &pb.Id{Id: input.ID})
+		if err != nil {
+			return "", nil, lookupError(err, policy.KindStorageLocation, input.ID, "GetStorageLocationById")
+		}
+		storedRaw = storageLocationPb.Connection
+	}
+	var connection *storageConnection
+	switch {
+	case input.ConnectionConfig != nil:
+		connection = &storageConnection{}
+		if stored, err := parseStorageConnection(storedRaw); err == nil {
+			connection = stored
+		}
+		connection.applyInput(input.ConnectionConfig)
+	case input.Connection != nil && *input.Connection != "" && *input.Connection != redactedConnection:
+		parsed, err := parseStorageConnection(*input.Connection)
+		if err != nil {
+			return "", nil, &validation.Errors{Input: "storage location input", Fields: []validation.FieldError{
+				{Field: "connection", Rule: "json", Message: "is no json object: " + err.Error()},
+			}}
+		}
+		connection = parsed
+	default:
+		return storedRaw, nil, nil
+	}
+	data, err := connection.marshal()
+	if err != nil {
+		return "", nil, err
+	}
+	return data, connection, nil
+}
+
+// storageConnectionRules checks the fields the storage handler needs for the backend
+func storageConnectionRules(connection *storageConnection) []validation.FieldRules {
+	fields := []validation.FieldRules{
+		validation.Field("connectionConfig.folder", connection.Folder, validation.Required),
+		validation.Field("connectionConfig", connection, validation.Func("oneOf", func(value any) string {
+			if c := value.(*storageConnection); c.S3 != nil && c.SFTP != nil {
+				return "must set at most one of s3 and sftp"
+			}
+			return ""
+		})),
+	}
+	if s3 := connection.S3; s3 != nil {
+		fields = append(fields,
+			validation.Field("connectionConfig.s3.endpoint", s3.Endpoint, validation.Required),
+			validation.Field("connectionConfig.s3.bucket", s3.Bucket, validation.Required),
+			validation.Field("connectionConfig.s3.accessKeyId", s3.AccessKeyID, validation.Required),
+			validation.Field("connectionConfig.s3.secretAccessKey", s3.SecretAccessKey, validation.Required),
+		)
+	}
+	if sftp := connection.SFTP; sftp != nil {
+		fields = append(fields,
+			validation.Field("connectionConfig.sftp.host", sftp.Host, validation.Required),
+			validation.Field("connectionConfig.sftp.port", sftp.Port, validation.Func("range", func(value any) string {
+				if port := value.(int); port < 1 || port > 65535 {
+					return fmt.Sprintf("%d is no port", port)
+				}
+				return ""
+			})),
+			validation.Field("connectionConfig.sftp.user", sftp.User, validation.Required),
+			validation.Field("connectionConfig.sftp.password", sftp, validation.Func("required", func(value any) string {
+				if c := value.(*sftpConnection); c.Password == "" && c.PrivateKey == "" {
+					return "or privateKey must be set"
+				}
+				return ""
+			})),
+		)
+	}
+	return fields
+}
+
+// storageConnectionToGraphQlStorageConnection shows the folder, the backend settings without their secrets and
+// the names of the other fields, nil if the connection cannot be read
+func storageConnectionToGraphQlStorageConnection(raw string) *model.StorageConnection {
+	connection, err := parseStorageConnection(raw)
+	if err != nil {
+		return nil
+	}
+	result := &model.StorageConnection{
+		Folder:      connection.Folder,
+		Backend:     connection.backend(),
+		OtherFields: slices.Sorted(maps.Keys(connection.other)),
+	}
+	if s3 := connection.S3; s3 != nil {
+		result.S3 = &model.S3Connection{
+			Endpoint:           s3.Endpoint,
+			Bucket:             s3.Bucket,
+			Region:             s3.Region,
+			AccessKeyID:        s3.AccessKeyID,
+			HasSecretAccessKey: s3.SecretAccessKey != "",
+		}
+	}
+	if sftp := connection.SFTP; sftp != nil {
+		result.Sftp = &model.SFTPConnection{
+			Host:          sftp.Host,
+			Port:          sftp.Port,
+			User:          sftp.User,
+			HasPassword:   sftp.Password != "",
+			HasPrivateKey: sftp.PrivateKey != "",
+		}
+	}
+	return result
+}
+
+// RedactConnection replaces the secrets of the backend and the values of the other fields of the connection json
+// of a storage location for the REST API and the audit trail
+func RedactConnection(raw string) string {
+	connection, err := parseStorageConnection(raw)
+	if err != nil {
+		return redactedConnection
+	}
+	redacted, _ := json.Marshal(redactedConnection)
+	for field := range connection.other {
+		connection.other[field] = redacted
+	}
+	if s3 := connection.S3; s3 != nil && s3.SecretAccessKey != "" {
+		s3.SecretAccessKey = redactedConnection
+	}
+	if sftp := connection.SFTP; sftp != nil {
+		if sftp.Password != "" {
+			sftp.Password = redactedConnection
+		}
+		if sftp.PrivateKey != "" {
+			sftp.PrivateKey = redactedConnection
+		}
+	}
+	data, err := connection.marshal()
+	if err != nil {
+		return redactedConnection
+	}
+	return data
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+)
+
+func TestApplyConnectionInput(t *testing.T) {
+	secret := "secret"
+	other := "other"
+	stored := `{"folder":"/old","s3":{"endpoint":"s3.example.org","bucket":"old","accessKeyId":"key","secretAccessKey":"stored"},"vault":"v1"}`
+	tests := []struct {
+		name       string
+		input      model.StorageConnectionInput
+		backend    model.StorageBackend
+		wantSecret string
+	}{
+		{"keeps the stored secret", model.StorageConnectionInput{Folder: "/new", S3: &model.S3ConnectionInput{Endpoint: "s3.example.org", Bucket: "new", AccessKeyID: "key"}}, model.StorageBackendS3, "stored"},
+		{"replaces the secret", model.StorageConnectionInput{Folder: "/new", S3: &model.S3ConnectionInput{Endpoint: "s3.example.org", Bucket: "new", AccessKeyID: "key", SecretAccessKey: &secret}}, model.StorageBackendS3, "secret"},
+		{"switches to sftp", model.StorageConnectionInput{Folder: "/new", Sftp: &model.SFTPConnectionInput{Host: "sftp.example.org", User: "dlza", Password: &other}}, model.StorageBackendSftp, ""},
+		{"switches to the filesystem", model.StorageConnectionInput{Folder: "/new"}, model.StorageBackendFilesystem, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connection, err := parseStorageConnection(stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			connection.applyInput(&tt.input)
+			if backend := connection.backend(); backend != tt.backend {
+				t.Fatalf("backend = %s, want %s", backend, tt.backend)
+			}
+			if connection.S3 != nil && connection.S3.SecretAccessKey != tt.wantSecret {
+				t.Errorf("secretAccessKey = %q, want %q", connection.S3.SecretAccessKey, tt.wantSecret)
+			}
+			if connection.SFTP != nil && connection.SFTP.Port != defaultSFTPPort {
+				t.Errorf("port = %d, want %d", connection.SFTP.Port, defaultSFTPPort)
+			}
+			if _, ok := connection.other["vault"]; !ok {
+				t.Error("other fields of the stored connection are dropped")
+			}
+		})
+	}
+}
+
+func TestRedactConnection(t *testing.T) {
+	redacted := RedactConnection(`{"folder":"/data","sftp":{"host":"sftp.example.org","port":22,"user":"dlza","privateKey":"key"},"token":"abc"}`)
+	for _, secret := range []string{`"key"`, `"abc"`} {
+		if strings.Contains(redacted, secret) {
+			t.Errorf("RedactConnection kept %s: %s", secret, redacted)
+		}
+	}
+	if !strings.Contains(redacted, `"sftp.example.org"`) || !strings.Contains(redacted, `"/data"`) {
+		t.Errorf("RedactConnection dropped host or folder: %s", redacted)
+	}
+	graphQl := storageConnectionToGraphQlStorageConnection(`{"folder":"/data","s3":{"endpoint":"e","bucket":"b","accessKeyId":"k","secretAccessKey":"s"}}`)
+	if graphQl.Backend != model.StorageBackendS3 || !graphQl.S3.HasSecretAccessKey {
+		t.Errorf("StorageConnection = %+v, want an s3 backend with a secret", graphQl)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/graph/model"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+	pbHandler "github.com/ocfl-archive/dlza-manager-handler/handlerproto"
+	pbStorageHandler "github.com/ocfl-archive/dlza-manager-storage-handler/storagehandlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const storageProbeTimeout = 30 * time.Second
+
+// TestStorageLocationConnection checks the input like a create or update and probes its connection without saving
+func TestStorageLocationConnection(ctx context.Context, clientClerkHandler pbHandler.ClerkHandlerServiceClient, clientClerkStorageHandler pbStorageHandler.ClerkStorageHandlerServiceClient, input *model.StorageLocationInput, conf models.ValidationConfig) (*model.StorageConnectionTest, error) {
+	update := input != nil && input.ID != ""
+	connection, err := validateStorageLocationInput(ctx, clientClerkHandler, input, conf, update)
+	if err != nil {
+		return nil, err
+	}
+	return probeStorageConnection(ctx, clientClerkStorageHandler, connection), nil
+}
+
+// probeStorageConnection lets the storage handler create the root folder of the connection, the same call
+// creating a partition starts with. The folder is kept, partitions are created below it.
+func probeStorageConnection(ctx context.Context, clientClerkStorageHandler pbStorageHandler.ClerkStorageHandlerServiceClient, raw string) *model.StorageConnectionTest {
+	result := &model.StorageConnectionTest{}
+	connection, err := parseStorageConnection(raw)
+	if err != nil {
+		message := "cannot read connection: " + err.Error()
+		result.Error = &message
+		return result
+	}
+	result.Path = connection.Folder
+	probeCtx, cancel := context.WithTimeout(ctx, storageProbeTimeout)
+	defer cancel()
+	start := time.Now()
+	statusPb, err := clientClerkStorageHandler.CreateFolder(probeCtx, &pb.Id{Id: connection.Folder})
+	result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+	switch {
+	case err != nil:
+		message := err.Error()
+		result.Error = &message
+		result.PermissionDenied = status.Code(err) == codes.PermissionDenied || strings.Contains(strings.ToLower(message), "permission denied")
+	case !statusPb.Ok:
+		message := "storage handler could not create folder " + connection.Folder
+		result.Error = &message
+	default:
+		result.Ok = true
+	}
+	return result
+}
+
+// verifyStorageConnection refuses a connection the probe fails for
+func verifyStorageConnection(ctx context.Context, clientClerkStorageHandler pbStorageHandler.ClerkStorageHandlerServiceClient, alias string, raw string) error {
+	result := probeStorageConnection(ctx, clientClerkStorageHandler, raw)
+	if result.Ok {
+		return nil
+	}
+	return errors.Errorf("Storage location %s failed the connection test: %s", alias, *result.Error)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	pbStorageHandler "github.com/ocfl-archive/dlza-manager-storage-handler/storagehandlerproto"
+	pb "github.com/ocfl-archive/dlza-manager/dlzamanagerproto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type probeStorageHandler struct {
+	pbStorageHandler.ClerkStorageHandlerServiceClient
+	folder string
+	status *pb.Status
+	err    error
+}
+
+func (h *probeStorageHandler) CreateFolder(_ context.Context, in *pb.Id, _ ...grpc.CallOption) (*pb.Status, error) {
+	h.folder = in.Id
+	return h.status, h.err
+}
+
+func TestProbeStorageConnection(t *testing.T) {
+	tests := []struct {
+		name             string
+		handler          *probeStorageHandler
+		ok               bool
+		permissionDenied bool
+	}{
+		{"folder created", &probeStorageHandler{status: &pb.Status{Ok: true}}, true, false},
+		{"folder not created", &probeStorageHandler{status: &pb.Status{Ok: false}}, false, false},
+		{"permission denied", &probeStorageHandler{err: status.Error(codes.PermissionDenied, "no access")}, false, true},
+		{"unreachable", &probeStorageHandler{err: status.Error(codes.Unavailable, "connection refused")}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := probeStorageConnection(context.Background(), tt.handler, `{"folder":"/data/dlza"}`)
+			if tt.handler.folder != "/data/dlza" || result.Path != "/data/dlza" {
+				t.Errorf("probed folder %q, path %q, want /data/dlza", tt.handler.folder, result.Path)
+			}
+			if result.Ok != tt.ok || result.PermissionDenied != tt.permissionDenied {
+				t.Errorf("ok = %v, permissionDenied = %v, want %v, %v", result.Ok, result.PermissionDenied, tt.ok, tt.permissionDenied)
+			}
+			if !tt.ok && result.Error == nil {
+				t.Error("error is missing")
+			}
+		})
+	}
+}
+
+func TestVerifyStorageConnection(t *testing.T) {
+	err := verifyStorageConnection(context.Background(), &probeStorageHandler{status: &pb.Status{Ok: false}}, "sl1", `{"folder":"/data"}`)
+	if err == nil || !strings.Contains(err.Error(), "failed the connection test") {
+		t.Fatalf("err = %v, want a failed connection test", err)
+	}
+	if err := verifyStorageConnection(context.Background(), &probeStorageHandler{status: &pb.Status{Ok: true}}, "sl1", `{"folder":"/data"}`); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,158 @@
+package sessionstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"emperror.dev/errors"
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+)
+
+const (
+	// cookieChunkSize keeps every cookie with its attributes below the 4096 bytes browsers accept
+	cookieChunkSize = 3800
+	// maxCookieChunks bounds the cookies of one session, browsers keep about 50 cookies per domain
+	maxCookieChunks = 8
+)
+
+// cookieStore keeps the whole session in the browser. The values are gzip compressed, encrypted and signed
+// with the session keys. Sessions larger than one cookie, like the access and refresh token of keycloak,
+// are split into the cookies <name>, <name>_1, <name>_2, ...
+type cookieStore struct {
+	codecs  []securecookie.Codec
+	options *gsessions.Options
+}
+
+func newCookieStore(keyPairs ...[]byte) *cookieStore {
+	codecs := securecookie.CodecsFromPairs(keyPairs...)
+	for _, codec := range codecs {
+		if secureCookie, ok := codec.(*securecookie.SecureCookie); ok {
+			// the length is checked per chunk when the session is saved
+			secureCookie.MaxLength(0)
+			secureCookie.SetSerializer(gzipSerializer{})
+		}
+	}
+	return &cookieStore{
+		codecs:  codecs,
+		options: &gsessions.Options{Path: "/", Secure: true, HttpOnly: true, SameSite: http.SameSiteNoneMode},
+	}
+}
+
+func (s *cookieStore) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+	for _, codec := range s.codecs {
+		if secureCookie, ok := codec.(*securecookie.SecureCookie); ok {
+			secureCookie.MaxAge(options.MaxAge)
+		}
+	}
+}
+
+func (s *cookieStore) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+func (s *cookieStore) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	options := *s.options
+	session.Options = &options
+	session.IsNew = true
+	encoded := readChunks(r, name)
+	if encoded == "" {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, encoded, &session.Values, s.codecs...); err != nil {
+		return session, err
+	}
+	session.IsNew = false
+	return session, nil
+}
+
+func (s *cookieStore) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	name := session.Name()
+	previous := countChunks(r, name)
+	if session.Options.MaxAge < 0 {
+		for i := 0; i < max(previous, 1); i++ {
+			http.SetCookie(w, gsessions.NewCookie(chunkName(name, i), "", session.Options))
+		}
+		return nil
+	}
+	encoded, err := securecookie.EncodeMulti(name, session.Values, s.codecs...)
+	if err != nil {
+		return err
+	}
+	chunks := (len(encoded) + cookieChunkSize - 1) / cookieChunkSize
+	if chunks > maxCookieChunks {
+		return errors.Errorf("session %s needs %d bytes, more than %d cookies can hold", name, len(encoded), maxCookieChunks)
+	}
+	for i := 0; i < chunks; i++ {
+		chunk := encoded[i*cookieChunkSize : min((i+1)*cookieChunkSize, len(encoded))]
+		http.SetCookie(w, gsessions.NewCookie(chunkName(name, i), chunk, session.Options))
+	}
+	// drop the chunks of a larger session saved before
+	expired := *session.Options
+	expired.MaxAge = -1
+	for i := chunks; i < previous; i++ {
+		http.SetCookie(w, gsessions.NewCookie(chunkName(name, i), "", &expired))
+	}
+	return nil
+}
+
+func chunkName(name string, i int) string {
+	if i == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s_%d", name, i)
+}
+
+func countChunks(r *http.Request, name string) int {
+	count := 0
+	for ; count < maxCookieChunks; count++ {
+		if _, err := r.Cookie(chunkName(name, count)); err != nil {
+			break
+		}
+	}
+	return count
+}
+
+func readChunks(r *http.Request, name string) string {
+	var encoded strings.Builder
+	chunks := countChunks(r, name)
+	for i := 0; i < chunks; i++ {
+		cookie, _ := r.Cookie(chunkName(name, i))
+		encoded.WriteString(cookie.Value)
+	}
+	return encoded.String()
+}
+
+// gzipSerializer gob encodes the session values like the default serializer of securecookie and compresses them
+type gzipSerializer struct{}
+
+func (gzipSerializer) Serialize(src interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := gob.NewEncoder(writer).Encode(src); err != nil {
+		return nil, errors.Wrap(err, "cannot encode session")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "cannot compress session")
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipSerializer) Deserialize(src []byte, dst interface{}) error {
+	reader, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return errors.Wrap(err, "cannot decompress session")
+	}
+	defer reader.Close()
+	if err := gob.NewDecoder(reader).Decode(dst); err != nil {
+		return errors.Wrap(err, "cannot decode session")
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package sessionstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/memstore"
+	"github.com/gin-contrib/sessions/redis"
+	gsessions "github.com/gorilla/sessions"
+	"github.com/je4/utils/v2/pkg/zLogger"
+	"github.com/ocfl-archive/dlza-manager-clerk/models"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendCookie = "cookie"
+	BackendFile   = "file"
+	BackendRedis  = "redis"
+
+	DefaultName = "mysession"
+
+	// DefaultSweepInterval is the interval expired session files are removed in
+	DefaultSweepInterval = time.Hour
+	// DefaultSweepAge is the age session files without a maximum age are removed at
+	DefaultSweepAge = 30 * 24 * time.Hour
+
+	// sessionFilePrefix is the prefix gorilla gives the session files
+	sessionFilePrefix = "session_"
+)
+
+// NewStore creates the session store selected in the configuration.
+// Without configured keys, random keys are generated, which is only suitable for a single instance.
+func NewStore(conf models.SessionConfig) (sessions.Store, error) {
+	keyPairs, err := ParseKeys(conf.Keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyPairs) == 0 {
+		if conf.Backend != "" && conf.Backend != BackendMemory {
+			return nil, errors.Errorf("session backend %s needs at least one key pair", conf.Backend)
+		}
+		keyPairs, err = randomKeyPair()
+		if err != nil {
+			return nil, err
+		}
+	}
+	var store sessions.Store
+	switch conf.Backend {
+	case "", BackendMemory:
+		store = memstore.NewStore(keyPairs...)
+	case BackendCookie:
+		store = newCookieStore(keyPairs...)
+	case BackendFile:
+		if conf.File.Path == "" {
+			return nil, errors.New("session backend file needs a path")
+		}
+		fileStore := gsessions.NewFilesystemStore(conf.File.Path, keyPairs...)
+		// the session holds the access and refresh token, which exceed the default limit of 4096 bytes
+		fileStore.MaxLength(0)
+		store = &filesystemStore{FilesystemStore: fileStore, path: conf.File.Path}
+	case BackendRedis:
+		network := conf.Redis.Network
+		if network == "" {
+			network = "tcp"
+		}
+		poolSize := conf.Redis.PoolSize
+		if poolSize == 0 {
+			poolSize = 10
+		}
+		redisStore, err := redis.NewStoreWithDB(poolSize, network, conf.Redis.Addr, conf.Redis.Username, conf.Redis.Password, conf.Redis.DB, keyPairs...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot connect to session redis %s", conf.Redis.Addr)
+		}
+		rediStore, err := redis.GetRedisStore(redisStore)
+		if err != nil {
+			return nil, err
+		}
+		rediStore.SetMaxLength(0)
+		if conf.Redis.KeyPrefix != "" {
+			rediStore.SetKeyPrefix(conf.Redis.KeyPrefix)
+		}
+		store = redisStore
+	default:
+		return nil, errors.Errorf("unknown session backend %s", conf.Backend)
+	}
+	return store, nil
+}
+
+// ParseKeys decodes the "<authentication key>:<encryption key>" pairs into the flat list gorilla expects
+func ParseKeys(keys []string) ([][]byte, error) {
+	keyPairs := make([][]byte, 0, len(keys)*2)
+	for i, key := range keys {
+		authKey, encKey, _ := strings.Cut(strings.TrimSpace(key), ":")
+		authBytes, err := base64.StdEncoding.DecodeString(authKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot decode authentication key of session key pair %d", i)
+		}
+		if len(authBytes) < 32 {
+			return nil, errors.Errorf("authentication key of session key pair %d must have at least 32 bytes", i)
+		}
+		encBytes, err := base64.StdEncoding.DecodeString(encKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot decode encryption key of session key pair %d", i)
+		}
+		switch len(encBytes) {
+		case 16, 24, 32:
+		default:
+			return nil, errors.Errorf("encryption key of session key pair %d must have 16, 24 or 32 bytes", i)
+		}
+		keyPairs = append(keyPairs, authBytes, encBytes)
+	}
+	return keyPairs, nil
+}
+
+func randomKeyPair() ([][]byte, error) {
+	authKey := make([]byte, 64)
+	encKey := make([]byte, 32)
+	if _, err := rand.Read(authKey); err != nil {
+		return nil, errors.Wrap(err, "cannot generate session key")
+	}
+	if _, err := rand.Read(encKey); err != nil {
+		return nil, errors.Wrap(err, "cannot generate session key")
+	}
+	return [][]byte{authKey, encKey}, nil
+}
+
+// Sweeper is implemented by stores which keep expired sessions until they are removed
+type Sweeper interface {
+	Sweep() error
+	// Start sweeps in interval until ctx is done
+	Start(ctx context.Context, interval time.Duration, logger zLogger.ZLogger)
+}
+
+type filesystemStore struct {
+	*gsessions.FilesystemStore
+	path string
+}
+
+func (s *filesystemStore) Options(options sessions.Options) {
+	s.FilesystemStore.Options = options.ToGorillaOptions()
+	s.FilesystemStore.MaxAge(options.MaxAge)
+}
+
+// Sweep removes the session files which were not saved within the lifetime of a session. The file store
+// only refuses to decode them, without a sweep they are kept forever.
+func (s *filesystemStore) Sweep() error {
+	maxAge := time.Duration(s.FilesystemStore.Options.MaxAge) * time.Second
+	if maxAge <= 0 {
+		maxAge = DefaultSweepAge
+	}
+	entries, err := os.ReadDir(s.path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read session folder %s", s.path)
+	}
+	var errs error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), sessionFilePrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// removed by another instance in the meantime
+			continue
+		}
+		if time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.path, entry.Name())); err != nil && !os.IsNotExist(err) {
+			errs = errors.Append(errs, errors.Wrapf(err, "cannot remove expired session %s", entry.Name()))
+		}
+	}
+	return errs
+}
+
+func (s *filesystemStore) Start(ctx context.Context, interval time.Duration, logger zLogger.ZLogger) {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Sweep(); err != nil {
+				logger.Warn().Msgf("cannot sweep expired sessions: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,137 @@
+package statusowner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/ocfl-archive/dlza-manager-clerk/atomicfile"
+)
+
+// DefaultRetention is how long the owner of a status is kept if the configuration has no retention
+const DefaultRetention = 30 * 24 * time.Hour
+
+// Owner is the subject of the token which created a status
+type Owner struct {
+	Subject string    `json:"subject"`
+	Created time.Time `json:"created"`
+}
+
+// Store keeps the creators of the upload statuses, which are not linked to a collection. With a file the owners
+// survive a restart and are shared by the clerks using the same file, every change is merged into the file under
+// its lock. Without one they only live as long as the process.
+type Store struct {
+	lock      sync.Mutex
+	path      string
+	retention time.Duration
+	modTime   time.Time
+	owners    map[string]Owner
+}
+
+func NewStore(path string, retention time.Duration) (*Store, error) {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	s := &Store{path: path, retention: retention, owners: map[string]Owner{}}
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Persistent reports whether the owners are kept in a file
+func (s *Store) Persistent() bool {
+	return s.path != ""
+}
+
+// Owner returns the subject which created the status, an empty one if it is unknown
+func (s *Store) Owner(ctx context.Context, statusId string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.refresh(); err != nil {
+		return "", err
+	}
+	return s.owners[statusId].Subject, nil
+}
+
+// Put records subject as the creator of the status and drops the owners older than the retention
+func (s *Store) Put(ctx context.Context, statusId string, subject string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	owner := Owner{Subject: subject, Created: time.Now()}
+	if s.path == "" {
+		s.owners[statusId] = owner
+		s.prune(s.owners, owner.Created)
+		return nil
+	}
+	var owners map[string]Owner
+	err := atomicfile.Update(s.path, func(data []byte) ([]byte, error) {
+		var err error
+		owners, err = parse(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot parse status owner file %s", s.path)
+		}
+		owners[statusId] = owner
+		s.prune(owners, owner.Created)
+		return json.MarshalIndent(owners, "", "  ")
+	})
+	if err != nil {
+		return errors.Wrapf(err, "cannot write status owner file %s", s.path)
+	}
+	s.owners = owners
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+func (s *Store) prune(owners map[string]Owner, now time.Time) {
+	cutoff := now.Add(-s.retention)
+	for statusId, owner := range owners {
+		if owner.Created.Before(cutoff) {
+			delete(owners, statusId)
+		}
+	}
+}
+
+// refresh reads the file again if another clerk changed it since it was last read or written
+func (s *Store) refresh() error {
+	if s.path == "" {
+		return nil
+	}
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot read status owner file %s", s.path)
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return errors.Wrapf(err, "cannot read status owner file %s", s.path)
+	}
+	owners, err := parse(data)
+	if err != nil {
+		return errors.Wrapf(err, "cannot parse status owner file %s", s.path)
+	}
+	s.owners = owners
+	s.modTime = info.ModTime()
+	return nil
+}
+
+func parse(data []byte) (map[string]Owner, error) {
+	owners := map[string]Owner{}
+	if len(data) == 0 {
+		return owners, nil
+	}
+	if err := json.Unmarshal(data, &owners); err != nil {
+		return nil, err
+	}
+	return owners, nil
+}
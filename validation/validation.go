@@ -0,0 +1,129 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// FieldError is a rule violated by an input field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors holds all violations of an input. It is reported as one graphql error listing the fields
+// in its extensions.
+type Errors struct {
+	Input  string
+	Fields []FieldError
+}
+
+func (e *Errors) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+" "+field.Message)
+	}
+	return fmt.Sprintf("Invalid %s: %s", e.Input, strings.Join(messages, "; "))
+}
+
+// Rule checks a field value and returns the violation message, an empty message if the value is valid
+type Rule struct {
+	Name  string
+	Check func(value any) string
+}
+
+type FieldRules struct {
+	Field string
+	Value any
+	Rules []Rule
+}
+
+func Field(name string, value any, rules ...Rule) FieldRules {
+	return FieldRules{Field: name, Value: value, Rules: rules}
+}
+
+// Validate applies the rules of all fields and returns an *Errors with every violation, or nil.
+// Only the first violated rule of a field is reported.
+func Validate(input string, fields ...FieldRules) error {
+	result := &Errors{Input: input}
+	for _, field := range fields {
+		for _, rule := range field.Rules {
+			if message := rule.Check(field.Value); message != "" {
+				result.Fields = append(result.Fields, FieldError{Field: field.Field, Rule: rule.Name, Message: message})
+				break
+			}
+		}
+	}
+	if len(result.Fields) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Func makes a rule of a check which is not covered by the predefined ones, e.g. a lookup of the handler
+func Func(name string, check func(value any) string) Rule {
+	return Rule{Name: name, Check: check}
+}
+
+var Required = Rule{Name: "required", Check: func(value any) string {
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Pointer && reflect.ValueOf(value).IsNil()) {
+		return "must not be empty"
+	}
+	if strings.TrimSpace(fmt.Sprint(value)) == "" {
+		return "must not be empty"
+	}
+	return ""
+}}
+
+// Email accepts addresses and empty values, combine it with Required for mandatory addresses
+var Email = Rule{Name: "email", Check: func(value any) string {
+	if strings.TrimSpace(fmt.Sprint(value)) == "" {
+		return ""
+	}
+	if _, err := mail.ParseAddress(fmt.Sprint(value)); err != nil {
+		return fmt.Sprintf("%q is not a valid address", value)
+	}
+	return ""
+}}
+
+var NonNegative = Rule{Name: "min", Check: func(value any) string {
+	if number, ok := value.(int); ok && number < 0 {
+		return "must not be negative"
+	}
+	return ""
+}}
+
+// Match accepts strings matching pattern, description tells the caller what is expected
+func Match(pattern *regexp.Regexp, description string) Rule {
+	return Rule{Name: "pattern", Check: func(value any) string {
+		if !pattern.MatchString(fmt.Sprint(value)) {
+			return fmt.Sprintf("%q %s", value, description)
+		}
+		return ""
+	}}
+}
+
+// OneOf accepts the allowed values regardless of case, an empty list accepts any value
+func OneOf(allowed ...string) Rule {
+	return Rule{Name: "oneOf", Check: func(value any) string {
+		if len(allowed) == 0 || slices.ContainsFunc(allowed, func(a string) bool { return strings.EqualFold(a, fmt.Sprint(value)) }) {
+			return ""
+		}
+		return fmt.Sprintf("%q is none of %s", value, strings.Join(allowed, ", "))
+	}}
+}
+
+// Unique rejects values used by another entity than id, taken maps the values in use to the ids of their entities
+func Unique(taken map[string]string, id string) Rule {
+	return Rule{Name: "unique", Check: func(value any) string {
+		if owner, ok := taken[fmt.Sprint(value)]; ok && owner != id {
+			return fmt.Sprintf("%q is already used by %s", value, owner)
+		}
+		return ""
+	}}
+}
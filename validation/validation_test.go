@@ -0,0 +1,70 @@
+package validation
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	var nilString *string
+	alias := regexp.MustCompile(`^[a-z0-9-]+$`)
+	tests := []struct {
+		name  string
+		rule  Rule
+		value any
+		valid bool
+	}{
+		{"required value", Required, "archive", true},
+		{"required blank", Required, "  ", false},
+		{"required nil pointer", Required, nilString, false},
+		{"email", Email, "archive@example.org", true},
+		{"email empty", Email, "", true},
+		{"email invalid", Email, "archive", false},
+		{"non negative", NonNegative, 0, true},
+		{"negative", NonNegative, -1, false},
+		{"match", Match(alias, "should be lower case"), "archive-1", true},
+		{"no match", Match(alias, "should be lower case"), "Archive", false},
+		{"one of any case", OneOf("HIGH", "LOW"), "high", true},
+		{"none of", OneOf("HIGH", "LOW"), "medium", false},
+		{"one of empty list", OneOf(), "medium", true},
+		{"unique unused", Unique(map[string]string{"archive": "1"}, "2"), "backup", true},
+		{"unique own value", Unique(map[string]string{"archive": "1"}, "1"), "archive", true},
+		{"unique taken", Unique(map[string]string{"archive": "1"}, "2"), "archive", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := tt.rule.Check(tt.value)
+			if (message == "") != tt.valid {
+				t.Errorf("%s(%v) = %q, want valid %v", tt.rule.Name, tt.value, message, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	err := Validate("tenant input",
+		Field("alias", "", Required, Match(regexp.MustCompile(`^[a-z]+$`), "should be lower case")),
+		Field("email", "archive", Email),
+		Field("name", "Archive", Required),
+	)
+	var invalid *Errors
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Validate error = %v, want *Errors", err)
+	}
+	want := []FieldError{
+		{Field: "alias", Rule: "required", Message: "must not be empty"},
+		{Field: "email", Rule: "email", Message: `"archive" is not a valid address`},
+	}
+	if len(invalid.Fields) != len(want) {
+		t.Fatalf("got violations %+v, want %+v", invalid.Fields, want)
+	}
+	for i := range want {
+		if invalid.Fields[i] != want[i] {
+			t.Errorf("violation %d = %+v, want %+v", i, invalid.Fields[i], want[i])
+		}
+	}
+	if err := Validate("tenant input", Field("name", "Archive", Required)); err != nil {
+		t.Errorf("Validate of a valid input = %v, want nil", err)
+	}
+}